@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/internal/yaml"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// BatchJob is a single mapping run listed in a batch file
+type BatchJob struct {
+	root    string
+	output  string
+	profile string             // Pattern file used instead of the root's own files
+	mode    mapper.PatternType // Whether the profile holds ignore or filter patterns
+}
+
+// loadBatchFile reads the job list from a batch file. Relative paths are
+// resolved against the batch file's directory.
+//
+//	jobs:
+//	  - root: ./services/api
+//	    profile: profiles/backend.ignore
+//	    output: out/api.txt
+//	  - root: ./services/web
+//	    profile: profiles/frontend.filter
+//	    mode: filter
+//
+// An output of - writes the job's snapshot to stdout.
+func loadBatchFile(filename string) ([]BatchJob, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch file %s: %v", filename, err)
+	}
+
+	doc, err := yaml.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing batch file %s: %v", filename, err)
+	}
+
+	// Accept either a top-level list or a "jobs" key
+	items, ok := doc.([]any)
+	if !ok {
+		m, _ := doc.(map[string]any)
+		items, ok = m["jobs"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("batch file %s has no jobs list", filename)
+		}
+	}
+
+	baseDir := filepath.Dir(filename)
+	resolve := func(path string) string {
+		path = mapper.ExpandEnv(path)
+		if path == "" || path == "-" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+
+	jobs := make([]BatchJob, 0, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("job %d: expected a mapping", i+1)
+		}
+
+		job := BatchJob{mode: mapper.Ignore}
+		for key, value := range fields {
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("job %d: %s must be a string", i+1, key)
+			}
+			switch key {
+			case "root":
+				job.root = resolve(str)
+			case "output":
+				job.output = resolve(str)
+			case "profile":
+				job.profile = resolve(str)
+			case "mode":
+				switch strings.ToLower(str) {
+				case "ignore":
+					job.mode = mapper.Ignore
+				case "filter":
+					job.mode = mapper.Filter
+				default:
+					return nil, fmt.Errorf("job %d: unknown mode %q", i+1, str)
+				}
+			default:
+				return nil, fmt.Errorf("job %d: unknown key %q", i+1, key)
+			}
+		}
+
+		if job.root == "" {
+			return nil, fmt.Errorf("job %d: root is required", i+1)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// runBatch executes every job of a batch file, continuing past failures
+func runBatch(filename string, opts *cliOptions) error {
+	if opts.gitattrs != "" {
+		return errors.New("--suggest-gitattributes cannot be combined with --batch")
+	}
+	jobs, err := loadBatchFile(filename)
+	if err != nil {
+		return err
+	}
+
+	cache := mapper.NewSharedCache()
+	failed, worst := 0, 0
+	for i, job := range jobs {
+		if opts.ctx.Err() != nil {
+			return errInterrupted
+		}
+		if job.output == "" {
+			job.output = filepath.Join(job.root, "project_structure"+opts.format.Extension())
+		}
+		_, status, err := runBatchJob(job, opts, cache)
+		if err != nil {
+			slog.Error(strings.TrimSuffix(i18n.Default.Sprintf("Error in job %d (%s): %v\n", i+1, job.root, err), "\n"), "job", i+1, "root", job.root, "err", err)
+			failed++
+			continue
+		}
+		done, output := os.Stdout, job.output
+		if output == "-" {
+			done, output = os.Stderr, "stdout"
+		}
+		i18n.Default.Fprintf(done, "Job %d: %s written to %s\n", i+1, job.root, output)
+		worst = max(worst, status)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+	}
+	return statusError(worst)
+}
+
+// runBatchJob maps a single job's root using its profile or the root's own
+// pattern files, returning its tree and the exit code of the completed job
+func runBatchJob(job BatchJob, opts *cliOptions, cache *mapper.SharedCache) (*mapper.Tree, int, error) {
+	warned := warnings.Load()
+	root, err := filepath.Abs(job.root)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error resolving root: %v", err)
+	}
+
+	patterns := mapper.NewPatterns(root, mapper.Ignore)
+	var filter *mapper.PatternList
+	switch {
+	case opts.noPatterns:
+		// Only the --ignore and --include patterns apply
+	case job.profile != "":
+		patterns, err = cache.PatternsFor(job.profile, root, job.mode)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error initializing patterns: %v", err)
+		}
+	default:
+		patterns, filter, err = mapper.LoadPatternFiles(root, false)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	jobOpts := *opts
+	if err := applyPatternFlags(patterns, filter, root, &jobOpts); err != nil {
+		return nil, 0, err
+	}
+	if err := prepareRun(root, &jobOpts); err != nil {
+		return nil, 0, err
+	}
+	jobOpts.Cache = cache
+
+	// Status messages must not mix with a snapshot written to stdout
+	output, status := job.output, os.Stdout
+	if output == "-" {
+		output, status = "", os.Stderr
+	}
+	tree, err := writeSnapshot(root, output, &jobOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := finishRun(tree, root, status, &jobOpts); err != nil {
+		return nil, 0, err
+	}
+	return tree, completedStatus(tree, warnings.Load()-warned), nil
+}
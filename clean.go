@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// runCleanCommand removes what runs leave behind: the temporary files of a
+// checkpoint, index or cache save that did not finish, incomplete outputs
+// kept with --keep-partial, and with --checkpoint the checkpoint of a run
+// that will not be resumed. With --all the index and cache go too.
+func runCleanCommand(args []string) error {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", "", "directory whose index and cache are cleaned (default: current directory)")
+	fs.StringVar(&opts.output, "output", "", "also remove `file`.partial (default: the project_structure*.partial files in the current directory)")
+	fs.StringVar(&opts.checkpoint, "checkpoint", "", "remove the checkpoint `file` of an interrupted run")
+	fs.StringVar(&opts.indexFile, "index", "", "index `file` (default: .project_structure_index in the root)")
+	fs.StringVar(&opts.cacheFile, "cache-file", "", "cache `file` (default: .project_structure_cache in the root)")
+	all := fs.Bool("all", false, "also remove the index and the cache")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "list the files that would be removed without removing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+
+	index, cache := indexPath(root, opts), cachePath(root, opts)
+	paths := []string{index + ".tmp", cache + ".tmp"}
+	if opts.checkpoint != "" {
+		checkpoint := mapper.ExpandEnv(opts.checkpoint)
+		paths = append(paths, checkpoint, checkpoint+".tmp")
+	}
+	if *all {
+		paths = append(paths, index, cache)
+	}
+	if opts.output != "" && opts.output != "-" {
+		paths = append(paths, mapper.ExpandEnv(opts.output)+".partial")
+	} else {
+		partials, _ := filepath.Glob("project_structure*.partial")
+		paths = append(paths, partials...)
+	}
+
+	removed := 0
+	for _, path := range paths {
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if !opts.dryRun {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("error removing %s: %v", path, err)
+			}
+		}
+		fmt.Println(path)
+		removed++
+	}
+	switch {
+	case removed == 0:
+		i18n.Default.Fprintf(os.Stdout, "Nothing to clean\n")
+	case opts.dryRun:
+		i18n.Default.Fprintf(os.Stdout, "%d files would be removed\n", removed)
+	default:
+		i18n.Default.Fprintf(os.Stdout, "Removed %d files\n", removed)
+	}
+	return nil
+}
@@ -0,0 +1,1061 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// cliOptions holds the settings collected from the command line
+type cliOptions struct {
+	mapper.Options
+	root         string          // Directory to map, defaults to the working directory
+	output       string          // Output file, "-" for stdout
+	container    bool            // Run with container conventions, see container.go
+	batchFile    string          // Run the jobs listed in this batch file
+	ruleStats    bool            // Report how many entries each rule matched
+	gitattrs     string          // Write suggested .gitattributes entries to this file
+	format       mapper.Format   // Output format
+	checkpoint   string          // Progress file used to resume interrupted runs
+	quiet        bool            // Do not show the progress line
+	dryRun       bool            // List what would be mapped instead of writing the output
+	keepPartial  bool            // Keep the output of an interrupted run as <output>.partial
+	ctx          context.Context // Cancelled when the run is interrupted
+	args         []string        // Command line of the run, identifies its checkpoint
+	ignore       stringList      // Patterns from --ignore, added after the pattern file
+	include      stringList      // Patterns from --include, entries must also match one
+	onlyExt      stringList      // Extensions from --only-ext, comma-separated
+	noPatterns   bool            // Ignore the pattern files, using only --ignore and --include
+	indexFile    string          // Word index read by --query and written by the index command
+	cache        bool            // Keep a content cache between runs
+	cacheFile    string          // Where the content cache is kept
+	bothFiles    bool            // Both pattern files exist, the filter file applying as Include
+	configs      []*Config       // User and project configuration, in increasing precedence
+	patternFile  string          // Ignore file used instead of the root's pattern files
+	filterFile   string          // Filter file used instead of the root's pattern files
+	embedURL     string          // OpenAI-compatible embeddings endpoint
+	embedModel   string          // Model requested from embedURL
+	noDefaults   bool            // Start from empty built-in skip lists
+	addSkip      stringList      // Entries added to the built-in skip lists
+	removeSkip   stringList      // Entries removed from the built-in skip lists
+	profiles     stringList      // Operating system skip profiles added to the built-in lists
+	logFormat    string          // Format of log records on stderr, text or json
+	verbosity    int             // 1 logs skipped directories, 2 every skipped entry
+	hidden       bool            // Map dotfiles even when the built-in lists name them
+	noHidden     bool            // Skip every dotfile and dot-directory
+	annotations  stringList      // Sidecar JSON files of annotations merged into the output
+	sarif        stringList      // SARIF logs whose findings are counted per file
+	follow       bool            // Walk symlinked directories instead of listing symlinks
+	tokenizer    string          // tiktoken ranks file counting tokens exactly
+	tokenizerCmd string          // Command counting tokens, see mapper.ExecTokenizer
+	splitSize    sizeFlag        // Largest part of a split snapshot, in bytes
+	splitTokens  int64           // Largest part of a split snapshot, in tokens
+}
+
+// stringList is a flag that may be repeated, collecting every value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// levelFlag is a boolean flag raising a verbosity to its level, so -v and
+// -vv can be given as separate flags
+type levelFlag struct {
+	verbosity *int
+	level     int
+}
+
+func (f levelFlag) String() string {
+	return ""
+}
+
+func (f levelFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on && *f.verbosity < f.level {
+		*f.verbosity = f.level
+	}
+	return nil
+}
+
+func (f levelFlag) IsBoolFlag() bool {
+	return true
+}
+
+// printUsage lists the available subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, `Usage: directory-mapper <command> [flags]
+
+Commands:
+  map                write the structure and file contents (default)
+  tree               write only the directory structure
+  explain <path>...  show why a path is included or excluded
+  init               create a pattern file with examples
+  index              build or update the word index used by search and --query
+  search <query>     list the indexed files most relevant to a query
+  clean              remove leftover temporary files, checkpoints and partial outputs
+  compare <a> <b>    report the structural differences between two projects
+  conform <template> check which paths of a template are missing or extra in the project
+  org <repo>...      map many repositories and summarize them together
+  history            show the totals of earlier runs made with --cache
+  verify <file>...   check snapshots written with --footer for truncation
+  schema [format]    print the schema of the xml (default) or protobuf format
+
+Run "directory-mapper <command> -h" for the flags of a command.`)
+}
+
+// newFlagSet creates the flag set of a subcommand with the shared scanning flags
+func newFlagSet(name string, opts *cliOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", "", "directory to map (default: current directory)")
+	fs.BoolVar(&opts.container, "container", false, "run with container conventions: read /src, write to /out or stdout, and warn about unreadable files with their owner, exiting with status 2")
+	fs.Var(levelFlag{&opts.verbosity, 1}, "v", "log every skipped directory with the rule that decided it to stderr")
+	fs.Var(levelFlag{&opts.verbosity, 2}, "vv", "log every skipped file and directory with the rule that decided it to stderr")
+	fs.Var(levelFlag{&opts.verbosity, verboseDirs}, "verbose", "same as -v")
+	fs.Var(levelFlag{&opts.verbosity, verboseDebug}, "debug", "log every skipped entry, the directories walked and how long each phase took")
+	fs.StringVar(&opts.logFormat, "log-format", "text", "`format` of warnings and logs on stderr: text, or json for one object per line with level, message and attributes")
+	fs.StringVar(&opts.Language, "lang", "", "`language` of messages and output labels: en, es or ja (default: from LC_ALL, LC_MESSAGES or LANG)")
+	fs.BoolVar(&opts.IgnoreCase, "ignore-case", runtime.GOOS == "windows" || runtime.GOOS == "darwin", "match patterns and .gitignore files case-insensitively (default true on Windows and macOS)")
+	fs.Var(&opts.Normalize, "normalize", "bring file names and patterns into Unicode normal `form` nfc or nfd before matching and output, so names written on macOS match patterns written elsewhere (default none)")
+	fs.IntVar(&opts.MaxFiles, "max-files", 0, "map at most N files, listing later entries as an ellipsis (0 for no limit)")
+	fs.IntVar(&opts.MaxEntriesPerDir, "max-entries-per-dir", 0, "show at most N entries per directory, the rest as an ellipsis (0 for no limit)")
+	fs.Int64Var(&opts.MaxFileSize, "max-file-size", 0, "list files larger than N bytes without their content (0 for the default of 50 MB)")
+	fs.IntVar(&opts.HeadLines, "head-lines", 0, "include the first N lines of text files over --max-file-size instead of leaving their content out")
+	fs.IntVar(&opts.TailLines, "tail-lines", 0, "include the last N lines of text files over --max-file-size instead of leaving their content out")
+	fs.BoolVar(&opts.RespectGitignore, "respect-gitignore", false, "also skip entries excluded by .gitignore files in the root and any subdirectory")
+	fs.Var(&opts.ignore, "ignore", "also skip entries matching `pattern`, after those of the pattern file (repeatable)")
+	fs.Var(&opts.sarif, "sarif", "count the findings of a SARIF log `file`, as written by linters and scanners, on their files (repeatable)")
+	fs.BoolVar(&opts.FindingsAppendix, "findings-appendix", false, "with --sarif, also list every finding in a Findings section")
+	fs.Var(&opts.include, "include", "map only entries matching `pattern`, on top of the pattern file (repeatable)")
+	fs.Var(&opts.onlyExt, "only-ext", "map only files with these comma-separated `extensions`, e.g. go,md,proto (repeatable)")
+	fs.BoolVar(&opts.noPatterns, "no-pattern-file", false, "ignore .project_structure_ignore and .project_structure_filter, using only --ignore and --include")
+	fs.StringVar(&opts.patternFile, "pattern-file", "", "read ignore patterns from `file` instead of the pattern files in the root")
+	fs.StringVar(&opts.filterFile, "filter-file", "", "read filter patterns from `file` instead of the pattern files in the root")
+	fs.BoolVar(&opts.ShowSymlinks, "show-symlinks", true, "list symlinks as name -> target instead of following them")
+	fs.BoolVar(&opts.follow, "follow-symlinks", false, "follow symlinks, walking symlinked directories; a link back into a directory being walked is listed as a cycle")
+	fs.BoolVar(&opts.SymlinkContent, "symlink-content", false, "with --show-symlinks, include the content of each symlinked file once; targets mapped elsewhere in the tree keep their content there")
+	fs.BoolVar(&opts.hidden, "hidden", false, "map dotfiles and dot-directories even when the built-in lists skip them, such as .env and .vscode (.git stays skipped)")
+	fs.BoolVar(&opts.noHidden, "no-hidden", false, "skip every dotfile and dot-directory")
+	fs.BoolVar(&opts.noDefaults, "no-default-ignores", false, "disable the built-in lists of skipped directories, binary extensions and files")
+	fs.Var(&opts.profiles, "skip-profile", "also skip the artifacts of another operating system: `os` is macos, windows, linux or all (repeatable; the running system's is always included)")
+	fs.Var(&opts.addSkip, "add-default-ignore", "add `entry` to the built-in skip lists: dir/ for a directory, *.ext for an extension, otherwise a file name (repeatable)")
+	fs.Var(&opts.removeSkip, "remove-default-ignore", "remove `entry` from the built-in skip lists, written as for --add-default-ignore (repeatable)")
+	fs.BoolVar(&opts.NestedPatterns, "nested-patterns", true, "also apply the .project_structure_ignore files of subdirectories, relative to their directory")
+	fs.BoolVar(&opts.ShowExcluded, "show-excluded", false, "list entries skipped by patterns, the built-in lists or as hidden in the tree, marked [excluded], without their contents")
+	fs.Var(&opts.TreePolicy, "tree-policy", "render skipped entries of a rule as `rule=show|hide` (rules: pattern, file, dir, binary, size, unreadable, lockfile, hidden, special, tokens)")
+	return fs
+}
+
+// addOutputFlags registers the flags controlling what a snapshot contains
+func addOutputFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.output, "output", "", "output `file`, or - for stdout (default: project_structure.txt, with the extension of --format)")
+	fs.Var(&opts.format, "format", "output `format`: text, json, markdown, html, yaml, xml, protobuf, parquet, mermaid, dot or prose")
+	fs.IntVar(&opts.DiagramDepth, "depth", 0, "with --format mermaid or dot, draw only N levels below the root (0 draws everything)")
+	fs.BoolVar(&opts.quiet, "quiet", false, "do not show the progress of the scan and the output written on stderr, nor the summary of included and skipped entries")
+	fs.BoolVar(&opts.SummaryHeader, "summary-header", false, "start text output with the counts of included files and of entries skipped for each reason")
+	fs.Int64Var(&opts.MaxTokens, "max-tokens", 0, "fit the tree and contents in N tokens, dropping fixtures, then tests, then cutting the largest files (0 for no limit)")
+	fs.Var(&opts.splitSize, "split-size", "split text output into self-contained parts of at most `size`, e.g. 200KB, written as project_structure.part1.txt and so on")
+	fs.Int64Var(&opts.splitTokens, "split-tokens", 0, "split text output into self-contained parts of at most N tokens (0 does not split)")
+	fs.StringVar(&opts.tokenizer, "tokenizer", "", "count tokens exactly with the BPE ranks of a tiktoken `file` such as cl100k_base.tiktoken, instead of estimating four bytes per token")
+	fs.StringVar(&opts.tokenizerCmd, "tokenizer-cmd", "", "count tokens with `command`, which reads a JSON line {\"text\": ...} per text and answers {\"tokens\": N}, for models without a built-in tokenizer")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "list the files that would be included and the entries left out with their rule, without reading contents or writing the output")
+	fs.BoolVar(&opts.ruleStats, "rule-stats", false, "report how many entries each pattern and built-in rule matched")
+	fs.StringVar(&opts.gitattrs, "suggest-gitattributes", "", "write suggested linguist-vendored, linguist-generated and export-ignore entries for detected vendored, build output and generated paths to `file`")
+	fs.BoolVar(&opts.ConsolidateMigrations, "consolidate-migrations", false, "replace Flyway, golang-migrate, Django and Rails migration directories with a consolidated Schema section")
+	fs.Var(&opts.annotations, "annotations", "merge the findings listed in a sidecar JSON `file` into the entries of their paths (repeatable)")
+	fs.IntVar(&opts.ChurnDays, "churn", 0, "annotate files with their commits in the last N days and rank frequently changed files higher for --query (0 disables)")
+	fs.BoolVar(&opts.PairTests, "pair-tests", false, "annotate source files with their test files and vice versa, marking untested sources")
+	fs.BoolVar(&opts.FileIndex, "file-index", false, "end text and markdown output with an alphabetical index of the files and the line or heading their content starts at")
+	fs.BoolVar(&opts.Delimited, "delimited", false, "frame each section of text output with --BEGIN/--END sentinel lines giving its length, for streaming parsers")
+	fs.BoolVar(&opts.Footer, "footer", false, "end text output with the file count, length and SHA-256 of the snapshot, checked by the verify command")
+	fs.BoolVar(&opts.HoistReadmes, "hoist-readmes", false, "describe directories by the first heading or paragraph of their README and put the README first in their content")
+}
+
+// addEmbedFlags adds the flags configuring the embedding endpoint
+func addEmbedFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.embedURL, "embed-url", "", "OpenAI-compatible embeddings `endpoint`, e.g. http://localhost:11434/v1/embeddings; an API key is read from "+embedKeyEnv)
+	fs.StringVar(&opts.embedModel, "embed-model", "", "embedding `model` requested from --embed-url")
+}
+
+// embedKeyEnv holds the API key sent to the embedding endpoint, kept out of
+// flags so it does not end up in shell history
+const embedKeyEnv = envPrefix + "EMBED_KEY"
+
+// embedder returns the embedder configured by the flags, or nil if none is
+func embedder(opts *cliOptions) (mapper.Embedder, error) {
+	if opts.embedURL == "" {
+		return nil, nil
+	}
+	if opts.embedModel == "" {
+		return nil, errors.New("--embed-url needs --embed-model")
+	}
+	return &mapper.HTTPEmbedder{URL: opts.embedURL, Model: opts.embedModel, APIKey: os.Getenv(embedKeyEnv)}, nil
+}
+
+// addContentFlags registers the flags that only affect file contents and sections
+func addContentFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.IntVar(&opts.OutlineOver, "outline-over", 0, "emit an outline instead of full content for files longer than N lines (0 disables)")
+	fs.BoolVar(&opts.Dependencies, "dependencies", false, "add a Dependencies section summarizing go.mod, package.json, requirements.txt and Cargo.toml, and omit lock file contents")
+	fs.BoolVar(&opts.Deployment, "deployment", false, "add a Deployment_Surface section summarizing Dockerfiles, compose files, Kubernetes manifests and Terraform")
+	fs.BoolVar(&opts.InfraSummaryOnly, "infra-summary-only", false, "with --deployment, omit the full content of detected infrastructure files")
+	fs.BoolVar(&opts.Interfaces, "interfaces", false, "hoist .proto and OpenAPI/Swagger files into an Interfaces section near the top")
+	fs.StringVar(&opts.Query, "query", "", "rank files by how often the words of `text` appear in their identifiers, comments and path, keeping the content of only the best matches")
+	fs.IntVar(&opts.QueryTop, "query-top", 10, "with --query, number of files that keep their content")
+	fs.StringVar(&opts.SemanticQuery, "query-semantic", "", "like --query, but rank files by the cosine similarity of their embeddings to the embedding of `text`; needs --embed-url and --embed-model")
+	addEmbedFlags(fs, opts)
+	fs.StringVar(&opts.indexFile, "index", "", "with --query or --query-semantic, read word counts and embeddings from the index `file` built by the index command (default: .project_structure_index in the root, when present)")
+	fs.BoolVar(&opts.cache, "cache", false, "remember which files are binary, the hashes of binaries and outlines between runs, reading again only files changed in size or modification time")
+	fs.StringVar(&opts.cacheFile, "cache-file", "", "with --cache, keep the cache in `file` (default: .project_structure_cache in the root)")
+	fs.IntVar(&opts.Jobs, "jobs", runtime.NumCPU(), "number of directories read and files checked in parallel while scanning; output order is unchanged")
+	fs.IntVar(&opts.Workers, "transform-workers", runtime.NumCPU(), "number of files read and transformed in parallel when a transform such as --outline-over is enabled; output order is unchanged")
+}
+
+// parseFlags applies DIRECTORY_MAPPER_* overrides and then the command line,
+// and switches messages to the language selected by --lang
+func parseFlags(fs *flag.FlagSet, opts *cliOptions, args []string) error {
+	if err := applyEnvOverrides(fs); err != nil {
+		return fmt.Errorf("error reading environment: %v", err)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Configuration files only fill in flags not set otherwise, the
+	// project's taking precedence over the user's
+	dir := mapper.ExpandEnv(opts.root)
+	if dir == "" && opts.container {
+		dir = containerRoot
+	}
+	project, err := loadConfig(filepath.Join(dir, projectConfigName))
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, config := range []*Config{project, userConfig} {
+		if err := config.apply(fs, set); err != nil {
+			return err
+		}
+	}
+	opts.configs = []*Config{userConfig, project}
+	if err := setupLogging(os.Stderr, opts); err != nil {
+		return err
+	}
+	if opts.Tokenizer, err = tokenizer(opts); err != nil {
+		return err
+	}
+
+	lang := fs.Lookup("lang")
+	if lang == nil {
+		return nil
+	}
+	if lang.Value.String() == "" {
+		return fs.Set("lang", i18n.Default.Lang())
+	}
+	p, err := i18n.Lookup(lang.Value.String())
+	if err != nil {
+		return err
+	}
+	i18n.Default = p
+	return nil
+}
+
+// tokenizer returns the tokenizer configured by the flags, or nil if none is
+func tokenizer(opts *cliOptions) (mapper.Tokenizer, error) {
+	switch {
+	case opts.tokenizer != "" && opts.tokenizerCmd != "":
+		return nil, errors.New("--tokenizer cannot be combined with --tokenizer-cmd")
+	case opts.tokenizer != "":
+		return mapper.LoadBPE(mapper.ExpandEnv(opts.tokenizer))
+	case strings.TrimSpace(opts.tokenizerCmd) != "":
+		args := strings.Fields(opts.tokenizerCmd)
+		return &mapper.ExecTokenizer{Command: args[0], Args: args[1:]}, nil
+	}
+	return nil, nil
+}
+
+// resolveRoot returns the absolute directory to map
+func resolveRoot(opts *cliOptions) (string, error) {
+	switch {
+	case opts.root != "":
+		root, err := filepath.Abs(mapper.ExpandEnv(opts.root))
+		if err != nil {
+			return "", fmt.Errorf("error resolving root directory: %v", err)
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return "", fmt.Errorf("error resolving root directory: %v", err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("error resolving root directory: %s is not a directory", root)
+		}
+		return root, nil
+	case opts.container:
+		if err := checkContainerRoot(); err != nil {
+			return "", err
+		}
+		return containerRoot, nil
+	default:
+		root, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("error getting current directory: %v", err)
+		}
+		return root, nil
+	}
+}
+
+// resolveOutput returns the output path, or "" for stdout
+func resolveOutput(opts *cliOptions, defaultPath string) string {
+	switch {
+	case opts.output == "-":
+		return ""
+	case opts.output != "":
+		return mapper.ExpandEnv(opts.output)
+	case opts.container:
+		return containerOutputPath("project_structure" + opts.format.Extension())
+	}
+	return defaultPath
+}
+
+// runMapCommand writes the full snapshot
+func runMapCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("map", opts)
+	addOutputFlags(fs, opts)
+	addContentFlags(fs, opts)
+	fs.StringVar(&opts.batchFile, "batch", "", "run every job listed in a batch `file` (e.g. batch.yaml)")
+	fs.StringVar(&opts.checkpoint, "checkpoint", "", "save progress to `file` and resume from it if an earlier run was interrupted")
+	fs.BoolVar(&opts.keepPartial, "keep-partial", false, "when interrupted, keep the incomplete output as <output>.partial instead of removing it")
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	opts.args = args
+	ctx, stop := interruptContext()
+	defer stop()
+	opts.ctx = ctx
+
+	if opts.batchFile != "" {
+		if opts.checkpoint != "" {
+			return errors.New("--checkpoint cannot be combined with --batch")
+		}
+		if opts.dryRun {
+			return errors.New("--dry-run cannot be combined with --batch")
+		}
+		return runBatch(opts.batchFile, opts)
+	}
+	return runSnapshot(opts, "Project structure and file contents have been written to %s using %s patterns\n", "project_structure"+opts.format.Extension())
+}
+
+// runTreeCommand writes only the directory structure, to stdout by default
+func runTreeCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy(), StructureOnly: true}}
+	fs := newFlagSet("tree", opts)
+	addOutputFlags(fs, opts)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	opts.ctx = ctx
+	return runSnapshot(opts, "Project structure has been written to %s using %s patterns\n", "")
+}
+
+// runSnapshot maps the root and writes the result, reporting on status with
+// the done message
+func runSnapshot(opts *cliOptions, done, defaultOutput string) error {
+	warned := warnings.Load()
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+	if err := loadPatterns(root, opts, !opts.container && !opts.StructureOnly && !opts.dryRun); err != nil {
+		return err
+	}
+	patterns := opts.Patterns
+	if err := prepareRun(root, opts); err != nil {
+		return err
+	}
+	outputPath := resolveOutput(opts, defaultOutput)
+	if opts.dryRun {
+		return dryRun(root, outputPath, opts, os.Stdout)
+	}
+
+	// Status messages must not mix with a snapshot written to stdout
+	status := os.Stdout
+	if outputPath == "" || opts.container {
+		status = os.Stderr
+	}
+
+	tree, err := writeSnapshot(root, outputPath, opts)
+	if err != nil {
+		return err
+	}
+
+	patternTypeStr := "ignore"
+	switch {
+	case patterns.Type() == mapper.Filter:
+		patternTypeStr = "filter"
+	case opts.bothFiles:
+		patternTypeStr = "ignore+filter"
+	}
+	if outputPath != "" && !splitting(opts) {
+		i18n.Default.Fprintf(status, done, outputPath, patternTypeStr)
+	}
+	if err := finishRun(tree, root, status, opts); err != nil {
+		return err
+	}
+	return statusError(completedStatus(tree, warnings.Load()-warned))
+}
+
+// prepareRun loads what the flags name for mapping root besides its
+// patterns: the embedder and index of queries, annotations, SARIF findings
+// and the cache, and sets the hooks logging the walk
+func prepareRun(root string, opts *cliOptions) error {
+	var err error
+	if opts.SemanticQuery != "" {
+		if opts.Embedder, err = embedder(opts); err != nil {
+			return err
+		}
+		if opts.Embedder == nil {
+			return errors.New("--query-semantic needs --embed-url and --embed-model")
+		}
+	}
+	if opts.Query != "" || opts.SemanticQuery != "" {
+		if opts.Index, err = mapper.LoadIndex(indexPath(root, opts)); err != nil {
+			return err
+		}
+	}
+	if opts.verbosity > 0 {
+		opts.OnSkip = func(name string, isDir bool, rule string) {
+			if isDir || opts.verbosity >= verboseFiles {
+				slog.Log(context.Background(), levelVerbose, fmt.Sprintf("skipped %s: %s", name, rule), "path", name, "dir", isDir, "rule", rule)
+			}
+		}
+	}
+	if opts.verbosity >= verboseDebug {
+		opts.OnProgress = func(dir string, files int) {
+			slog.Debug("walking "+dir, "dir", dir, "files", files)
+		}
+	}
+	for _, file := range opts.annotations {
+		annotations, err := mapper.LoadAnnotations(mapper.ExpandEnv(file))
+		if err != nil {
+			return err
+		}
+		opts.Annotations = append(opts.Annotations, annotations...)
+	}
+	for _, file := range opts.sarif {
+		findings, err := mapper.LoadSARIF(mapper.ExpandEnv(file), root)
+		if err != nil {
+			return err
+		}
+		opts.Findings = append(opts.Findings, findings...)
+	}
+	if opts.container {
+		opts.OnUnreadable = func(name string) {
+			warnUnreadableOwnership(filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	if opts.cache {
+		if opts.ContentCache, err = mapper.LoadContentCache(cachePath(root, opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishRun saves the cache of a written tree and reports on status its
+// summary, the directories cut short, the rule statistics and the
+// suggested .gitattributes entries
+func finishRun(tree *mapper.Tree, root string, status io.Writer, opts *cliOptions) error {
+	if opts.ContentCache != nil {
+		opts.ContentCache.Record(tree.Totals())
+		if err := opts.ContentCache.Save(cachePath(root, opts)); err != nil {
+			i18n.Warnf("Could not save cache: %v", err)
+		}
+	}
+	if !opts.quiet {
+		tree.WriteSummary(status)
+	}
+	tree.WriteTruncated(status)
+	if opts.ruleStats {
+		tree.WriteRuleStats(status)
+	}
+
+	if opts.gitattrs != "" {
+		if err := writeGitattributes(tree, mapper.ExpandEnv(opts.gitattrs)); err != nil {
+			return err
+		}
+		i18n.Default.Fprintf(status, "Suggested .gitattributes entries have been written to %s\n", opts.gitattrs)
+	}
+	return nil
+}
+
+// loadPatterns reads the pattern file of root unless --no-pattern-file is
+// set, then adds the patterns given with --ignore and --include
+func loadPatterns(root string, opts *cliOptions, createMissing bool) error {
+	patterns := mapper.NewPatterns(root, mapper.Ignore)
+	var filter *mapper.PatternList
+	var err error
+	switch {
+	case opts.noPatterns:
+	case opts.patternFile != "" || opts.filterFile != "":
+		if opts.patternFile != "" {
+			if patterns, err = mapper.NewPatternList(mapper.ExpandEnv(opts.patternFile), root, mapper.Ignore); err != nil {
+				return fmt.Errorf("error initializing patterns: %v", err)
+			}
+		}
+		if opts.filterFile != "" {
+			if filter, err = mapper.NewPatternList(mapper.ExpandEnv(opts.filterFile), root, mapper.Filter); err != nil {
+				return fmt.Errorf("error initializing patterns: %v", err)
+			}
+		}
+	default:
+		if patterns, filter, err = mapper.LoadPatternFiles(root, createMissing); err != nil {
+			return err
+		}
+	}
+	return applyPatternFlags(patterns, filter, root, opts)
+}
+
+// applyPatternFlags sets opts.Patterns to patterns extended with --ignore,
+// and opts.Include to filter, if any, extended with --include
+func applyPatternFlags(patterns, filter *mapper.PatternList, root string, opts *cliOptions) error {
+	if opts.noPatterns {
+		opts.NestedPatterns = false
+	}
+	patterns, filter, err := withPatterns(opts.configs, patterns, filter, root)
+	if err != nil {
+		return err
+	}
+	if len(opts.ignore) > 0 {
+		ignore := []string(opts.ignore)
+		if patterns.Type() == mapper.Filter {
+			// A negated filter pattern excludes what it matches
+			ignore = make([]string, len(opts.ignore))
+			for i, p := range opts.ignore {
+				ignore[i] = "!" + p
+			}
+		}
+		if patterns, err = patterns.Extend("--ignore", ignore...); err != nil {
+			return err
+		}
+	}
+	opts.Patterns = patterns
+	opts.bothFiles = filter != nil
+
+	extensions := extensionPatterns(opts.onlyExt)
+	if len(opts.include) > 0 || len(extensions) > 0 {
+		if filter == nil {
+			filter = mapper.NewPatterns(root, mapper.Filter)
+		}
+		if filter, err = filter.Extend("--include", opts.include...); err != nil {
+			return err
+		}
+		if filter, err = filter.Extend("--only-ext", extensions...); err != nil {
+			return err
+		}
+	}
+	opts.Include = filter
+	return applySkipFlags(opts)
+}
+
+// extensionPatterns turns the values of --only-ext into filter patterns
+// matching files with those extensions at any depth
+func extensionPatterns(values []string) []string {
+	patterns := make([]string, 0)
+	for _, value := range values {
+		for _, ext := range strings.Split(value, ",") {
+			ext = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(ext), "*"), ".")
+			if ext != "" {
+				patterns = append(patterns, "*."+ext)
+			}
+		}
+	}
+	return patterns
+}
+
+// applySkipFlags sets opts.ShowSymlinks from --follow-symlinks, opts.Hidden
+// from --hidden and --no-hidden, and opts.SkipLists from
+// --no-default-ignores, the profiles and the entries added and removed,
+// leaving it nil for the defaults
+func applySkipFlags(opts *cliOptions) error {
+	if opts.follow {
+		opts.ShowSymlinks = false
+	}
+	switch {
+	case opts.hidden && opts.noHidden:
+		return errors.New("--hidden and --no-hidden cannot be combined")
+	case opts.hidden:
+		opts.Hidden = mapper.HiddenShown
+	case opts.noHidden:
+		opts.Hidden = mapper.HiddenSkipped
+	}
+	if !opts.noDefaults && len(opts.profiles) == 0 && len(opts.addSkip) == 0 && len(opts.removeSkip) == 0 {
+		return nil
+	}
+	lists := &mapper.SkipLists{}
+	if !opts.noDefaults {
+		lists = mapper.DefaultSkipLists()
+	}
+	for _, profile := range opts.profiles {
+		if err := lists.AddProfile(profile); err != nil {
+			return err
+		}
+	}
+	for _, entry := range opts.removeSkip {
+		lists.Remove(entry)
+	}
+	for _, entry := range opts.addSkip {
+		lists.Add(entry)
+	}
+	opts.SkipLists = lists
+	return nil
+}
+
+// writeSnapshot scans root and writes the result to outputPath, or to stdout
+// when outputPath is empty
+func writeSnapshot(root, outputPath string, opts *cliOptions) (*mapper.Tree, error) {
+	if splitting(opts) {
+		return writeSplitSnapshot(root, outputPath, opts)
+	}
+	output := os.Stdout
+	if opts.checkpoint != "" {
+		if outputPath == "" {
+			return nil, errors.New("--checkpoint needs an output file")
+		}
+		key := strings.Join(append([]string{root, outputPath}, opts.args...), "\x00")
+		cp, err := mapper.LoadCheckpoint(opts.checkpoint, key)
+		if err != nil {
+			return nil, err
+		}
+		opts.Checkpoint = cp
+	}
+
+	if outputPath != "" {
+		var err error
+		output, err = openOutput(outputPath, opts.Checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		defer output.Close()
+		// A crash must not leave a half written output looking complete
+		defer func() {
+			if r := recover(); r != nil {
+				abandonOutput(output, nil, outputPath, opts)
+				panic(r)
+			}
+		}()
+		if abs, err := filepath.Abs(outputPath); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				opts.ExcludePath = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	progress := newProgress(opts, outputPath)
+	defer progress.clear()
+	if progress != nil {
+		opts.OnProgress = progress.scanning
+	}
+	start := time.Now()
+	tree, err := mapper.ScanContext(opts.ctx, root, &opts.Options)
+	slog.Debug(fmt.Sprintf("scanned %s in %v", root, time.Since(start).Round(time.Millisecond)), "root", root, "duration", time.Since(start))
+	if err != nil {
+		progress.clear()
+		if opts.ctx.Err() != nil {
+			if outputPath != "" {
+				abandonOutput(output, nil, outputPath, opts)
+			}
+			return nil, errInterrupted
+		}
+		return nil, err
+	}
+	if outputPath != "" {
+		needed := tree.EstimateSize(opts.format)
+		if opts.Checkpoint != nil {
+			needed -= opts.Checkpoint.Offset()
+		}
+		if err := checkDiskSpace(outputPath, needed); err != nil {
+			// Nothing was written yet unless resuming
+			if opts.Checkpoint == nil {
+				output.Close()
+				os.Remove(outputPath)
+			}
+			return nil, err
+		}
+	}
+
+	// Many small writes go to the output, which may be on a network drive
+	start = time.Now()
+	var sink io.Writer = output
+	if progress != nil {
+		sink = progress.writer(output)
+	}
+	buffered := bufio.NewWriterSize(sink, 256<<10)
+	if err := tree.RenderContext(opts.ctx, buffered, opts.format); err != nil {
+		progress.clear()
+		if opts.ctx.Err() != nil {
+			if outputPath != "" {
+				abandonOutput(output, buffered, outputPath, opts)
+			}
+			return nil, errInterrupted
+		}
+		return nil, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, fmt.Errorf("error writing output: %v", err)
+	}
+	slog.Debug(fmt.Sprintf("wrote %s in %v", opts.format.String(), time.Since(start).Round(time.Millisecond)), "format", opts.format.String(), "duration", time.Since(start))
+	if opts.Checkpoint != nil {
+		if err := opts.Checkpoint.Remove(); err != nil {
+			i18n.Warnf("Could not remove checkpoint: %v", err)
+		}
+	}
+	return tree, nil
+}
+
+// writeGitattributes writes the suggested .gitattributes entries of tree to path
+func writeGitattributes(tree *mapper.Tree, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", path, err)
+	}
+	if err := tree.WriteGitattributes(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return file.Close()
+}
+
+// openOutput creates the output file, or reopens it at the end of the part
+// a checkpointed earlier run completely wrote
+func openOutput(outputPath string, cp *mapper.Checkpoint) (*os.File, error) {
+	if cp != nil && cp.Offset() > 0 {
+		file, err := os.OpenFile(outputPath, os.O_WRONLY, 0)
+		if err == nil {
+			info, err := file.Stat()
+			if err == nil && info.Size() >= cp.Offset() {
+				if err := file.Truncate(cp.Offset()); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("error truncating output file: %v", err)
+				}
+				if _, err := file.Seek(cp.Offset(), io.SeekStart); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("error seeking output file: %v", err)
+				}
+				i18n.Infof("Resuming %s after %d bytes\n", outputPath, cp.Offset())
+				return file, nil
+			}
+			file.Close()
+		}
+		// The output no longer holds what the checkpoint describes
+		cp.Restart()
+	}
+
+	file, err := os.Create(outputPath)
+	if errors.Is(err, os.ErrPermission) {
+		return nil, fmt.Errorf("output location is not writable: %v", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file: %v", err)
+	}
+	return file, nil
+}
+
+// checkDiskSpace fails when the filesystem of outputPath has less free space
+// than the estimated bytes needed, rather than running out while writing
+func checkDiskSpace(outputPath string, needed int64) error {
+	needed += needed / 10 // Margin for the estimate
+	free, ok := diskFree(filepath.Dir(outputPath))
+	if !ok || needed <= 0 || uint64(needed) <= free {
+		return nil
+	}
+	return fmt.Errorf("not enough disk space for %s: about %.1f MB needed, %.1f MB free", outputPath, float64(needed)/(1<<20), float64(free)/(1<<20))
+}
+
+// runExplainCommand reports the decision taken for each path argument
+func runExplainCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("explain", opts)
+	fs.BoolVar(&opts.Dependencies, "dependencies", false, "explain as if --dependencies were set")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper explain [flags] <path>...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("explain needs at least one path")
+	}
+
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+	if err := loadPatterns(root, opts, false); err != nil {
+		return err
+	}
+
+	for _, target := range fs.Args() {
+		if err := mapper.Explain(root, target, &opts.Options, os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runSchemaCommand prints the schema of the xml or protobuf format
+func runSchemaCommand(args []string) error {
+	format := "xml"
+	if len(args) > 0 {
+		format = args[0]
+	}
+	switch format {
+	case "xml":
+		fmt.Print(mapper.XMLSchema)
+	case "protobuf":
+		fmt.Print(mapper.ProtoSchema)
+	default:
+		return fmt.Errorf("no schema for format %q, only for xml and protobuf", format)
+	}
+	return nil
+}
+
+// runVerifyCommand checks each snapshot argument against its footer
+func runVerifyCommand(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper verify <snapshot>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("verify needs at least one snapshot")
+	}
+
+	failed := 0
+	for _, name := range fs.Args() {
+		if err := verifySnapshot(name); err != nil {
+			slog.Error(fmt.Sprintf("%s: %v", name, err), "file", name)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed verification", failed, fs.NArg())
+	}
+	return nil
+}
+
+// verifySnapshot checks one snapshot file and prints what its footer records
+func verifySnapshot(name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	footer, err := mapper.VerifySnapshot(file, info.Size())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: ok, %d files, %d bytes\n", name, footer.Files, footer.Bytes)
+	return nil
+}
+
+// indexPath returns the index file of root, by default inside it
+func indexPath(root string, opts *cliOptions) string {
+	if opts.indexFile != "" {
+		return mapper.ExpandEnv(opts.indexFile)
+	}
+	return filepath.Join(root, ".project_structure_index")
+}
+
+// cachePath returns where the content cache of root is kept
+func cachePath(root string, opts *cliOptions) string {
+	if opts.cacheFile != "" {
+		return mapper.ExpandEnv(opts.cacheFile)
+	}
+	return filepath.Join(root, ".project_structure_cache")
+}
+
+// runIndexCommand builds the word index of the root, reading only the files
+// changed since the previous index
+func runIndexCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("index", opts)
+	fs.StringVar(&opts.indexFile, "index", "", "index `file` to write (default: .project_structure_index in the root)")
+	addEmbedFlags(fs, opts)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	embed, err := embedder(opts)
+	if err != nil {
+		return err
+	}
+
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+	if err := loadPatterns(root, opts, false); err != nil {
+		return err
+	}
+	path := indexPath(root, opts)
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil {
+			opts.ExcludePath = filepath.ToSlash(rel)
+		}
+	}
+
+	previous, err := mapper.LoadIndex(path)
+	if err != nil {
+		return err
+	}
+	tree, err := mapper.Scan(root, &opts.Options)
+	if err != nil {
+		return err
+	}
+	index := mapper.BuildIndex(tree, previous)
+	if embed != nil {
+		embedded, err := index.Embed(context.Background(), tree, embed)
+		if err != nil {
+			return err
+		}
+		i18n.Default.Fprintf(os.Stdout, "Embedded %d files with %s\n", embedded, opts.embedModel)
+	}
+	if err := index.Save(path); err != nil {
+		return err
+	}
+	i18n.Default.Fprintf(os.Stdout, "Index of %d files written to %s (%d read, %d unchanged)\n", index.Len(), path, index.Read(), index.Len()-index.Read())
+	return nil
+}
+
+// runSearchCommand lists the indexed files most relevant to a query
+func runSearchCommand(args []string) error {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", "", "directory whose index is searched (default: current directory)")
+	fs.StringVar(&opts.indexFile, "index", "", "index `file` to search (default: .project_structure_index in the root)")
+	fs.StringVar(&opts.Language, "lang", "", "`language` of messages: en, es or ja (default: from LC_ALL, LC_MESSAGES or LANG)")
+	top := fs.Int("top", 10, "number of results to list")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper search [flags] <query>")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("search needs a query")
+	}
+
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+	path := indexPath(root, opts)
+	index, err := mapper.LoadIndex(path)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return fmt.Errorf("no index at %s, run directory-mapper index first", path)
+	}
+
+	for _, result := range index.Search(strings.Join(fs.Args(), " "), *top) {
+		fmt.Printf("%8.2f  %s\n", result.Score, result.Path)
+	}
+	return nil
+}
+
+// initTemplate is written by the init command
+const initTemplate = `# Patterns for directory-mapper, one per line.
+#
+# Patterns follow .gitignore: a name without a slash matches at any depth,
+# one with a slash is anchored to this directory, and a trailing slash
+# matches only directories.
+#
+#   *.ext           match files by extension at any depth
+#   /build          match build in this directory only
+#   docs/*.md       match the Markdown files directly in docs
+#   **/generated/   match a generated directory at any depth
+#   logs/**         match everything inside logs
+#   !keep.log       re-include what an earlier pattern excluded
+#   re:.*\.tmp\d+   match the whole path against a regular expression
+#   (?i)*.jpg       match without regard to case
+#   \#notes.txt     escape a leading #, !, or a wildcard with a backslash
+#   @show pattern   keep matches in the tree but leave out their content
+#   @hide pattern   remove matches from the tree entirely
+#   @include file   read more patterns from another file
+#   ${VAR}          expand an environment variable
+#
+# Examples:
+# node_modules/
+# *.log
+# !important.log
+# @show *.svg
+`
+
+// runInitCommand scaffolds a pattern file in the root
+func runInitCommand(args []string) error {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", "", "directory to create the pattern file in (default: current directory)")
+	fs.StringVar(&opts.Language, "lang", "", "`language` of messages: en, es or ja (default: from LC_ALL, LC_MESSAGES or LANG)")
+	filter := fs.Bool("filter", false, "create .project_structure_filter instead of .project_structure_ignore")
+	force := fs.Bool("force", false, "overwrite an existing pattern file")
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+
+	name := ".project_structure_ignore"
+	if *filter {
+		name = ".project_structure_filter"
+	}
+	path := filepath.Join(root, name)
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+	}
+	if err := os.WriteFile(path, []byte(initTemplate), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	i18n.Default.Fprintf(os.Stdout, "Created %s\n", path)
+	return nil
+}
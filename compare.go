@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// runCompareCommand reports the structural differences between two projects
+func runCompareCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("compare", opts)
+	fs.StringVar(&opts.output, "output", "", "write the report to `file` instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper compare [flags] <project> <project>")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("compare needs two projects")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	opts.ctx = ctx
+
+	var trees [2]*mapper.Tree
+	for i, root := range fs.Args() {
+		tree, err := scanProject(root, opts)
+		if err != nil {
+			if opts.ctx.Err() != nil {
+				return errInterrupted
+			}
+			return err
+		}
+		trees[i] = tree
+	}
+	comparison := mapper.Compare(trees[0], trees[1])
+
+	var w io.Writer = os.Stdout
+	if opts.output != "" {
+		file, err := os.Create(mapper.ExpandEnv(opts.output))
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := comparison.Write(w); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	if opts.output != "" {
+		i18n.Default.Fprintf(os.Stdout, "Comparison has been written to %s\n", opts.output)
+	}
+	return nil
+}
+
+// scanProject maps root with its own pattern files and the pattern flags,
+// without writing anything
+func scanProject(root string, opts *cliOptions) (*mapper.Tree, error) {
+	root, err := filepath.Abs(mapper.ExpandEnv(root))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving root: %v", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("error resolving root: %s is not a directory", root)
+	}
+	projectOpts := *opts
+	if err := loadPatterns(root, &projectOpts, false); err != nil {
+		return nil, err
+	}
+	return mapper.ScanContext(opts.ctx, root, &projectOpts.Options)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/yaml"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// configEnv overrides the location of the user configuration; an empty
+// value disables it
+const configEnv = envPrefix + "CONFIG"
+
+// projectConfigName is the configuration file read from the root
+const projectConfigName = ".directory-mapper.yaml"
+
+// userConfig holds the defaults every run inherits, see userConfigPath
+var userConfig = &Config{}
+
+// pathFlags are the flags whose relative values in a configuration file are
+// resolved against the file's directory
+var pathFlags = map[string]bool{
+	"output":                true,
+	"pattern-file":          true,
+	"filter-file":           true,
+	"checkpoint":            true,
+	"index":                 true,
+	"cache-file":            true,
+	"annotations":           true,
+	"sarif":                 true,
+	"batch":                 true,
+	"suggest-gitattributes": true,
+}
+
+// Config holds flag defaults read from a configuration file, either the
+// user's or the project's. Flags hold values by flag name, and Ignore
+// patterns applied before those of the project's pattern files.
+//
+//	format: markdown
+//	max-file-size: 1048576
+//	respect-gitignore: true
+//	ignore:
+//	  - .idea/
+//	  - "*.swp"
+type Config struct {
+	path   string
+	flags  map[string][]string
+	ignore []string
+}
+
+// userConfigPath returns where the user configuration is read from, or ""
+// when it is disabled: $XDG_CONFIG_HOME or ~/.config on Linux, AppData on
+// Windows and Library/Application Support on macOS
+func userConfigPath() string {
+	if path, ok := os.LookupEnv(configEnv); ok {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "directory-mapper", "config.yaml")
+}
+
+// loadConfig reads the configuration file at path. A missing file, or an
+// empty path, yields an empty configuration.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{path: path, flags: make(map[string][]string)}
+	if path == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config %s: %v", path, err)
+	}
+
+	doc, err := yaml.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+	if doc == nil {
+		return config, nil
+	}
+	fields, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config %s: expected a mapping", path)
+	}
+
+	for key, value := range fields {
+		var values []string
+		switch v := value.(type) {
+		case string:
+			values = []string{v}
+		case []any:
+			for _, item := range v {
+				str, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("config %s: %s must be a list of strings", path, key)
+				}
+				values = append(values, str)
+			}
+		default:
+			return nil, fmt.Errorf("config %s: %s must be a string or a list", path, key)
+		}
+
+		switch {
+		case key == "ignore":
+			config.ignore = values
+		case key == "root":
+			return nil, fmt.Errorf("config %s: root cannot be set in a config file", path)
+		default:
+			config.flags[key] = values
+		}
+	}
+	return config, nil
+}
+
+// apply sets the flags of fs named in the configuration, except those in
+// set, and adds the flags it sets to set. Keys for flags of other commands
+// are left alone.
+func (c *Config) apply(fs *flag.FlagSet, set map[string]bool) error {
+	applied := make([]string, 0, len(c.flags))
+	for name, values := range c.flags {
+		if fs.Lookup(name) == nil || set[name] {
+			continue
+		}
+		for _, value := range values {
+			value = mapper.ExpandEnv(value)
+			if pathFlags[name] && value != "-" && !filepath.IsAbs(value) {
+				value = filepath.Join(filepath.Dir(c.path), value)
+			}
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("config %s: invalid value %q for %s: %v", c.path, value, name, err)
+			}
+		}
+		applied = append(applied, name)
+	}
+	for _, name := range applied {
+		set[name] = true
+	}
+	return nil
+}
+
+// withPatterns puts the ignore patterns of the configurations, in order,
+// before the project's. With only a filter file the project's list becomes
+// the filter and the configured patterns the ignore list.
+func withPatterns(configs []*Config, patterns, filter *mapper.PatternList, root string) (*mapper.PatternList, *mapper.PatternList, error) {
+	configured := mapper.NewPatterns(root, mapper.Ignore)
+	count := 0
+	for _, c := range configs {
+		var err error
+		if configured, err = configured.Extend(c.path, c.ignore...); err != nil {
+			return nil, nil, fmt.Errorf("config %s: %v", c.path, err)
+		}
+		count += len(c.ignore)
+	}
+	if count == 0 {
+		return patterns, filter, nil
+	}
+	if patterns.Type() == mapper.Filter {
+		return configured, patterns, nil
+	}
+	return configured.Concat(patterns), filter, nil
+}
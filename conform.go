@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// runConformCommand checks the structure of the project against a template
+// repository, failing when expected paths are missing
+func runConformCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("conform", opts)
+	fs.StringVar(&opts.output, "output", "", "write the report to `file` instead of stdout")
+	strict := fs.Bool("strict", false, "also fail when the project has paths the template lacks")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper conform [flags] <template> [project]")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("conform needs a template and at most one project")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	opts.ctx = ctx
+
+	project := fs.Arg(1)
+	if project == "" {
+		root, err := resolveRoot(opts)
+		if err != nil {
+			return err
+		}
+		project = root
+	}
+	var trees [2]*mapper.Tree
+	for i, root := range []string{fs.Arg(0), project} {
+		tree, err := scanProject(root, opts)
+		if err != nil {
+			if opts.ctx.Err() != nil {
+				return errInterrupted
+			}
+			return err
+		}
+		trees[i] = tree
+	}
+	conformance := mapper.Conform(trees[0], trees[1])
+
+	var w io.Writer = os.Stdout
+	if opts.output != "" {
+		file, err := os.Create(mapper.ExpandEnv(opts.output))
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := conformance.Write(w); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	if opts.output != "" {
+		i18n.Default.Fprintf(os.Stdout, "Conformance report has been written to %s\n", opts.output)
+	}
+
+	if len(conformance.Missing) > 0 || *strict && len(conformance.Extra) > 0 {
+		return statusError(exitNonconform)
+	}
+	return nil
+}
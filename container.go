@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Container mode (--container) adapts the tool for running inside Docker or
+// other container runtimes:
+//
+//   - The project is read from the volume mounted at /src.
+//   - Output goes to /out/project_structure.txt when /out is mounted as a
+//     directory, otherwise to stdout; status messages then go to stderr so
+//     the snapshot can be piped.
+//   - No files are created in the mounted project, so /src may be read-only.
+//   - Files that cannot be read make the run exit with exitWarnings after
+//     the output is complete, as in every mode.
+//   - Permission warnings include the file owner and the container's UID/GID,
+//     since mismatched user mappings are the usual cause.
+//
+// A typical invocation is:
+//
+//	docker run --rm -u "$(id -u):$(id -g)" -v "$PWD:/src:ro" -v "$PWD/out:/out" directory-mapper --container
+const (
+	containerRoot      = "/src"
+	containerOutputDir = "/out"
+)
+
+// containerOutputPath returns where container mode writes the snapshot file,
+// or "" when it should go to stdout
+func containerOutputPath(name string) string {
+	if info, err := os.Stat(containerOutputDir); err == nil && info.IsDir() {
+		return containerOutputDir + "/" + name
+	}
+	return ""
+}
+
+// checkContainerRoot verifies the project volume is mounted and warns about
+// ownership mismatches that commonly break reads
+func checkContainerRoot() error {
+	info, err := os.Stat(containerRoot)
+	if err != nil {
+		return fmt.Errorf("project volume not mounted at %s: %v", containerRoot, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", containerRoot)
+	}
+
+	if os.Geteuid() == 0 {
+		i18n.Warnf("Running as root; files written to mounted volumes will be owned by root (use -u \"$(id -u):$(id -g)\")")
+	}
+	warnOwnership(containerRoot, info)
+	return nil
+}
+
+// warnUnreadableOwnership explains an unreadable file in terms of UID/GID
+func warnUnreadableOwnership(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	warnOwnership(path, info)
+}
+
+// ownership is the owner of a file together with the user of the process
+type ownership struct {
+	uid, gid   int // Of the file
+	euid, egid int // Of the process
+}
+
+// warnOwnership warns when the file at path, described by info, is owned by
+// another user than the process
+func warnOwnership(path string, info os.FileInfo) {
+	if o, ok := ownershipMismatch(info); ok {
+		i18n.Warnf("%s is owned by uid %d gid %d but running as uid %d gid %d", path, o.uid, o.gid, o.euid, o.egid)
+	}
+}
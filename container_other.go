@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// ownershipMismatch is not supported on this platform
+func ownershipMismatch(info os.FileInfo) (ownership, bool) {
+	return ownership{}, false
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownershipMismatch returns the owner of a file and the user of the current
+// process, reporting false when they match or cannot be compared
+func ownershipMismatch(info os.FileInfo) (ownership, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ownership{}, false
+	}
+
+	uid, gid := os.Geteuid(), os.Getegid()
+	if uid == 0 || (int(stat.Uid) == uid && int(stat.Gid) == gid) {
+		return ownership{}, false
+	}
+	return ownership{uid: int(stat.Uid), gid: int(stat.Gid), euid: uid, egid: gid}, true
+}
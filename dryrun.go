@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// dryRun lists the files a snapshot of root would include, marked +, and the
+// entries it would list without content, marked ~, or leave out, marked -,
+// with the rule deciding each, and the tokens of every included file.
+// Contents are only read to count tokens with a tokenizer, otherwise they are
+// estimated from sizes. Nothing is written to outputPath, which is only left
+// out of the walk as a real run would.
+func dryRun(root, outputPath string, opts *cliOptions, w io.Writer) error {
+	if outputPath != "" {
+		if abs, err := filepath.Abs(outputPath); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				opts.ExcludePath = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	var included, listed, skipped int
+	var tokens int64
+	err := mapper.WalkEventsContext(opts.ctx, root, &opts.Options, func(e mapper.Event) error {
+		name := e.Path
+		if e.IsDir {
+			name += "/"
+		}
+		switch e.Kind {
+		case mapper.EventFile:
+			included++
+			n := fileTokens(filepath.Join(root, filepath.FromSlash(e.Path)), opts.Tokenizer)
+			tokens += n
+			i18n.Default.Fprintf(w, "+ %s: %d tokens\n", name, n)
+		case mapper.EventSkip:
+			mark := "-"
+			if e.Listed {
+				mark = "~"
+				listed++
+			} else {
+				skipped++
+			}
+			fmt.Fprintf(w, "%s %s: %s\n", mark, name, e.Rule)
+		case mapper.EventError:
+			return fmt.Errorf("error reading directory %s: %v", e.Path, e.Err)
+		}
+		return nil
+	})
+	if opts.ctx.Err() != nil {
+		return errInterrupted
+	}
+	if err != nil {
+		return err
+	}
+	if opts.Tokenizer != nil {
+		i18n.Default.Fprintf(w, "%d files would be included with %d tokens, %d entries listed without content and %d left out\n", included, tokens, listed, skipped)
+	} else {
+		i18n.Default.Fprintf(w, "%d files would be included with about %d tokens, %d entries listed without content and %d left out\n", included, tokens, listed, skipped)
+	}
+	return nil
+}
+
+// fileTokens counts the tokens of a file with tokenizer, or estimates them
+// from its size when tokenizer is nil or the file cannot be read
+func fileTokens(path string, tokenizer mapper.Tokenizer) int64 {
+	if tokenizer != nil {
+		if data, err := os.ReadFile(path); err == nil {
+			return int64(tokenizer.Count(data))
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return mapper.EstimateTokens(info.Size())
+}
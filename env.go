@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// envPrefix is prepended to flag names to form their environment variable
+const envPrefix = "DIRECTORY_MAPPER_"
+
+// flagEnvName returns the environment variable bound to a flag,
+// e.g. outline-over becomes DIRECTORY_MAPPER_OUTLINE_OVER
+func flagEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnvOverrides sets flags from their DIRECTORY_MAPPER_* variables.
+// It runs before parsing so explicit command line flags still win.
+func applyEnvOverrides(fs *flag.FlagSet) error {
+	var firstErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(flagEnvName(f.Name))
+		if !ok || firstErr != nil {
+			return
+		}
+		if err := fs.Set(f.Name, mapper.ExpandEnv(value)); err != nil {
+			firstErr = fmt.Errorf("invalid value %q for %s: %v", value, flagEnvName(f.Name), err)
+		}
+	})
+	return firstErr
+}
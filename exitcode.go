@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// Exit codes of the process, listed in the README. A run that completed
+// with both limits hit and warnings exits with exitLimits.
+const (
+	exitFatal       = 1   // The run failed
+	exitWarnings    = 2   // Completed, but with warnings such as unreadable files skipped
+	exitLimits      = 3   // Completed, but size or entry limits left entries out
+	exitNonconform  = 4   // The conform command found the project departing from its template
+	exitInterrupted = 130 // Stopped by SIGINT, following the shell convention
+	exitTerminated  = 143 // Stopped by SIGTERM
+)
+
+// exitCodeError makes the process exit with a specific status. A nil err
+// exits without a message, for runs that completed.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+// completedStatus returns the exit code of a run that wrote tree, given the
+// number of warnings logged meanwhile
+func completedStatus(tree *mapper.Tree, warnings int64) int {
+	switch {
+	case tree.OverLimits() > 0:
+		return exitLimits
+	case tree.Unreadable() > 0 || warnings > 0:
+		return exitWarnings
+	}
+	return 0
+}
+
+// statusError turns an exit code into the error returned by a command
+func statusError(code int) error {
+	if code == 0 {
+		return nil
+	}
+	return &exitCodeError{code: code}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// runHistoryCommand shows the totals recorded by the runs made with --cache,
+// warning when the last run grew suspiciously
+func runHistoryCommand(args []string) error {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", "", "directory whose history is shown (default: current directory)")
+	fs.StringVar(&opts.cacheFile, "cache-file", "", "read the history from the cache `file` (default: .project_structure_cache in the root)")
+	last := fs.Int("last", 0, "show only the last N runs (0 shows all)")
+	maxGrowth := fs.Float64("max-growth", 50, "warn when the size or tokens of the last run grew by more than `percent` since the run before")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := resolveRoot(opts)
+	if err != nil {
+		return err
+	}
+	path := cachePath(root, opts)
+	cache, err := mapper.LoadContentCache(path)
+	if err != nil {
+		return err
+	}
+	runs := cache.History()
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs recorded in %s; runs with --cache record their totals", path)
+	}
+
+	start := 0
+	if *last > 0 && *last < len(runs) {
+		start = len(runs) - *last
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", i18n.Default.Sprintf("Run"), i18n.Default.Sprintf("Files"), i18n.Default.Sprintf("Size"), i18n.Default.Sprintf("Tokens"), i18n.Default.Sprintf("Change"))
+	for i := start; i < len(runs); i++ {
+		run := runs[i]
+		change := ""
+		if i > 0 {
+			change = formatGrowth(runs[i-1].Bytes, run.Bytes)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\t\n", run.Time.Local().Format("2006-01-02 15:04"), run.Files, mapper.FormatSize(run.Bytes), run.Tokens, change)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(runs) < 2 {
+		return nil
+	}
+	prev, cur := runs[len(runs)-2], runs[len(runs)-1]
+	if growth(prev.Bytes, cur.Bytes) > *maxGrowth || growth(prev.Tokens, cur.Tokens) > *maxGrowth {
+		i18n.Warnf("The last run grew from %s to %s and from %d to %d tokens, more than %g%%; look for new generated or vendored files", mapper.FormatSize(prev.Bytes), mapper.FormatSize(cur.Bytes), prev.Tokens, cur.Tokens, *maxGrowth)
+		return statusError(exitWarnings)
+	}
+	return nil
+}
+
+// growth returns by how many percent to exceeds from, 0 when it does not
+func growth(from, to int64) float64 {
+	if to <= from {
+		return 0
+	}
+	if from == 0 {
+		return 100
+	}
+	return float64(to-from) / float64(from) * 100
+}
+
+// formatGrowth describes the change from from to to bytes
+func formatGrowth(from, to int64) string {
+	if from == to {
+		return "="
+	}
+	change := "+" + mapper.FormatSize(to-from)
+	if to < from {
+		change = "-" + mapper.FormatSize(from-to)
+	}
+	if from > 0 {
+		change += fmt.Sprintf(" (%+.0f%%)", float64(to-from)/float64(from)*100)
+	}
+	return change
+}
@@ -0,0 +1,201 @@
+package i18n
+
+// spanish is the Spanish catalog
+var spanish = map[string]string{
+	// Warnings and status messages
+	"Warning: %s":                           "Advertencia: %s",
+	"Error: %v\n":                           "Error: %v\n",
+	"Ignoring unreadable checkpoint %s: %v": "Se ignora el punto de control ilegible %s: %v",
+	"Ignoring checkpoint %s written for a different run": "Se ignora el punto de control %s escrito por otra ejecución",
+	"Could not save checkpoint %s: %v":                   "No se pudo guardar el punto de control %s: %v",
+	"Could not save cache: %v":                           "No se pudo guardar la caché: %v",
+	"Could not remove checkpoint: %v":                    "No se pudo eliminar el punto de control: %v",
+	"Leaving out the footer, since the checkpoint lacks the checksum of the output written before": "Se omite el pie, ya que el punto de control no tiene la suma de comprobación de la salida escrita antes",
+	"Could not read %s: %v":                    "No se pudo leer %s: %v",
+	"Could not read the git history of %s: %v": "No se pudo leer el historial de git de %s: %v",
+	"Could not read manifest %s: %v":           "No se pudo leer el manifiesto %s: %v",
+	"Could not parse manifest %s: %v":          "No se pudo analizar el manifiesto %s: %v",
+	"Could not read file %s: %v":               "No se pudo leer el archivo %s: %v",
+	"Cannot read file %s: %v":                  "No se puede leer el archivo %s: %v",
+	"Could not copy file %s: %v":               "No se pudo copiar el archivo %s: %v",
+	"Cannot stat binary file %s: %v":           "No se puede consultar el archivo binario %s: %v",
+	"Cannot hash binary file %s: %v":           "No se puede calcular el hash del archivo binario %s: %v",
+	"Could not read migration %s: %v":          "No se pudo leer la migración %s: %v",
+	"Could not outline file %s: %v":            "No se pudo resumir el archivo %s: %v",
+	"Skipping pattern %s:%d: %v":               "Se omite el patrón %s:%d: %v",
+	"Running as root; files written to mounted volumes will be owned by root (use -u \"$(id -u):$(id -g)\")": "Ejecutando como root; los archivos escritos en volúmenes montados pertenecerán a root (use -u \"$(id -u):$(id -g)\")",
+	"%s is owned by uid %d gid %d but running as uid %d gid %d":                                              "%s pertenece al uid %d gid %d, pero se ejecuta como uid %d gid %d",
+	"Project structure and file contents have been written to %s using %s patterns\n":                        "La estructura del proyecto y el contenido de los archivos se han escrito en %s con patrones de tipo %s\n",
+	"Project structure has been written to %s using %s patterns\n":                                           "La estructura del proyecto se ha escrito en %s con patrones de tipo %s\n",
+	"Resuming %s after %d bytes\n":                                                                           "Reanudando %s después de %d bytes\n",
+	"Created %s\n":                                                                                           "Creado %s\n",
+	"Suggested .gitattributes entries have been written to %s\n":                                             "Las entradas sugeridas para .gitattributes se han escrito en %s\n",
+	"Embedded %d files with %s\n":                                                                            "%d archivos incrustados con %s\n",
+	"Index of %d files written to %s (%d read, %d unchanged)\n":                                              "Índice de %d archivos escrito en %s (%d leídos, %d sin cambios)\n",
+	"Nothing to clean\n":                                                                                     "No hay nada que limpiar\n",
+	"%d files would be removed\n":                                                                            "Se eliminarían %d archivos\n",
+	"Removed %d files\n":                                                                                     "Se eliminaron %d archivos\n",
+	"Error in job %d (%s): %v\n":                                                                             "Error en el trabajo %d (%s): %v\n",
+	"Job %d: %s written to %s\n":                                                                             "Trabajo %d: %s escrito en %s\n",
+
+	// Tree annotations
+	"%d migrations consolidated into Schema": "%d migraciones consolidadas en Schema",
+	"untested":                               "sin pruebas",
+	"line %d":                                "línea %d",
+	"irregular file":                         "archivo irregular",
+	"device":                                 "dispositivo",
+	"character device":                       "dispositivo de caracteres",
+	"socket":                                 "socket",
+	"named pipe":                             "tubería con nombre",
+	"symlink cycle":                          "ciclo de enlaces simbólicos",
+	"%d annotation refers to a path not in the snapshot": "%d anotación se refiere a una ruta que no está en la instantánea",
+	"%d annotations refer to paths not in the snapshot":  "%d anotaciones se refieren a rutas que no están en la instantánea",
+	"… %d more entry":                     "… %d entrada más",
+	"… %d more entries":                   "… %d entradas más",
+	"Entry limits left out %d entry:\n":   "Los límites de entradas dejaron fuera %d entrada:\n",
+	"Entry limits left out %d entries:\n": "Los límites de entradas dejaron fuera %d entradas:\n",
+	"content under %s":                    "contenido en %s",
+	"%d commit":                           "%d commit",
+	"%d commits":                          "%d commits",
+	"query rank %d":                       "puesto %d en la consulta",
+
+	// Summaries and labels
+	"This snapshot is partial: %d entry was left out by limits.\n":    "Esta instantánea es parcial: %d entrada quedó fuera por los límites.\n",
+	"This snapshot is partial: %d entries were left out by limits.\n": "Esta instantánea es parcial: %d entradas quedaron fuera por los límites.\n",
+	"%s (%s): %d entry, %d bytes\n":                                   "%s (%s): %d entrada, %d bytes\n",
+	"%s (%s): %d entries, %d bytes\n":                                 "%s (%s): %d entradas, %d bytes\n",
+	"Largest omitted entries:":                                        "Entradas omitidas más grandes:",
+	"This snapshot is partial: %d file was cut short by limits.\n":    "Esta instantánea es parcial: %d archivo se recortó por los límites.\n",
+	"This snapshot is partial: %d files were cut short by limits.\n":  "Esta instantánea es parcial: %d archivos se recortaron por los límites.\n",
+	"Files cut short:":                     "Archivos recortados:",
+	"%s | %s | %d of %d lines, %d bytes\n": "%s | %s | %d de %d líneas, %d bytes\n",
+	"%s | %s | %d bytes\n":                 "%s | %s | %d bytes\n",
+	"Organization Summary":                 "Resumen de la organización",
+	"Repository":                           "Repositorio",
+	"Files":                                "Archivos",
+	"Size":                                 "Tamaño",
+	"Main languages":                       "Lenguajes principales",
+	"Total":                                "Total",
+	"Languages":                            "Lenguajes",
+	"Language":                             "Lenguaje",
+	"Repositories":                         "Repositorios",
+	"(other)":                              "(otros)",
+	"Shared Dependencies":                  "Dependencias compartidas",
+	"Dependency":                           "Dependencia",
+	"Ecosystem":                            "Ecosistema",
+	"Versions":                             "Versiones",
+	"(unversioned)":                        "(sin versión)",
+	"Dependencies in bold are asked for at more than one version.": "Las dependencias en negrita se piden en más de una versión.",
+	"Error mapping %s: %v\n":                      "Error al mapear %s: %v\n",
+	"%s written to %s\n":                          "%s escrito en %s\n",
+	"Summary of %d repositories written to %s\n":  "Resumen de %d repositorios escrito en %s\n",
+	"Comparing %s and %s":                         "Comparando %s y %s",
+	"Directories only in %s:":                     "Directorios solo en %s:",
+	"Files only in %s:":                           "Archivos solo en %s:",
+	"Divergent shared directories:":               "Directorios compartidos divergentes:",
+	"%d of %d shared files differ":                "%d de %d archivos compartidos difieren",
+	"(%d files)":                                  "(%d archivos)",
+	"Identical content:":                          "Contenido idéntico:",
+	"Same content at different paths:":            "Mismo contenido en rutas distintas:",
+	"%d shared files: %d identical, %d differ":    "%d archivos compartidos: %d idénticos, %d difieren",
+	"Comparison has been written to %s\n":         "La comparación se ha escrito en %s\n",
+	"Skipped nothing\n":                           "No se omitió nada\n",
+	"Skipped %d entry:\n":                         "Se omitió %d entrada:\n",
+	"Skipped %d entries:\n":                       "Se omitieron %d entradas:\n",
+	"%d by ignore or filter patterns":             "%d por patrones de ignorar o filtrar",
+	"%d by the built-in directory list":           "%d por la lista integrada de directorios",
+	"%d by the built-in file list":                "%d por la lista integrada de archivos",
+	"%d hidden":                                   "%d ocultos",
+	"%d binary":                                   "%d binarios",
+	"%d over the size limit":                      "%d por encima del límite de tamaño",
+	"%d over the entry limits":                    "%d por encima de los límites de entradas",
+	"%d lock files":                               "%d archivos de bloqueo",
+	"%d unreadable":                               "%d ilegibles",
+	"%d special files":                            "%d archivos especiales",
+	"Checking %s against the template %s":         "Comprobando %s con la plantilla %s",
+	"Missing:":                                    "Faltan:",
+	"Extra:":                                      "Sobran:",
+	"%d missing, %d extra":                        "%d faltan, %d sobran",
+	"Conformance report has been written to %s\n": "El informe de conformidad se ha escrito en %s\n",
+	"Run":    "Ejecución",
+	"Tokens": "Tokens",
+	"Change": "Cambio",
+	"The last run grew from %s to %s and from %d to %d tokens, more than %g%%; look for new generated or vendored files": "La última ejecución creció de %s a %s y de %d a %d tokens, más del %g%%; busque archivos generados o incluidos de terceros nuevos",
+	"Included %d file, %s, %d tokens\n":        "Incluido %d archivo, %s, %d tokens\n",
+	"Included %d files, %s, %d tokens\n":       "Incluidos %d archivos, %s, %d tokens\n",
+	"Included %d file, %s, about %d tokens\n":  "Incluido %d archivo, %s, unos %d tokens\n",
+	"Included %d files, %s, about %d tokens\n": "Incluidos %d archivos, %s, unos %d tokens\n",
+	"Most tokens:\n":                           "Más tokens:\n",
+	"+ %s: %d tokens\n":                        "+ %s: %d tokens\n",
+	"%d files would be included with %d tokens, %d entries listed without content and %d left out\n":       "Se incluirían %d archivos con %d tokens, %d entradas se listarían sin contenido y %d quedarían fuera\n",
+	"%d files would be included with about %d tokens, %d entries listed without content and %d left out\n": "Se incluirían %d archivos con unos %d tokens, %d entradas se listarían sin contenido y %d quedarían fuera\n",
+	"dropped for the token budget":                                                 "descartado por el presupuesto de tokens",
+	"cut to %d of %d lines for the token budget":                                   "recortado a %d de %d líneas por el presupuesto de tokens",
+	"[outline of %d lines, %d entries]\n":                                          "[esquema de %d líneas, %d entradas]\n",
+	"first %d lines, over the size limit":                                          "primeras %d líneas, supera el límite de tamaño",
+	"last %d lines, over the size limit":                                           "últimas %d líneas, supera el límite de tamaño",
+	"first %d and last %d lines, over the size limit":                              "primeras %d y últimas %d líneas, supera el límite de tamaño",
+	"%d over the token budget":                                                     "%d por encima del presupuesto de tokens",
+	"The tree alone takes about %d tokens, over the budget of %d; no content fits": "El árbol por sí solo ocupa unos %d tokens, más que el presupuesto de %d; no cabe ningún contenido",
+	"Tokenizer %s failed: %v; estimating tokens from sizes":                        "El tokenizador %s falló: %v; se estiman los tokens a partir de los tamaños",
+	"%s does not fit in a part, so its part is larger":                             "%s no cabe en una parte, así que su parte es mayor",
+	"Part %d of %d":                          "Parte %d de %d",
+	"Part %d of %d has been written to %s\n": "La parte %d de %d se ha escrito en %s\n",
+	"Omissions":                              "Omisiones",
+	"Scanning: %d files, %s":                 "Explorando: %d archivos, %s",
+	"Writing: %.1f MB":                       "Escribiendo: %.1f MB",
+	"Interrupted: run the same command again to resume %s\n": "Interrumpido: ejecute el mismo comando otra vez para reanudar %s\n",
+	"Interrupted: the incomplete output was kept as %s\n":    "Interrumpido: la salida incompleta se conservó como %s\n",
+	"Interrupted: removed the incomplete output %s\n":        "Interrumpido: se eliminó la salida incompleta %s\n",
+	"Could not rename the incomplete output: %v":             "No se pudo renombrar la salida incompleta: %v",
+	"File Index":             "Índice de archivos",
+	"Findings":               "Hallazgos",
+	"%d finding":             "%d hallazgo",
+	"%d findings":            "%d hallazgos",
+	"Rule statistics:":       "Estadísticas de reglas:",
+	" (unused)":              " (sin usar)",
+	"%s – project structure": "%s – estructura del proyecto",
+	"%d files, %s":           "%d archivos, %s",
+	"%d lines":               "%d líneas",
+	"(%d entries)":           "(%d entradas)",
+	"(%d entry)":             "(%d entrada)",
+
+	// Prose format
+	"Directory %s is listed without its contents.": "El directorio %s aparece sin su contenido.",
+	"Directory %s is empty.":                       "El directorio %s está vacío.",
+	"%d subdirectory":                              "%d subdirectorio",
+	"%d subdirectories":                            "%d subdirectorios",
+	"%d file":                                      "%d archivo",
+	"%d files":                                     "%d archivos",
+	"%s and %s":                                    "%s y %s",
+	"Directory %s contains %s: %s.":                "El directorio %s contiene %s: %s.",
+	", ":                                           ", ",
+	"content omitted":                              "contenido omitido",
+
+	// The explain command
+	"%s: included (the root itself)\n":                                                                     "%s: incluido (la propia raíz)\n",
+	"%s: excluded as the output file being written\n":                                                      "%s: excluido por ser el archivo de salida que se escribe\n",
+	"%s: not mapped, because its parent %s is a symlink cycle, which is not followed\n":                    "%s: no se mapea, porque su padre %s es un ciclo de enlaces simbólicos, que no se sigue\n",
+	"%s: a symlink cycle leading back into a directory being walked; listed in the tree without content\n": "%s: un ciclo de enlaces simbólicos que vuelve a un directorio que se recorre; listado en el árbol sin contenido\n",
+	"%s: matches no filter pattern, so it is mapped only if entries inside match\n":                        "%s: no coincide con ningún patrón de filtro, así que solo se mapea si coinciden entradas de su interior\n",
+	"hidden from the tree":                       "oculto en el árbol",
+	"listed in the tree without content":         "listado en el árbol sin contenido",
+	"excluded":                                   "excluido",
+	"excluded because its parent %s is excluded": "excluido porque su padre %s está excluido",
+	"%s: %s by %s; %s\n":                         "%s: %s por %s; %s\n",
+	" (matches filter pattern %q at %s)":         " (coincide con el patrón de filtro %q en %s)",
+	" (re-included by pattern %q at %s)":         " (reincluido por el patrón %q en %s)",
+	" with its %s (> %d bytes)":                  " con sus %s (> %d bytes)",
+	"%s: included%s\n":                           "%s: incluido%s\n",
+	" via %s":                                    " a través de %s",
+	"  also matched by %q at %s%s\n":             "  también coincide con %q en %s%s\n",
+	"the negated filter pattern %q at %s":        "el patrón de filtro negado %q en %s",
+	"ignore pattern %q at %s":                    "el patrón de exclusión %q en %s",
+	"the filter file: no filter pattern matches": "el archivo de filtro: ningún patrón de filtro coincide",
+	"the size limit (%s)":                        "el límite de tamaño (%s)",
+	"a read failure (%s)":                        "un fallo de lectura (%s)",
+	"the special file rule (a %s is never read)": "la regla de archivos especiales (un %s nunca se lee)",
+	"the hidden entry rule (--no-hidden)":        "la regla de entradas ocultas (--no-hidden)",
+	"the entry limit (%s)":                       "el límite de entradas (%s)",
+	"the built-in %s rule %q":                    "la regla integrada %s %q",
+}
@@ -0,0 +1,121 @@
+// Package i18n translates the human-facing messages of directory-mapper.
+// Messages are looked up by their English format string, so untranslated
+// messages fall back to English.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// catalogs holds the translations of each supported language besides English
+var catalogs = map[string]map[string]string{
+	"es": spanish,
+	"ja": japanese,
+}
+
+// Printer formats messages in one language. The zero value prints English.
+type Printer struct {
+	lang     string
+	messages map[string]string
+}
+
+// Default is the printer for warnings and status messages
+var Default Printer
+
+// Lookup returns the printer for a language tag such as "es", "ja-JP" or
+// "es_ES.UTF-8". An empty tag, "C" and "POSIX" select English.
+func Lookup(tag string) (Printer, error) {
+	lang := normalize(tag)
+	if lang == "" || lang == "en" {
+		return Printer{}, nil
+	}
+	messages, ok := catalogs[lang]
+	if !ok {
+		return Printer{}, fmt.Errorf("unsupported language %q (available: %s)", tag, strings.Join(Languages(), ", "))
+	}
+	return Printer{lang: lang, messages: messages}, nil
+}
+
+// FromEnv returns the printer for the locale in LC_ALL, LC_MESSAGES or LANG,
+// falling back to English for unsupported locales
+func FromEnv() Printer {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if tag := os.Getenv(name); tag != "" {
+			p, _ := Lookup(tag)
+			return p
+		}
+	}
+	return Printer{}
+}
+
+// Languages lists the supported language codes
+func Languages() []string {
+	langs := []string{"en"}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// normalize reduces a locale or language tag to its language code
+func normalize(tag string) string {
+	tag = strings.ToLower(tag)
+	if i := strings.IndexAny(tag, "_-.@"); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "c" || tag == "posix" {
+		return ""
+	}
+	return tag
+}
+
+// Lang returns the language code of the printer
+func (p Printer) Lang() string {
+	if p.lang == "" {
+		return "en"
+	}
+	return p.lang
+}
+
+// Sprintf formats the translation of format
+func (p Printer) Sprintf(format string, args ...any) string {
+	if translated, ok := p.messages[format]; ok {
+		format = translated
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Fprintf writes the translation of format to w
+func (p Printer) Fprintf(w io.Writer, format string, args ...any) {
+	io.WriteString(w, p.Sprintf(format, args...))
+}
+
+// Logger receives warnings and status messages when set. Each record carries
+// the English format string as its "id" attribute, which stays the same
+// whatever the language of the message.
+var Logger *slog.Logger
+
+// Warnf writes a translated warning line to stderr, or logs it to Logger
+func Warnf(format string, args ...any) {
+	if Logger != nil {
+		Logger.Warn(Default.Sprintf(format, args...), "id", format)
+		return
+	}
+	fmt.Fprintln(os.Stderr, Default.Sprintf("Warning: %s", Default.Sprintf(format, args...)))
+}
+
+// Infof writes a translated status message, ending in a newline, to stderr,
+// or logs it to Logger
+func Infof(format string, args ...any) {
+	if Logger != nil {
+		Logger.Info(strings.TrimSuffix(Default.Sprintf(format, args...), "\n"), "id", format)
+		return
+	}
+	Default.Fprintf(os.Stderr, format, args...)
+}
@@ -0,0 +1,25 @@
+package i18n
+
+import (
+	"sort"
+	"testing"
+)
+
+// Every catalog translates the same messages, so no language falls back to
+// English for some of them
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for lang, messages := range catalogs {
+		for other, otherMessages := range catalogs {
+			var missing []string
+			for key := range otherMessages {
+				if _, ok := messages[key]; !ok {
+					missing = append(missing, key)
+				}
+			}
+			sort.Strings(missing)
+			for _, key := range missing {
+				t.Errorf("%s catalog lacks %q, translated in %s", lang, key, other)
+			}
+		}
+	}
+}
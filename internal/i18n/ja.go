@@ -0,0 +1,201 @@
+package i18n
+
+// japanese is the Japanese catalog
+var japanese = map[string]string{
+	// Warnings and status messages
+	"Warning: %s":                           "警告: %s",
+	"Error: %v\n":                           "エラー: %v\n",
+	"Ignoring unreadable checkpoint %s: %v": "読み取れないチェックポイント %s を無視します: %v",
+	"Ignoring checkpoint %s written for a different run": "別の実行で書かれたチェックポイント %s を無視します",
+	"Could not save checkpoint %s: %v":                   "チェックポイント %s を保存できませんでした: %v",
+	"Could not save cache: %v":                           "キャッシュを保存できませんでした: %v",
+	"Could not remove checkpoint: %v":                    "チェックポイントを削除できませんでした: %v",
+	"Leaving out the footer, since the checkpoint lacks the checksum of the output written before": "チェックポイントに以前書き込まれた出力のチェックサムがないため、フッターを省略します",
+	"Could not read %s: %v":                    "%s を読み取れませんでした: %v",
+	"Could not read the git history of %s: %v": "%s の git 履歴を読み取れませんでした: %v",
+	"Could not read manifest %s: %v":           "マニフェスト %s を読み取れませんでした: %v",
+	"Could not parse manifest %s: %v":          "マニフェスト %s を解析できませんでした: %v",
+	"Could not read file %s: %v":               "ファイル %s を読み取れませんでした: %v",
+	"Cannot read file %s: %v":                  "ファイル %s を読み取れません: %v",
+	"Could not copy file %s: %v":               "ファイル %s をコピーできませんでした: %v",
+	"Cannot stat binary file %s: %v":           "バイナリファイル %s の情報を取得できません: %v",
+	"Cannot hash binary file %s: %v":           "バイナリファイル %s のハッシュを計算できません: %v",
+	"Could not read migration %s: %v":          "マイグレーション %s を読み取れませんでした: %v",
+	"Could not outline file %s: %v":            "ファイル %s のアウトラインを作成できませんでした: %v",
+	"Skipping pattern %s:%d: %v":               "パターン %s:%d をスキップします: %v",
+	"Running as root; files written to mounted volumes will be owned by root (use -u \"$(id -u):$(id -g)\")": "root として実行しています。マウントされたボリュームに書き込んだファイルの所有者は root になります (-u \"$(id -u):$(id -g)\" を指定してください)",
+	"%s is owned by uid %d gid %d but running as uid %d gid %d":                                              "%s の所有者は uid %d gid %d ですが、uid %d gid %d として実行しています",
+	"Project structure and file contents have been written to %s using %s patterns\n":                        "%[2]s パターンを使用してプロジェクト構造とファイル内容を %[1]s に書き込みました\n",
+	"Project structure has been written to %s using %s patterns\n":                                           "%[2]s パターンを使用してプロジェクト構造を %[1]s に書き込みました\n",
+	"Resuming %s after %d bytes\n":                                                                           "%s を %d バイト目から再開します\n",
+	"Created %s\n":                                                                                           "%s を作成しました\n",
+	"Suggested .gitattributes entries have been written to %s\n":                                             ".gitattributes の推奨エントリを %s に書き込みました\n",
+	"Embedded %d files with %s\n":                                                                            "%[2]s で %[1]d 件のファイルを埋め込みました\n",
+	"Index of %d files written to %s (%d read, %d unchanged)\n":                                              "%[1]d 件のファイルの索引を %[2]s に書き込みました（読み込み %[3]d 件、変更なし %[4]d 件）\n",
+	"Nothing to clean\n":                                                                                     "削除するものはありません\n",
+	"%d files would be removed\n":                                                                            "%d 件のファイルが削除されます\n",
+	"Removed %d files\n":                                                                                     "%d 件のファイルを削除しました\n",
+	"Error in job %d (%s): %v\n":                                                                             "ジョブ %d (%s) でエラー: %v\n",
+	"Job %d: %s written to %s\n":                                                                             "ジョブ %d: %s を %s に書き込みました\n",
+
+	// Tree annotations
+	"%d migrations consolidated into Schema": "%d 件のマイグレーションを Schema に統合",
+	"untested":                               "テストなし",
+	"line %d":                                "%d 行目",
+	"irregular file":                         "特殊ファイル",
+	"device":                                 "デバイス",
+	"character device":                       "キャラクタデバイス",
+	"socket":                                 "ソケット",
+	"named pipe":                             "名前付きパイプ",
+	"symlink cycle":                          "シンボリックリンクの循環",
+	"%d annotation refers to a path not in the snapshot": "%d 件の注釈がスナップショットにないパスを参照しています",
+	"%d annotations refer to paths not in the snapshot":  "%d 件の注釈がスナップショットにないパスを参照しています",
+	"… %d more entry":                     "… 他 %d 件",
+	"… %d more entries":                   "… 他 %d 件",
+	"Entry limits left out %d entry:\n":   "エントリ数の制限により %d 件が除外されました:\n",
+	"Entry limits left out %d entries:\n": "エントリ数の制限により %d 件が除外されました:\n",
+	"content under %s":                    "内容は %s",
+	"%d commit":                           "コミット %d 件",
+	"%d commits":                          "コミット %d 件",
+	"query rank %d":                       "クエリ順位 %d",
+
+	// Summaries and labels
+	"This snapshot is partial: %d entry was left out by limits.\n":    "このスナップショットは部分的です: %d 件のエントリが制限により除外されました。\n",
+	"This snapshot is partial: %d entries were left out by limits.\n": "このスナップショットは部分的です: %d 件のエントリが制限により除外されました。\n",
+	"%s (%s): %d entry, %d bytes\n":                                   "%s (%s): %d 件, %d バイト\n",
+	"%s (%s): %d entries, %d bytes\n":                                 "%s (%s): %d 件, %d バイト\n",
+	"Largest omitted entries:":                                        "除外された最大のエントリ:",
+	"This snapshot is partial: %d file was cut short by limits.\n":    "このスナップショットは部分的です: %d 件のファイルが制限により短縮されました。\n",
+	"This snapshot is partial: %d files were cut short by limits.\n":  "このスナップショットは部分的です: %d 件のファイルが制限により短縮されました。\n",
+	"Files cut short:":                     "短縮されたファイル:",
+	"%s | %s | %d of %d lines, %d bytes\n": "%s | %s | %d / %d 行, %d バイト\n",
+	"%s | %s | %d bytes\n":                 "%s | %s | %d バイト\n",
+	"Organization Summary":                 "組織のまとめ",
+	"Repository":                           "リポジトリ",
+	"Files":                                "ファイル",
+	"Size":                                 "サイズ",
+	"Main languages":                       "主な言語",
+	"Total":                                "合計",
+	"Languages":                            "言語",
+	"Language":                             "言語",
+	"Repositories":                         "リポジトリ数",
+	"(other)":                              "(その他)",
+	"Shared Dependencies":                  "共通の依存関係",
+	"Dependency":                           "依存関係",
+	"Ecosystem":                            "エコシステム",
+	"Versions":                             "バージョン",
+	"(unversioned)":                        "(バージョンなし)",
+	"Dependencies in bold are asked for at more than one version.": "太字の依存関係は複数のバージョンで要求されています。",
+	"Error mapping %s: %v\n":                      "%s のマッピング中にエラー: %v\n",
+	"%s written to %s\n":                          "%s を %s に書き込みました\n",
+	"Summary of %d repositories written to %s\n":  "%d 個のリポジトリのまとめを %s に書き込みました\n",
+	"Comparing %s and %s":                         "%s と %s の比較",
+	"Directories only in %s:":                     "%s にのみあるディレクトリ:",
+	"Files only in %s:":                           "%s にのみあるファイル:",
+	"Divergent shared directories:":               "内容が分かれた共通ディレクトリ:",
+	"%d of %d shared files differ":                "共通ファイル %[2]d 個中 %[1]d 個が異なります",
+	"(%d files)":                                  "(%d 個のファイル)",
+	"Identical content:":                          "同一の内容:",
+	"Same content at different paths:":            "異なるパスにある同じ内容:",
+	"%d shared files: %d identical, %d differ":    "共通ファイル %d 個: 同一 %d 個、相違 %d 個",
+	"Comparison has been written to %s\n":         "比較結果を %s に書き込みました\n",
+	"Skipped nothing\n":                           "除外された項目はありません\n",
+	"Skipped %d entry:\n":                         "%d 個の項目を除外しました:\n",
+	"Skipped %d entries:\n":                       "%d 個の項目を除外しました:\n",
+	"%d by ignore or filter patterns":             "無視またはフィルタのパターンで %d 個",
+	"%d by the built-in directory list":           "組み込みのディレクトリリストで %d 個",
+	"%d by the built-in file list":                "組み込みのファイルリストで %d 個",
+	"%d hidden":                                   "隠しファイル %d 個",
+	"%d binary":                                   "バイナリ %d 個",
+	"%d over the size limit":                      "サイズ上限超過 %d 個",
+	"%d over the entry limits":                    "項目数の上限超過 %d 個",
+	"%d lock files":                               "ロックファイル %d 個",
+	"%d unreadable":                               "読み取り不可 %d 個",
+	"%d special files":                            "特殊ファイル %d 個",
+	"Checking %s against the template %s":         "%s をテンプレート %s と照合しています",
+	"Missing:":                                    "不足:",
+	"Extra:":                                      "余分:",
+	"%d missing, %d extra":                        "不足 %d 個、余分 %d 個",
+	"Conformance report has been written to %s\n": "適合性レポートを %s に書き込みました\n",
+	"Run":    "実行",
+	"Tokens": "トークン",
+	"Change": "変化",
+	"The last run grew from %s to %s and from %d to %d tokens, more than %g%%; look for new generated or vendored files": "前回の実行で %s から %s、%d から %d トークンに増え、%g%% を超えました。新しい生成ファイルやベンダーファイルを確認してください",
+	"Included %d file, %s, %d tokens\n":        "%d 個のファイルを含めました (%s、%d トークン)\n",
+	"Included %d files, %s, %d tokens\n":       "%d 個のファイルを含めました (%s、%d トークン)\n",
+	"Included %d file, %s, about %d tokens\n":  "%d 個のファイルを含めました (%s、約 %d トークン)\n",
+	"Included %d files, %s, about %d tokens\n": "%d 個のファイルを含めました (%s、約 %d トークン)\n",
+	"Most tokens:\n":                           "トークンの多いファイル:\n",
+	"+ %s: %d tokens\n":                        "+ %s: %d トークン\n",
+	"%d files would be included with %d tokens, %d entries listed without content and %d left out\n":       "%d 個のファイル (%d トークン) が含まれ、%d 個の項目が内容なしで一覧され、%d 個が除外されます\n",
+	"%d files would be included with about %d tokens, %d entries listed without content and %d left out\n": "%d 個のファイル (約 %d トークン) が含まれ、%d 個の項目が内容なしで一覧され、%d 個が除外されます\n",
+	"dropped for the token budget":                                                 "トークン予算のため除外",
+	"cut to %d of %d lines for the token budget":                                   "トークン予算のため %d / %d 行に短縮",
+	"[outline of %d lines, %d entries]\n":                                          "[%d 行、%d 項目のアウトライン]\n",
+	"first %d lines, over the size limit":                                          "サイズ上限超過のため先頭 %d 行",
+	"last %d lines, over the size limit":                                           "サイズ上限超過のため末尾 %d 行",
+	"first %d and last %d lines, over the size limit":                              "サイズ上限超過のため先頭 %d 行と末尾 %d 行",
+	"%d over the token budget":                                                     "トークン予算超過 %d 個",
+	"The tree alone takes about %d tokens, over the budget of %d; no content fits": "ツリーだけで約 %d トークンあり、予算 %d を超えています。内容は含められません",
+	"Tokenizer %s failed: %v; estimating tokens from sizes":                        "トークナイザー %s が失敗しました: %v。トークン数はサイズから推定します",
+	"%s does not fit in a part, so its part is larger":                             "%s は 1 つのパートに収まらないため、そのパートは大きくなります",
+	"Part %d of %d":                          "パート %d / %d",
+	"Part %d of %d has been written to %s\n": "パート %d / %d を %s に書き込みました\n",
+	"Omissions":                              "除外",
+	"Scanning: %d files, %s":                 "走査中: %d ファイル, %s",
+	"Writing: %.1f MB":                       "書き込み中: %.1f MB",
+	"Interrupted: run the same command again to resume %s\n": "中断しました: 同じコマンドを再度実行すると %s を再開します\n",
+	"Interrupted: the incomplete output was kept as %s\n":    "中断しました: 不完全な出力を %s として残しました\n",
+	"Interrupted: removed the incomplete output %s\n":        "中断しました: 不完全な出力 %s を削除しました\n",
+	"Could not rename the incomplete output: %v":             "不完全な出力の名前を変更できませんでした: %v",
+	"File Index":             "ファイル索引",
+	"Findings":               "検出結果",
+	"%d finding":             "%d 件の検出",
+	"%d findings":            "%d 件の検出",
+	"Rule statistics:":       "ルールの統計:",
+	" (unused)":              " (未使用)",
+	"%s – project structure": "%s – プロジェクト構造",
+	"%d files, %s":           "%d ファイル, %s",
+	"%d lines":               "%d 行",
+	"(%d entries)":           "(%d 件)",
+	"(%d entry)":             "(%d 件)",
+
+	// Prose format
+	"Directory %s is listed without its contents.": "ディレクトリ %s は内容を省略して表示されています。",
+	"Directory %s is empty.":                       "ディレクトリ %s は空です。",
+	"%d subdirectory":                              "%d 個のサブディレクトリ",
+	"%d subdirectories":                            "%d 個のサブディレクトリ",
+	"%d file":                                      "%d 個のファイル",
+	"%d files":                                     "%d 個のファイル",
+	"%s and %s":                                    "%sと%s",
+	"Directory %s contains %s: %s.":                "ディレクトリ %s には%sがあります: %s。",
+	", ":                                           "、",
+	"content omitted":                              "内容は省略",
+
+	// The explain command
+	"%s: included (the root itself)\n":                                                                     "%s: 含まれます（ルート自体）\n",
+	"%s: excluded as the output file being written\n":                                                      "%s: 書き込み中の出力ファイルのため除外されます\n",
+	"%s: not mapped, because its parent %s is a symlink cycle, which is not followed\n":                    "%s: 親の %s がたどらないシンボリックリンクの循環のため、マップされません\n",
+	"%s: a symlink cycle leading back into a directory being walked; listed in the tree without content\n": "%s: 走査中のディレクトリに戻るシンボリックリンクの循環です。内容なしでツリーに表示されます\n",
+	"%s: matches no filter pattern, so it is mapped only if entries inside match\n":                        "%s: どのフィルターパターンにも一致しないため、中のエントリが一致する場合にのみマップされます\n",
+	"hidden from the tree":                       "ツリーから隠されます",
+	"listed in the tree without content":         "内容なしでツリーに表示されます",
+	"excluded":                                   "除外されます",
+	"excluded because its parent %s is excluded": "親の %s が除外されているため除外されます",
+	"%s: %s by %s; %s\n":                         "%[1]s: %[3]s により%[2]s。%[4]s\n",
+	" (matches filter pattern %q at %s)":         "（%[2]s のフィルターパターン %[1]q に一致）",
+	" (re-included by pattern %q at %s)":         "（%[2]s のパターン %[1]q により再び含まれます）",
+	" with its %s (> %d bytes)":                  "（%s、> %d バイト）",
+	"%s: included%s\n":                           "%s: 含まれます%s\n",
+	" via %s":                                    "（%s 経由）",
+	"  also matched by %q at %s%s\n":             "  %[2]s の %[1]q にも一致%[3]s\n",
+	"the negated filter pattern %q at %s":        "%[2]s の否定フィルターパターン %[1]q",
+	"ignore pattern %q at %s":                    "%[2]s の除外パターン %[1]q",
+	"the filter file: no filter pattern matches": "フィルターファイル（どのフィルターパターンにも一致しない）",
+	"the size limit (%s)":                        "サイズ制限（%s）",
+	"a read failure (%s)":                        "読み込みの失敗（%s）",
+	"the special file rule (a %s is never read)": "特殊ファイルの規則（%s は読み込まれません）",
+	"the hidden entry rule (--no-hidden)":        "隠しエントリの規則（--no-hidden）",
+	"the entry limit (%s)":                       "エントリ数の制限（%s）",
+	"the built-in %s rule %q":                    "組み込みの %s 規則 %q",
+}
@@ -0,0 +1,290 @@
+// Package yaml parses the small subset of YAML used by directory-mapper's own
+// files and by the infrastructure summaries, and quotes scalars for the YAML
+// output format.
+package yaml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlLine is a significant line of a YAML document
+type yamlLine struct {
+	number int    // 1-based line number in the source
+	indent int    // Leading spaces
+	text   string // Content without indentation or comments
+}
+
+// Parse parses the subset of YAML used by the tool's own files: block
+// mappings, block sequences, quoted and plain scalars and flow lists like
+// [a, b]. Mappings become map[string]any, sequences []any and scalars string.
+func Parse(data string) (any, error) {
+	lines := make([]yamlLine, 0)
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(raw, "---") || strings.HasPrefix(raw, "...") {
+			continue
+		}
+		text := stripYAMLComment(raw)
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{
+			number: i + 1,
+			indent: len(text) - len(trimmed),
+			text:   strings.TrimRight(trimmed, " \t"),
+		})
+	}
+
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].number)
+	}
+	return value, nil
+}
+
+// stripYAMLComment removes a trailing # comment that is outside quotes
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLBlock parses the mapping or sequence starting at lines[i]
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if lines[i].text == "-" || strings.HasPrefix(lines[i].text, "- ") {
+		return parseYAMLSequence(lines, i, indent)
+	}
+	return parseYAMLMapping(lines, i, indent)
+}
+
+// parseYAMLSequence parses consecutive "- item" lines at the given indent
+func parseYAMLSequence(lines []yamlLine, i, indent int) (any, int, error) {
+	items := make([]any, 0)
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+			return nil, i, fmt.Errorf("line %d: expected sequence item", line.number)
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		switch {
+		case rest == "":
+			// Item content is on the following, deeper indented lines
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				items = append(items, "")
+				i++
+				continue
+			}
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, value)
+			i = next
+		case isYAMLMappingEntry(rest):
+			// "- key: value" starts a mapping indented past the dash
+			itemIndent := indent + len(line.text) - len(rest)
+			lines[i] = yamlLine{number: line.number, indent: itemIndent, text: rest}
+			value, next, err := parseYAMLMapping(lines, i, itemIndent)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, value)
+			i = next
+		default:
+			items = append(items, parseYAMLScalar(rest))
+			i++
+		}
+	}
+	return items, i, nil
+}
+
+// parseYAMLMapping parses consecutive "key: value" lines at the given indent
+func parseYAMLMapping(lines []yamlLine, i, indent int) (any, int, error) {
+	m := make(map[string]any)
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if !isYAMLMappingEntry(line.text) {
+			return nil, i, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+
+		key, rest, _ := strings.Cut(line.text, ":")
+		key = Unquote(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+		if _, dup := m[key]; dup {
+			return nil, i, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+
+		if rest != "" {
+			m[key] = parseYAMLScalar(rest)
+			i++
+			continue
+		}
+
+		// Nested block; sequences may sit at the same indent as their key
+		if i+1 < len(lines) && (lines[i+1].indent > indent ||
+			(lines[i+1].indent == indent && strings.HasPrefix(lines[i+1].text, "- "))) {
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			m[key] = value
+			i = next
+			continue
+		}
+
+		m[key] = ""
+		i++
+	}
+	return m, i, nil
+}
+
+// isYAMLMappingEntry reports whether text looks like "key:" or "key: value"
+func isYAMLMappingEntry(text string) bool {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		end := strings.IndexByte(text[1:], text[0])
+		return end >= 0 && strings.HasPrefix(text[end+2:], ":")
+	}
+	key, rest, ok := strings.Cut(text, ":")
+	return ok && key != "" && !strings.HasPrefix(key, "[") && (rest == "" || rest[0] == ' ')
+}
+
+// parseYAMLScalar converts an inline value, expanding flow lists
+func parseYAMLScalar(text string) any {
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		items := make([]any, 0)
+		if inner == "" {
+			return items
+		}
+		for _, item := range strings.Split(inner, ",") {
+			items = append(items, Unquote(strings.TrimSpace(item)))
+		}
+		return items
+	}
+	return Unquote(text)
+}
+
+// Unquote strips matching single or double quotes
+func Unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		inner := s[1 : len(s)-1]
+		if s[0] == '\'' {
+			return strings.ReplaceAll(inner, "''", "'")
+		}
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(inner)
+	}
+	return s
+}
+
+// plainScalar matches strings that can be written without quotes
+var plainScalar = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./+@ -]*$`)
+
+// datePrefix matches strings YAML loaders may read as timestamps
+var datePrefix = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}`)
+
+// Quote returns s as a scalar that reads back as the same string, leaving it
+// plain when that is unambiguous
+func Quote(s string) string {
+	if plainScalar.MatchString(s) && !strings.HasSuffix(s, " ") && !looksTyped(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// looksTyped reports whether a plain scalar would be read as something other than a string
+func looksTyped(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", ".inf", "-.inf", ".nan":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return true
+	}
+	return datePrefix.MatchString(s)
+}
+
+// Block returns s as a literal block scalar, the value of a key indented by
+// indent, so multi-line text stays readable. Strings a block cannot hold
+// exactly are double-quoted instead, or tagged !!binary and base64-encoded
+// when they are not valid UTF-8.
+func Block(s, indent string) string {
+	indent += "  "
+	if !utf8.ValidString(s) {
+		return "!!binary " + strconv.Quote(base64.StdEncoding.EncodeToString([]byte(s)))
+	}
+	// A block of empty lines reads back as an empty string
+	if strings.Trim(s, "\n") == "" || strings.ContainsFunc(s, notInBlock) {
+		return strconv.Quote(s)
+	}
+
+	header := "|"
+	// The indentation of the first line cannot be told from the block's own
+	first, _, _ := strings.Cut(strings.TrimLeft(s, "\n"), "\n")
+	if strings.HasPrefix(first, " ") {
+		header += "2"
+	}
+	body := s
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		header += "-"
+	case strings.HasSuffix(s, "\n\n"):
+		header += "+"
+		body = s[:len(s)-1]
+	default:
+		body = s[:len(s)-1]
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for _, line := range strings.Split(body, "\n") {
+		b.WriteByte('\n')
+		if line != "" {
+			b.WriteString(indent)
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// notInBlock reports whether r cannot appear as itself in a block scalar:
+// control characters other than tab and line feed, and the characters YAML
+// treats as line breaks or a byte order mark
+func notInBlock(r rune) bool {
+	switch r {
+	case '\t', '\n':
+		return false
+	case 0x85, 0x2028, 0x2029, 0xFEFF:
+		return true
+	}
+	return r < 0x20 || r == 0x7F || r >= 0x80 && r < 0xA0
+}
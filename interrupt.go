@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// errInterrupted is returned by a run stopped by a signal. The run has
+// already told what became of its output, so it exits without a message.
+var errInterrupted = &exitCodeError{exitInterrupted, nil}
+
+// terminated records that the signal stopping the run was SIGTERM
+var terminated atomic.Bool
+
+// interruptContext returns a context cancelled by the first SIGINT or
+// SIGTERM. Signals are handled as usual again afterwards, so a second
+// Ctrl-C kills a run that does not stop quickly enough.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			terminated.Store(sig == syscall.SIGTERM)
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+// abandonOutput deals with the output of an interrupted run. With a
+// checkpoint the part written so far is kept to resume from; otherwise the
+// output is removed, or with --keep-partial renamed so it cannot be taken
+// for a complete snapshot.
+func abandonOutput(output *os.File, buffered *bufio.Writer, outputPath string, opts *cliOptions) {
+	if buffered != nil && (opts.Checkpoint != nil || opts.keepPartial) {
+		buffered.Flush()
+	}
+	output.Close()
+	switch {
+	case opts.Checkpoint != nil:
+		opts.Checkpoint.Save()
+		i18n.Infof("Interrupted: run the same command again to resume %s\n", outputPath)
+	case opts.keepPartial && buffered != nil:
+		partial := outputPath + ".partial"
+		if err := os.Rename(outputPath, partial); err != nil {
+			i18n.Warnf("Could not rename the incomplete output: %v", err)
+			return
+		}
+		i18n.Infof("Interrupted: the incomplete output was kept as %s\n", partial)
+	default:
+		os.Remove(outputPath)
+		i18n.Infof("Interrupted: removed the incomplete output %s\n", outputPath)
+	}
+}
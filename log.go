@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// levelVerbose is the level of the entries skipped by the walk, logged with
+// --verbose: between info and debug
+const levelVerbose = slog.LevelDebug + 2
+
+// Verbosity levels set by -v, -vv and --debug
+const (
+	verboseDirs  = 1 // Skipped directories
+	verboseFiles = 2 // Every skipped entry
+	verboseDebug = 3 // Also the directories walked and timings
+)
+
+// warnings counts the warnings logged, which make a completed run exit with
+// exitWarnings
+var warnings atomic.Int64
+
+// countingHandler counts the warnings passing through to its handler
+type countingHandler struct {
+	slog.Handler
+}
+
+func (h countingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level == slog.LevelWarn {
+		warnings.Add(1)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h countingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return countingHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h countingHandler) WithGroup(name string) slog.Handler {
+	return countingHandler{h.Handler.WithGroup(name)}
+}
+
+// newLogger returns the logger of a run writing to w in format, "text" or
+// "json", showing the records of verbosity and above
+func newLogger(w io.Writer, format string, verbosity int) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	switch {
+	case verbosity >= verboseDebug:
+		level = slog.LevelDebug
+	case verbosity > 0:
+		level = levelVerbose
+	}
+	var handler slog.Handler
+	switch format {
+	case "", "text": // Commands without --log-format log as text
+		handler = &textHandler{w: w, level: level}
+	case "json":
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: nameLevels})
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use text or json", format)
+	}
+	return slog.New(countingHandler{handler}), nil
+}
+
+// nameLevels writes levelVerbose as VERBOSE rather than DEBUG+2
+func nameLevels(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 && a.Value.Any() == levelVerbose {
+		a.Value = slog.StringValue("VERBOSE")
+	}
+	return a
+}
+
+// textHandler writes records as the plain lines directory-mapper has always
+// written, leaving out their attributes: warnings prefixed as such, other
+// records as their message
+type textHandler struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level slog.Level
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	line := r.Message
+	if r.Level == slog.LevelWarn {
+		line = i18n.Default.Sprintf("Warning: %s", line)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.w, line)
+	return err
+}
+
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *textHandler) WithGroup(string) slog.Handler { return h }
+
+// setupLogging routes the warnings and status messages of the run through
+// the logger selected by the flags
+func setupLogging(w io.Writer, opts *cliOptions) error {
+	logger, err := newLogger(w, opts.logFormat, opts.verbosity)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	mapper.SetLogger(logger)
+	return nil
+}
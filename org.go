@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// orgSummaryName is the file of the cross-repo summary in the output directory
+const orgSummaryName = "org_summary.md"
+
+// runOrgCommand maps every repository given as an argument into its own
+// output and writes a summary across them
+func runOrgCommand(args []string) error {
+	opts := &cliOptions{Options: mapper.Options{TreePolicy: mapper.DefaultTreePolicy()}}
+	fs := newFlagSet("org", opts)
+	addOutputFlags(fs, opts)
+	addContentFlags(fs, opts)
+	outputDir := fs.String("output-dir", "org_structure", "`directory` receiving one snapshot per repository and "+orgSummaryName)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: directory-mapper org [flags] <repository>...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("org needs at least one repository")
+	}
+	if opts.output != "" {
+		return errors.New("org writes one snapshot per repository into --output-dir, not to --output")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	opts.ctx = ctx
+
+	dir := mapper.ExpandEnv(*outputDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	cache := mapper.NewSharedCache()
+	summary := mapper.NewOrgSummary()
+	names := make(map[string]int)
+	repos := 0
+	failed, worst := 0, 0
+	for _, root := range fs.Args() {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			// Globs such as ./repos/* also match plain files
+			continue
+		}
+		if opts.ctx.Err() != nil {
+			return errInterrupted
+		}
+		repos++
+		name := repoName(root, names)
+		job := BatchJob{root: root, output: filepath.Join(dir, name+opts.format.Extension()), mode: mapper.Ignore}
+		tree, status, err := runBatchJob(job, opts, cache)
+		if err != nil {
+			slog.Error(strings.TrimSuffix(i18n.Default.Sprintf("Error mapping %s: %v\n", root, err), "\n"), "root", root, "err", err)
+			failed++
+			continue
+		}
+		summary.Add(name, tree)
+		i18n.Default.Fprintf(os.Stdout, "%s written to %s\n", root, job.output)
+		worst = max(worst, status)
+	}
+	if repos == 0 {
+		return errors.New("none of the arguments is a directory")
+	}
+
+	summaryPath := filepath.Join(dir, orgSummaryName)
+	file, err := os.Create(summaryPath)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", summaryPath, err)
+	}
+	if err := summary.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing %s: %v", summaryPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %v", summaryPath, err)
+	}
+	i18n.Default.Fprintf(os.Stdout, "Summary of %d repositories written to %s\n", repos-failed, summaryPath)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed", failed, repos)
+	}
+	return statusError(worst)
+}
+
+// repoName names the output of the repository at root after its directory,
+// numbering repeated names
+func repoName(root string, seen map[string]int) string {
+	name := filepath.Base(root)
+	if abs, err := filepath.Abs(root); err == nil {
+		name = filepath.Base(abs)
+	}
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		return fmt.Sprintf("%s-%d", name, n)
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Annotation is a finding an external tool attaches to a path, such as a
+// lint warning or a security scan result
+type Annotation struct {
+	Path     string `json:"path"`               // Slash-separated path below the root
+	Source   string `json:"source,omitempty"`   // Tool that produced it, e.g. "golangci-lint"
+	Severity string `json:"severity,omitempty"` // e.g. "error", "warning"
+	Line     int    `json:"line,omitempty"`     // Line in the file, 0 for the whole entry
+	Message  string `json:"message"`
+}
+
+// annotationFile is the layout of an annotations sidecar:
+//
+//	{"annotations": [{"path": "src/app.go", "source": "golangci-lint",
+//	  "severity": "warning", "line": 12, "message": "unused variable x"}]}
+type annotationFile struct {
+	Annotations []Annotation `json:"annotations"`
+}
+
+// LoadAnnotations reads the annotations of a sidecar JSON file
+func LoadAnnotations(name string) ([]Annotation, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading annotations: %v", err)
+	}
+	var file annotationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing annotations %s: %v", name, err)
+	}
+	for i, a := range file.Annotations {
+		if a.Path == "" || a.Message == "" {
+			return nil, fmt.Errorf("annotations %s: entry %d needs a path and a message", name, i+1)
+		}
+	}
+	return file.Annotations, nil
+}
+
+// label renders the annotation as a tree note, e.g.
+// "[warning] golangci-lint: unused variable x (line 12)"
+func (a Annotation) label(msg i18n.Printer) string {
+	var b strings.Builder
+	if a.Severity != "" {
+		b.WriteString("[" + a.Severity + "] ")
+	}
+	if a.Source != "" {
+		b.WriteString(a.Source + ": ")
+	}
+	b.WriteString(a.Message)
+	if a.Line > 0 {
+		b.WriteString(" (" + msg.Sprintf("line %d", a.Line) + ")")
+	}
+	return b.String()
+}
+
+// annotate attaches annotations to the nodes of their paths as notes and
+// returns them by path for the structured formats. Annotations of paths
+// missing from the tree are counted in a warning.
+func annotate(tree *TreeNode, annotations []Annotation, msg i18n.Printer) map[string][]Annotation {
+	nodes := nodesByPath(tree)
+	byPath := groupByPath(nodes, annotations)
+	for name, list := range byPath {
+		for _, a := range list {
+			nodes[name].addNote(a.label(msg))
+		}
+	}
+	return byPath
+}
+
+// groupByPath groups annotations by their cleaned path, warning about those
+// whose path is not among nodes
+func groupByPath(nodes map[string]*TreeNode, annotations []Annotation) map[string][]Annotation {
+	byPath := make(map[string][]Annotation)
+	missing := 0
+	for _, a := range annotations {
+		name := path.Clean(strings.TrimPrefix(a.Path, "./"))
+		if _, ok := nodes[name]; !ok {
+			missing++
+			continue
+		}
+		a.Path = name
+		byPath[name] = append(byPath[name], a)
+	}
+	if missing > 0 {
+		i18n.Warnf(plural(missing, "%d annotation refers to a path not in the snapshot", "%d annotations refer to paths not in the snapshot"), missing)
+	}
+	return byPath
+}
+
+// nodesByPath indexes the nodes of tree by their path below the root
+func nodesByPath(tree *TreeNode) map[string]*TreeNode {
+	nodes := make(map[string]*TreeNode)
+	var visit func(node *TreeNode, name string)
+	visit = func(node *TreeNode, name string) {
+		nodes[name] = node
+		for _, child := range node.children {
+			visit(child, path.Join(name, child.name))
+		}
+	}
+	visit(tree, ".")
+	return nodes
+}
@@ -0,0 +1,174 @@
+package mapper_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// The signatures of the v1 API. A change breaking a caller fails to compile.
+var (
+	_ func(string, *mapper.Options) (*mapper.Tree, error)                         = mapper.Scan
+	_ func(context.Context, string, *mapper.Options) (*mapper.Tree, error)        = mapper.ScanContext
+	_ func(fs.FS, string, *mapper.Options) (*mapper.Tree, error)                  = mapper.ScanFS
+	_ func(context.Context, fs.FS, string, *mapper.Options) (*mapper.Tree, error) = mapper.ScanFSContext
+	_ func(string, *mapper.Options, func(mapper.Event) error) error               = mapper.WalkEvents
+	_ func(string, string, *mapper.Options, io.Writer) error                      = mapper.Explain
+	_ func(string, bool) (*mapper.PatternList, error)                             = mapper.LoadPatterns
+	_ func(string, bool) (*mapper.PatternList, *mapper.PatternList, error)        = mapper.LoadPatternFiles
+	_ func(string, string, mapper.PatternType) (*mapper.PatternList, error)       = mapper.NewPatternList
+	_ func(string, mapper.PatternType) *mapper.PatternList                        = mapper.NewPatterns
+	_ func(string, string) (*mapper.Checkpoint, error)                            = mapper.LoadCheckpoint
+	_ func(string) (*mapper.ContentCache, error)                                  = mapper.LoadContentCache
+	_ func(string) (*mapper.Index, error)                                         = mapper.LoadIndex
+	_ func(io.ReaderAt, int64) (mapper.SnapshotFooter, error)                     = mapper.VerifySnapshot
+	_ func(a, b *mapper.Tree) *mapper.Comparison                                  = mapper.Compare
+	_ func(template, project *mapper.Tree) *mapper.Conformance                    = mapper.Conform
+	_ func() mapper.TreePolicy                                                    = mapper.DefaultTreePolicy
+	_ func() *mapper.SkipLists                                                    = mapper.DefaultSkipLists
+	_ func(...mapper.Option) *mapper.Options                                      = mapper.NewOptions
+	_ func(*mapper.Tree, io.Writer, mapper.Format) error                          = (*mapper.Tree).Render
+	_ func(*mapper.Tree, context.Context, io.Writer, mapper.Format) error         = (*mapper.Tree).RenderContext
+	_ func(*mapper.Tree, io.Writer)                                               = (*mapper.Tree).WriteSummary
+	_ func(*mapper.Tree, mapper.Format) int64                                     = (*mapper.Tree).EstimateSize
+	_ func(*mapper.Tree) []mapper.FileTokens                                      = (*mapper.Tree).TokenCounts
+	_ func(*mapper.Tree, int64, int64) []*mapper.Part                             = (*mapper.Tree).SplitParts
+	_ func(*mapper.Tree, io.Writer, []*mapper.Part, int) error                    = (*mapper.Tree).RenderPart
+	_ mapper.Tokenizer                                                            = (*mapper.BPE)(nil)
+	_ mapper.Tokenizer                                                            = (*mapper.ExecTokenizer)(nil)
+)
+
+func TestVersionIsSemantic(t *testing.T) {
+	if !regexp.MustCompile(`^1\.\d+\.\d+$`).MatchString(mapper.Version) {
+		t.Errorf("Version = %q, want a 1.x.y semantic version", mapper.Version)
+	}
+}
+
+// The values of the formats are part of the API, so new ones go last
+func TestFormatValues(t *testing.T) {
+	formats := []mapper.Format{
+		mapper.FormatText, mapper.FormatJSON, mapper.FormatMarkdown, mapper.FormatHTML,
+		mapper.FormatYAML, mapper.FormatMermaid, mapper.FormatDOT, mapper.FormatProse,
+		mapper.FormatXML, mapper.FormatProtobuf, mapper.FormatParquet,
+	}
+	for want, format := range formats {
+		if int(format) != want {
+			t.Errorf("format %s = %d, want %d", format.String(), format, want)
+		}
+	}
+}
+
+var apiFS = fstest.MapFS{
+	"main.go":        {Data: []byte("package main\n\nfunc main() {}\n")},
+	"docs/README.md": {Data: []byte("# Docs\n")},
+	"logo.png":       {Data: []byte("\x89PNG\r\n\x1a\n")},
+}
+
+const apiText = `<Project_Structure>
+[api]
+    ├── [docs]
+    │   └── README.md
+    ├── logo.png [omitted]
+    └── main.go
+</Project_Structure>
+<README.md>
+# Docs
+
+
+</README.md>
+<main.go>
+package main
+
+func main() {}
+
+
+</main.go>
+<Binary_Inventory>
+logo.png | image/png | 8 bytes | sha256:4c4b6a3be1314ab86138bef4314dde022e600960d8689a2c8f8631802d20dab6
+</Binary_Inventory>
+`
+
+func render(t *testing.T, opts *mapper.Options) string {
+	t.Helper()
+	tree, err := mapper.ScanFS(apiFS, "api", opts)
+	if err != nil {
+		t.Fatalf("ScanFS: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tree.Render(&out, mapper.FormatText); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return out.String()
+}
+
+// Options added after v1.0.0 keep the earlier output at their zero value,
+// however the options are built
+func TestDefaultOutput(t *testing.T) {
+	for name, opts := range map[string]*mapper.Options{
+		"literal":    {TreePolicy: mapper.DefaultTreePolicy()},
+		"NewOptions": mapper.NewOptions(),
+	} {
+		if got := render(t, opts); got != apiText {
+			t.Errorf("%s options: got\n%s\nwant\n%s", name, got, apiText)
+		}
+	}
+	if got, want := render(t, nil), render(t, &mapper.Options{}); got != want {
+		t.Errorf("nil options: got\n%s\nwant the zero Options'\n%s", got, want)
+	}
+}
+
+// Each option sets the fields it documents and leaves the rest alone
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		option mapper.Option
+		check  func(*mapper.Options) bool
+	}{
+		{"WithGitignore", mapper.WithGitignore(), func(o *mapper.Options) bool { return o.RespectGitignore }},
+		{"WithNestedPatterns", mapper.WithNestedPatterns(), func(o *mapper.Options) bool { return o.NestedPatterns }},
+		{"WithIgnoreCase", mapper.WithIgnoreCase(), func(o *mapper.Options) bool { return o.IgnoreCase }},
+		{"WithMaxFileSize", mapper.WithMaxFileSize(10), func(o *mapper.Options) bool { return o.MaxFileSize == 10 }},
+		{"WithHeadTail", mapper.WithHeadTail(3, 2), func(o *mapper.Options) bool { return o.HeadLines == 3 && o.TailLines == 2 }},
+		{"WithOutline", mapper.WithOutline(100), func(o *mapper.Options) bool { return o.OutlineOver == 100 }},
+		{"WithWorkers", mapper.WithWorkers(4), func(o *mapper.Options) bool { return o.Workers == 4 }},
+		{"WithJobs", mapper.WithJobs(4), func(o *mapper.Options) bool { return o.Jobs == 4 }},
+		{"WithQuery", mapper.WithQuery("retry", 5), func(o *mapper.Options) bool { return o.Query == "retry" && o.QueryTop == 5 }},
+		{"WithChurn", mapper.WithChurn(30), func(o *mapper.Options) bool { return o.ChurnDays == 30 }},
+		{"WithSymlinksShown", mapper.WithSymlinksShown(true), func(o *mapper.Options) bool { return o.ShowSymlinks && o.SymlinkContent }},
+		{"WithEntryLimits", mapper.WithEntryLimits(10, 5), func(o *mapper.Options) bool { return o.MaxFiles == 10 && o.MaxEntriesPerDir == 5 }},
+		{"WithHidden", mapper.WithHidden(mapper.HiddenShown), func(o *mapper.Options) bool { return o.Hidden == mapper.HiddenShown }},
+		{"WithDependencies", mapper.WithDependencies(), func(o *mapper.Options) bool { return o.Dependencies }},
+		{"WithDeployment", mapper.WithDeployment(true), func(o *mapper.Options) bool { return o.Deployment && o.InfraSummaryOnly }},
+		{"WithInterfaces", mapper.WithInterfaces(), func(o *mapper.Options) bool { return o.Interfaces }},
+		{"WithConsolidatedMigrations", mapper.WithConsolidatedMigrations(), func(o *mapper.Options) bool { return o.ConsolidateMigrations }},
+		{"WithTestPairs", mapper.WithTestPairs(), func(o *mapper.Options) bool { return o.PairTests }},
+		{"WithReadmes", mapper.WithReadmes(), func(o *mapper.Options) bool { return o.HoistReadmes }},
+		{"WithFileIndex", mapper.WithFileIndex(), func(o *mapper.Options) bool { return o.FileIndex }},
+		{"WithDelimited", mapper.WithDelimited(), func(o *mapper.Options) bool { return o.Delimited }},
+		{"WithFooter", mapper.WithFooter(), func(o *mapper.Options) bool { return o.Footer }},
+		{"WithShowExcluded", mapper.WithShowExcluded(), func(o *mapper.Options) bool { return o.ShowExcluded }},
+		{"WithSummaryHeader", mapper.WithSummaryHeader(), func(o *mapper.Options) bool { return o.SummaryHeader }},
+		{"WithStructureOnly", mapper.WithStructureOnly(), func(o *mapper.Options) bool { return o.StructureOnly }},
+		{"WithDiagramDepth", mapper.WithDiagramDepth(2), func(o *mapper.Options) bool { return o.DiagramDepth == 2 }},
+		{"WithLanguage", mapper.WithLanguage("es"), func(o *mapper.Options) bool { return o.Language == "es" }},
+		{"WithMaxTokens", mapper.WithMaxTokens(1000), func(o *mapper.Options) bool { return o.MaxTokens == 1000 }},
+		{"WithExcludePath", mapper.WithExcludePath("out.txt"), func(o *mapper.Options) bool { return o.ExcludePath == "out.txt" }},
+		{"WithVisibility", mapper.WithVisibility(mapper.SkipBinaryExtension, mapper.Hidden), func(o *mapper.Options) bool {
+			return o.TreePolicy[mapper.SkipBinaryExtension] == mapper.Hidden
+		}},
+	}
+	for _, test := range tests {
+		if opts := mapper.NewOptions(test.option); !test.check(opts) {
+			t.Errorf("%s did not set its fields: %+v", test.name, opts)
+		}
+	}
+	if opts := mapper.NewOptions(); opts.TreePolicy == nil {
+		t.Error("NewOptions has no tree policy")
+	}
+}
@@ -0,0 +1,220 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// budgetNoteTokens is roughly what the note of a dropped or cut file in the
+// tree, or the marker replacing cut lines, costs
+const budgetNoteTokens = 12
+
+// fixtureDirs name directories holding test data rather than code
+var fixtureDirs = map[string]bool{
+	"testdata":      true,
+	"fixtures":      true,
+	"fixture":       true,
+	"__fixtures__":  true,
+	"__snapshots__": true,
+	"__mocks__":     true,
+	"mocks":         true,
+}
+
+// testDirs name directories holding tests
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"__tests__": true,
+	"spec":      true,
+}
+
+// budgetClass orders files by what is given up first to fit a token budget
+type budgetClass int
+
+const (
+	budgetFixture budgetClass = iota
+	budgetTest
+	budgetSource
+)
+
+// classifyBudget tells fixtures and tests apart from the files kept longest
+func classifyBudget(name string) budgetClass {
+	dirs := strings.Split(path.Dir(name), "/")
+	for _, dir := range dirs {
+		if fixtureDirs[strings.ToLower(dir)] {
+			return budgetFixture
+		}
+	}
+	for _, dir := range dirs {
+		if testDirs[strings.ToLower(dir)] {
+			return budgetTest
+		}
+	}
+	if info, ok := classifyTestFile(path.Base(name)); ok && info.isTest {
+		return budgetTest
+	}
+	return budgetSource
+}
+
+// budgetFile is a file competing for the token budget
+type budgetFile struct {
+	node    *TreeNode
+	name    string
+	tokens  int64
+	frame   int64 // Tokens of the tags around its content
+	class   budgetClass
+	dropped bool
+}
+
+// fitTokenBudget drops and cuts files until the tree and the contents fit
+// in opts.MaxTokens. Fixtures are dropped first, then tests, largest first.
+// If that is not enough, the files over a common length are cut to it,
+// keeping their first lines, and files that would keep none are dropped.
+func (t *Tree) fitTokenBudget() {
+	budget := t.opts.MaxTokens
+	files := make([]*budgetFile, 0)
+	nodes := make(map[string]*TreeNode)
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		nodes[name] = node
+	})
+	var total int64
+	for _, count := range t.TokenCounts() {
+		f := &budgetFile{node: nodes[count.Path], name: count.Path, tokens: count.Tokens, class: classifyBudget(count.Path)}
+		f.frame = EstimateTokens(int64(2*len(f.node.name) + 8))
+		files = append(files, f)
+		total += f.tokens + f.frame
+	}
+	overhead := EstimateTokens(t.estimateNode(t.root, ".", 0, false))
+	total += overhead
+	if total <= budget {
+		return
+	}
+	if overhead > budget {
+		i18n.Warnf("The tree alone takes about %d tokens, over the budget of %d; no content fits", overhead, budget)
+	}
+
+	kept := make([]*budgetFile, 0, len(files))
+	for _, class := range []budgetClass{budgetFixture, budgetTest} {
+		for _, f := range files {
+			if f.class == class && total > budget {
+				t.dropForBudget(f)
+				total -= f.tokens + f.frame - budgetNoteTokens
+			}
+		}
+	}
+	for _, f := range files {
+		if !f.node.omitted {
+			kept = append(kept, f)
+		}
+	}
+
+	if total > budget {
+		// Files are sorted by tokens, most first. Starting from the smallest,
+		// find the length the files over it are cut to for everything to fit.
+		available := budget - overhead
+		for _, f := range kept {
+			available -= f.frame + budgetNoteTokens
+		}
+		var below, limit int64
+		for i := len(kept) - 1; i >= 0; i-- {
+			limit = (available - below) / int64(i+1)
+			if limit < kept[i].tokens {
+				break
+			}
+			below += kept[i].tokens
+		}
+		for _, f := range kept {
+			if f.tokens > limit {
+				t.cutForBudget(f, limit)
+			}
+		}
+	}
+
+	// Keep the counts in line with what is written
+	counts := make([]FileTokens, 0, len(kept))
+	for _, f := range kept {
+		if !f.node.omitted {
+			counts = append(counts, FileTokens{Path: f.name, Tokens: f.tokens})
+		}
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].Tokens > counts[j].Tokens })
+	t.tokens = counts
+
+	if t.opts.TreePolicy.visibility(SkipTokenBudget) == Hidden {
+		hidden := make(map[*TreeNode]bool)
+		for _, f := range files {
+			hidden[f.node] = f.dropped
+		}
+		pruneNodes(t.root, hidden)
+	}
+}
+
+// dropForBudget leaves the content of a file out for the token budget
+func (t *Tree) dropForBudget(f *budgetFile) {
+	f.node.omitted, f.dropped = true, true
+	f.node.addNote(t.msg.Sprintf("dropped for the token budget"))
+	d := SkipDecision{reason: SkipTokenBudget, rule: fmt.Sprintf("> %d tokens", t.opts.MaxTokens)}
+	if info, err := fs.Stat(t.fsys, f.name); err == nil {
+		d.size = info.Size()
+	}
+	t.report.recordOmission(f.name, d)
+	t.report.countSkip(SkipTokenBudget)
+}
+
+// cutForBudget keeps the first lines of a file worth about limit tokens,
+// ending before a top-level declaration when that keeps at least half of
+// them, and drops the file when not a single line fits. A file already cut
+// to its first and last lines gives up the last ones.
+func (t *Tree) cutForBudget(f *budgetFile, limit int64) {
+	data, release, err := readContent(t.fsys, f.name)
+	if err != nil {
+		t.dropForBudget(f)
+		return
+	}
+	defer release()
+	lines, _ := countLines(bytes.NewReader(data))
+	kept := lines
+	if f.node.truncated() {
+		kept, _ = countLines(bytes.NewReader(truncateContent(data, f.node.keepLines, f.node.keepTail)))
+	}
+	keep := 0
+	if f.tokens > 0 && limit > budgetNoteTokens {
+		keep = int(int64(kept) * (limit - budgetNoteTokens) / f.tokens)
+	}
+	if f.node.truncated() {
+		keep = min(keep, f.node.keepLines)
+	}
+	if keep == 0 {
+		t.dropForBudget(f)
+		return
+	}
+	starts := declarationStarts(f.name, data)
+	if i := sort.SearchInts(starts, keep+2); i > 0 && starts[i-1]-1 >= (keep+1)/2 {
+		keep = starts[i-1] - 1
+	}
+	f.node.keepLines, f.node.keepTail = keep, 0
+	f.node.addNote(t.msg.Sprintf("cut to %d of %d lines for the token budget", keep, lines))
+	t.report.omissions = append(t.report.omissions, Omission{path: f.name, reason: SkipTokenBudget, rule: fmt.Sprintf("> %d tokens", t.opts.MaxTokens), size: int64(len(data)), kept: keep, lines: lines})
+	f.tokens = t.opts.countTokens(truncateContent(data, keep, 0))
+}
+
+// pruneNodes removes the nodes in hidden from the tree below node
+func pruneNodes(node *TreeNode, hidden map[*TreeNode]bool) {
+	children := node.children[:0]
+	for _, child := range node.children {
+		if hidden[child] {
+			continue
+		}
+		if child.isDir {
+			pruneNodes(child, hidden)
+		}
+		children = append(children, child)
+	}
+	node.children = children
+}
@@ -0,0 +1,71 @@
+package mapper
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// SharedCache holds data reused across several scans, such as the jobs of a
+// batch or the requests of a server. It is safe for concurrent use.
+type SharedCache struct {
+	mu       sync.Mutex
+	profiles map[string]*PatternList
+	hashes   map[string]string
+}
+
+// NewSharedCache creates an empty cache
+func NewSharedCache() *SharedCache {
+	return &SharedCache{
+		profiles: make(map[string]*PatternList),
+		hashes:   make(map[string]string),
+	}
+}
+
+// PatternsFor returns the parsed pattern file for root, loading it only once
+func (c *SharedCache) PatternsFor(profile, root string, mode PatternType) (*PatternList, error) {
+	key := fmt.Sprintf("%d:%s", mode, profile)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.profiles[key]
+	if !ok {
+		var err error
+		cached, err = NewPatternList(profile, root, mode)
+		if err != nil {
+			return nil, err
+		}
+		c.profiles[key] = cached
+	}
+
+	// Patterns are shared, only the base path differs between roots
+	pl := *cached
+	pl.basePath = root
+	return &pl, nil
+}
+
+// fileHash returns the hash of a file, reusing earlier results for unchanged
+// files. The origin names the scanned filesystem so equal paths in different
+// roots are kept apart.
+func (c *SharedCache) fileHash(fsys fs.FS, origin, name string, info fs.FileInfo) (string, error) {
+	if c == nil {
+		return hashFile(fsys, name)
+	}
+
+	key := fmt.Sprintf("%s|%s|%d|%d", origin, name, info.Size(), info.ModTime().UnixNano())
+	c.mu.Lock()
+	hash, ok := c.hashes[key]
+	c.mu.Unlock()
+	if ok {
+		return hash, nil
+	}
+
+	// Hashing happens unlocked; concurrent scans may hash a file twice
+	hash, err := hashFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.hashes[key] = hash
+	c.mu.Unlock()
+	return hash, nil
+}
@@ -0,0 +1,355 @@
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// checkpointInterval is the minimum time between two saves of a checkpoint
+const checkpointInterval = 5 * time.Second
+
+// Checkpoint persists scan and render progress to a file so an interrupted
+// run can resume instead of starting over. Directories are recorded once
+// their whole subtree has been walked, and the text format records how much
+// of the output was completely written.
+type Checkpoint struct {
+	path     string
+	key      string
+	resumed  map[string]*subtreeRecord // Subtrees walked by an earlier run
+	done     map[string]*subtreeRecord // Topmost subtrees completed in this run
+	offset   int64                     // Output bytes completely written
+	files    int                       // Content sections completely written
+	digest   []byte                    // State of the output checksum at offset, for the footer
+	lastSave time.Time
+}
+
+// checkpointFile is the JSON form of a checkpoint
+type checkpointFile struct {
+	Key      string                    `json:"key"`
+	Subtrees map[string]*subtreeRecord `json:"subtrees"`
+	Offset   int64                     `json:"offset"`
+	Files    int                       `json:"files"`
+	Digest   []byte                    `json:"digest,omitempty"`
+}
+
+// subtreeRecord is a walked directory with what the scan report gathered below it
+type subtreeRecord struct {
+	Tree        *nodeRecord      `json:"tree"`
+	Assets      []assetRecord    `json:"assets,omitempty"`
+	Omissions   []omissionRecord `json:"omissions,omitempty"`
+	BuiltinDirs []string         `json:"builtinDirs,omitempty"`
+	Unreadable  int              `json:"unreadable,omitempty"`
+	RuleHits    map[string]int   `json:"ruleHits,omitempty"`
+	Skips       map[string]int   `json:"skips,omitempty"`       // By skip reason
+	PatternHits map[int]int      `json:"patternHits,omitempty"` // By index in the pattern list
+
+	node *TreeNode // Set while the record belongs to the current run
+}
+
+type nodeRecord struct {
+	Name     string        `json:"n"`
+	IsDir    bool          `json:"d,omitempty"`
+	Omitted  bool          `json:"o,omitempty"`
+	Excluded bool          `json:"x,omitempty"`
+	Link     string        `json:"l,omitempty"`
+	More     int           `json:"m,omitempty"`
+	Head     int           `json:"h,omitempty"`
+	Tail     int           `json:"t,omitempty"`
+	Children []*nodeRecord `json:"c,omitempty"`
+}
+
+type omissionRecord struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Rule   string `json:"rule"`
+	Size   int64  `json:"size"`
+}
+
+type assetRecord struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// LoadCheckpoint opens the checkpoint at path for the run identified by key.
+// Progress saved by an earlier run with the same key is resumed; a missing
+// file or one written for another run starts from scratch.
+func LoadCheckpoint(path, key string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:     path,
+		key:      key,
+		resumed:  make(map[string]*subtreeRecord),
+		done:     make(map[string]*subtreeRecord),
+		lastSave: time.Now(),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint %s: %v", path, err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		i18n.Warnf("Ignoring unreadable checkpoint %s: %v", path, err)
+		return c, nil
+	}
+	if file.Key != key {
+		i18n.Warnf("Ignoring checkpoint %s written for a different run", path)
+		return c, nil
+	}
+	if file.Subtrees != nil {
+		c.resumed = file.Subtrees
+	}
+	c.offset, c.files, c.digest = file.Offset, file.Files, file.Digest
+	return c, nil
+}
+
+// Offset returns how many bytes of the output an earlier run completely wrote.
+// The output must be truncated to this size before rendering resumes.
+func (c *Checkpoint) Offset() int64 {
+	return c.offset
+}
+
+// Restart discards the render progress, e.g. when the output no longer matches it
+func (c *Checkpoint) Restart() {
+	c.offset, c.files, c.digest = 0, 0, nil
+}
+
+// Save writes the checkpoint now, e.g. when the run is interrupted. Render
+// progress is only valid once everything written before has reached the output.
+func (c *Checkpoint) Save() {
+	c.save(true)
+}
+
+// Remove deletes the checkpoint file after a successful run
+func (c *Checkpoint) Remove() error {
+	err := os.Remove(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// save writes the checkpoint atomically, at most once per interval unless forced
+func (c *Checkpoint) save(force bool) {
+	if !force && time.Since(c.lastSave) < checkpointInterval {
+		return
+	}
+	c.lastSave = time.Now()
+
+	file := checkpointFile{Key: c.key, Subtrees: make(map[string]*subtreeRecord), Offset: c.offset, Files: c.files, Digest: c.digest}
+	for name, record := range c.resumed {
+		file.Subtrees[name] = record
+	}
+	for name, record := range c.done {
+		if record.Tree == nil {
+			record.Tree = nodeToRecord(record.node)
+		}
+		file.Subtrees[name] = record
+	}
+
+	data, err := json.Marshal(file)
+	if err == nil {
+		tmp := c.path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, c.path)
+		}
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		i18n.Warnf("Could not save checkpoint %s: %v", c.path, err)
+	}
+}
+
+// restore returns the subtree an earlier run walked at name, applying what it
+// contributed to the report, or nil when the directory must be walked
+func (c *Checkpoint) restore(name string, report *ScanReport, patterns *PatternList) *TreeNode {
+	if c == nil {
+		return nil
+	}
+	record, ok := c.resumed[name]
+	if !ok || record.Tree == nil {
+		return nil
+	}
+	delete(c.resumed, name)
+
+	for _, a := range record.Assets {
+		report.assets = append(report.assets, BinaryAsset{path: a.Path, fileType: a.Type, size: a.Size, hash: a.Hash})
+	}
+	for _, o := range record.Omissions {
+		reason, _ := parseSkipReason(o.Reason)
+		report.omissions = append(report.omissions, Omission{path: o.Path, reason: reason, rule: o.Rule, size: o.Size})
+	}
+	report.builtinDirs = append(report.builtinDirs, record.BuiltinDirs...)
+	report.unreadable += record.Unreadable
+	for rule, n := range record.RuleHits {
+		if report.ruleHits == nil {
+			report.ruleHits = make(map[string]int)
+		}
+		report.ruleHits[rule] += n
+	}
+	for reason, n := range record.Skips {
+		if r, err := parseSkipReason(reason); err == nil {
+			if report.skips == nil {
+				report.skips = make(map[SkipReason]int)
+			}
+			report.skips[r] += n
+		}
+	}
+	for i, n := range record.PatternHits {
+		if patterns == nil || i < 0 || i >= len(patterns.patterns) {
+			continue
+		}
+		if report.patternHits == nil {
+			report.patternHits = make(map[*Pattern]int)
+		}
+		report.patternHits[&patterns.patterns[i]] += n
+	}
+
+	record.node = recordToNode(record.Tree, report)
+	c.done[name] = record
+	return record.node
+}
+
+// reportMark is the state of a scan report when a directory walk starts
+type reportMark struct {
+	assets      int
+	omissions   int
+	builtinDirs int
+	unreadable  int
+	ruleHits    map[string]int
+	skips       map[SkipReason]int
+	patternHits map[*Pattern]int
+}
+
+// mark remembers the report state so a subtree's contribution can be computed
+func (c *Checkpoint) mark(report *ScanReport) reportMark {
+	m := reportMark{
+		assets:      len(report.assets),
+		omissions:   len(report.omissions),
+		builtinDirs: len(report.builtinDirs),
+		unreadable:  report.unreadable,
+		ruleHits:    make(map[string]int, len(report.ruleHits)),
+		skips:       make(map[SkipReason]int, len(report.skips)),
+		patternHits: make(map[*Pattern]int, len(report.patternHits)),
+	}
+	for rule, n := range report.ruleHits {
+		m.ruleHits[rule] = n
+	}
+	for reason, n := range report.skips {
+		m.skips[reason] = n
+	}
+	for p, n := range report.patternHits {
+		m.patternHits[p] = n
+	}
+	return m
+}
+
+// completed records that the directory at name has been walked entirely.
+// Its children's records are dropped since the new record contains them.
+func (c *Checkpoint) completed(name string, node *TreeNode, since reportMark, report *ScanReport, patterns *PatternList) {
+	record := &subtreeRecord{node: node, Unreadable: report.unreadable - since.unreadable}
+	for _, a := range report.assets[since.assets:] {
+		record.Assets = append(record.Assets, assetRecord{Path: a.path, Type: a.fileType, Size: a.size, Hash: a.hash})
+	}
+	record.BuiltinDirs = append(record.BuiltinDirs, report.builtinDirs[since.builtinDirs:]...)
+	for _, o := range report.omissions[since.omissions:] {
+		record.Omissions = append(record.Omissions, omissionRecord{Path: o.path, Reason: o.reason.String(), Rule: o.rule, Size: o.size})
+	}
+	for rule, n := range report.ruleHits {
+		if d := n - since.ruleHits[rule]; d > 0 {
+			if record.RuleHits == nil {
+				record.RuleHits = make(map[string]int)
+			}
+			record.RuleHits[rule] = d
+		}
+	}
+	for reason, n := range report.skips {
+		if d := n - since.skips[reason]; d > 0 {
+			if record.Skips == nil {
+				record.Skips = make(map[string]int)
+			}
+			record.Skips[reason.String()] = d
+		}
+	}
+	if patterns != nil {
+		for i := range patterns.patterns {
+			p := &patterns.patterns[i]
+			if d := report.patternHits[p] - since.patternHits[p]; d > 0 {
+				if record.PatternHits == nil {
+					record.PatternHits = make(map[int]int)
+				}
+				record.PatternHits[i] = d
+			}
+		}
+	}
+
+	for _, child := range node.children {
+		if child.isDir {
+			delete(c.done, path.Join(name, child.name))
+		}
+	}
+	c.done[name] = record
+	c.save(name == ".")
+}
+
+// saveDue reports whether the next progress update is saved
+func (c *Checkpoint) saveDue() bool {
+	return time.Since(c.lastSave) >= checkpointInterval
+}
+
+// wroteSection records that a content section was completely written
+func (c *Checkpoint) wroteSection(offset int64, digest []byte) {
+	c.offset, c.digest = offset, digest
+	c.files++
+	c.save(false)
+}
+
+// nodeToRecord converts a walked subtree for saving
+func nodeToRecord(node *TreeNode) *nodeRecord {
+	r := &nodeRecord{Name: node.name, IsDir: node.isDir, Omitted: node.omitted, Excluded: node.excluded, Link: node.link, More: node.more, Head: node.keepLines, Tail: node.keepTail}
+	for _, child := range node.children {
+		r.Children = append(r.Children, nodeToRecord(child))
+	}
+	return r
+}
+
+// recordToNode rebuilds a saved subtree
+func recordToNode(r *nodeRecord, report *ScanReport) *TreeNode {
+	node := report.nodes.newNode()
+	node.name, node.isDir, node.omitted, node.excluded, node.link, node.more = r.Name, r.IsDir, r.Omitted, r.Excluded, r.Link, r.More
+	node.keepLines, node.keepTail = r.Head, r.Tail
+	if len(r.Children) > 0 {
+		node.children = make([]*TreeNode, 0, len(r.Children))
+		for _, child := range r.Children {
+			node.children = append(node.children, recordToNode(child, report))
+		}
+	}
+	return node
+}
+
+// countingWriter tracks how many bytes have been written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
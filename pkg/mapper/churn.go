@@ -0,0 +1,56 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// churnWeight is how much a file's commit count raises its relevance score
+const churnWeight = 0.25
+
+// collectChurn counts the commits touching each file below dir within the
+// last days, by path relative to dir
+func collectChurn(ctx context.Context, dir string, days int) (map[string]int, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "log", "--since="+strconv.Itoa(days)+" days ago", "--format=", "--name-only", "--relative", "--no-renames", "--", ".")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	churn := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			churn[name]++
+		}
+	}
+	return churn, scanner.Err()
+}
+
+// annotateChurn marks every file with the number of commits touching it
+func annotateChurn(tree *TreeNode, churn map[string]int, msg i18n.Printer) {
+	walkFiles(tree, func(node *TreeNode, name string) {
+		if n := churn[name]; n > 0 {
+			node.addNote(msg.Sprintf(plural(n, "%d commit", "%d commits"), n))
+		}
+	})
+}
+
+// churnBoost is the factor by which a relevance score grows with the
+// commits touching the file, growing slowly so relevance still dominates
+func churnBoost(commits int) float64 {
+	return 1 + churnWeight*math.Log1p(float64(commits))
+}
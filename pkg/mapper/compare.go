@@ -0,0 +1,247 @@
+package mapper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Comparison is the structural difference between two mapped projects,
+// such as two forks being consolidated
+type Comparison struct {
+	names     [2]string
+	onlyDirs  [2][]string // Topmost directories missing from the other tree
+	onlyFiles [2][]string // Files missing from the other tree within a shared directory
+	identical []identicalEntry
+	divergent []divergentDir
+	moved     [][2]string // The same content at different paths
+	shared    int         // Files present in both trees
+	differ    int         // Shared files whose contents differ
+	msg       i18n.Printer
+}
+
+// identicalEntry is a shared file with the same content in both trees, or a
+// directory holding only such files
+type identicalEntry struct {
+	path  string // Ends in / for a directory
+	files int    // Files below a directory
+}
+
+// divergentDir is a shared directory whose shared files do not all match
+type divergentDir struct {
+	path   string
+	shared int
+	files  []string // Shared files whose contents differ
+}
+
+// compareSide is what a comparison needs of one tree
+type compareSide struct {
+	files map[string]string // Content hash by path, "" when unreadable or not hashed
+	dirs  map[string]int    // Files below each directory, by path
+}
+
+// Compare compares the trees a and b, reading every mapped file of both
+// to find identical and differing contents
+func Compare(a, b *Tree) *Comparison {
+	c := &Comparison{names: [2]string{a.root.name, b.root.name}, msg: a.msg}
+	sides := [2]compareSide{newCompareSide(a, true), newCompareSide(b, true)}
+	for i, side := range sides {
+		c.onlyDirs[i], c.onlyFiles[i] = side.missingFrom(sides[1-i])
+	}
+
+	// Shared files, counted in every directory above them
+	same := make(map[string]int)
+	divergent := make(map[string]*divergentDir)
+	var names []string
+	for name, hash := range sides[0].files {
+		other, ok := sides[1].files[name]
+		if !ok || hash == "" || other == "" {
+			continue
+		}
+		c.shared++
+		names = append(names, name)
+		dir := path.Dir(name)
+		d := divergent[dir]
+		if d == nil {
+			d = &divergentDir{path: dir}
+			divergent[dir] = d
+		}
+		d.shared++
+		if hash != other {
+			c.differ++
+			d.files = append(d.files, name)
+			continue
+		}
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			same[dir]++
+		}
+	}
+	sort.Strings(names)
+
+	// Identical files are listed under their topmost directory holding the
+	// same files in both trees and nothing else
+	listed := make(map[string]bool)
+	for _, name := range names {
+		if sides[0].files[name] != sides[1].files[name] {
+			continue
+		}
+		entry := identicalEntry{path: name}
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if n := same[dir]; n == sides[0].dirs[dir] && n == sides[1].dirs[dir] {
+				entry = identicalEntry{path: dir + "/", files: n}
+			}
+		}
+		if !listed[entry.path] {
+			listed[entry.path] = true
+			c.identical = append(c.identical, entry)
+		}
+	}
+	for _, d := range divergent {
+		if len(d.files) > 0 {
+			sort.Strings(d.files)
+			c.divergent = append(c.divergent, *d)
+		}
+	}
+	sort.Slice(c.divergent, func(i, j int) bool { return c.divergent[i].path < c.divergent[j].path })
+
+	// Contents found at a path of one tree only and at another path of the
+	// other, as when files were moved or copied between the forks
+	byHash := make(map[string][]string)
+	for _, name := range unmatchedFiles(1, sides) {
+		byHash[sides[1].files[name]] = append(byHash[sides[1].files[name]], name)
+	}
+	for _, name := range unmatchedFiles(0, sides) {
+		for _, target := range byHash[sides[0].files[name]] {
+			c.moved = append(c.moved, [2]string{name, target})
+		}
+	}
+	return c
+}
+
+// emptyHash is the hash of an empty file, whose matches mean nothing
+var emptyHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// unmatchedFiles returns the readable, non-empty files of side i missing
+// from the other tree, wherever they are, in order
+func unmatchedFiles(i int, sides [2]compareSide) []string {
+	var names []string
+	for name, hash := range sides[i].files {
+		if _, ok := sides[1-i].files[name]; !ok && hash != "" && hash != emptyHash {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// missingFrom returns the topmost directories of side that other lacks, with
+// a trailing slash, and the files other lacks within the directories it has
+func (side compareSide) missingFrom(other compareSide) (dirs, files []string) {
+	for dir := range side.dirs {
+		if _, ok := other.dirs[dir]; !ok {
+			if _, ok := other.dirs[path.Dir(dir)]; ok {
+				dirs = append(dirs, dir+"/")
+			}
+		}
+	}
+	for name := range side.files {
+		if _, ok := other.files[name]; !ok {
+			if _, ok := other.dirs[path.Dir(name)]; ok {
+				files = append(files, name)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	return dirs, files
+}
+
+// newCompareSide counts the files of t per directory, hashing them when
+// contents are compared
+func newCompareSide(t *Tree, hash bool) compareSide {
+	side := compareSide{files: make(map[string]string), dirs: make(map[string]int)}
+	var walk func(node *TreeNode, name string)
+	walk = func(node *TreeNode, name string) {
+		if !node.isDir {
+			side.files[name] = ""
+			if hash {
+				sum, err := hashFile(t.fsys, name)
+				if err != nil {
+					i18n.Warnf("Could not read file %s: %v", name, err)
+				}
+				side.files[name] = sum
+			}
+			for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+				side.dirs[dir]++
+			}
+			return
+		}
+		if name != "." {
+			// Counted by the files below, which are walked after it
+			side.dirs[name] = 0
+		}
+		for _, child := range node.children {
+			walk(child, path.Join(name, child.name))
+		}
+	}
+	walk(t.root, ".")
+	side.dirs["."] = len(side.files)
+	return side
+}
+
+// Write writes the comparison as indented plain text, one section per kind
+// of difference, leaving out empty sections
+func (c *Comparison) Write(w io.Writer) error {
+	msg := c.msg
+	fmt.Fprintln(w, msg.Sprintf("Comparing %s and %s", c.names[0], c.names[1]))
+
+	list := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s\n", title)
+		for _, e := range entries {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+	for i := range c.names {
+		list(msg.Sprintf("Directories only in %s:", c.names[i]), c.onlyDirs[i])
+	}
+	for i := range c.names {
+		list(msg.Sprintf("Files only in %s:", c.names[i]), c.onlyFiles[i])
+	}
+
+	if len(c.divergent) > 0 {
+		fmt.Fprintf(w, "\n%s\n", msg.Sprintf("Divergent shared directories:"))
+		for _, d := range c.divergent {
+			fmt.Fprintf(w, "  %s/: %s\n", d.path, msg.Sprintf("%d of %d shared files differ", len(d.files), d.shared))
+			for _, name := range d.files {
+				fmt.Fprintf(w, "    %s\n", path.Base(name))
+			}
+		}
+	}
+
+	identical := make([]string, len(c.identical))
+	for i, entry := range c.identical {
+		identical[i] = entry.path
+		if strings.HasSuffix(entry.path, "/") {
+			identical[i] += " " + msg.Sprintf("(%d files)", entry.files)
+		}
+	}
+	list(msg.Sprintf("Identical content:"), identical)
+
+	moved := make([]string, len(c.moved))
+	for i, m := range c.moved {
+		moved[i] = m[0] + " -> " + m[1]
+	}
+	list(msg.Sprintf("Same content at different paths:"), moved)
+
+	_, err := fmt.Fprintf(w, "\n%s\n", msg.Sprintf("%d shared files: %d identical, %d differ", c.shared, c.shared-c.differ, c.differ))
+	return err
+}
@@ -0,0 +1,55 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Conformance is how the structure of a project departs from a template
+// or skeleton repository it should follow
+type Conformance struct {
+	Missing []string // Topmost paths of the template the project lacks, directories ending in /
+	Extra   []string // Topmost paths of the project the template lacks
+	names   [2]string
+	msg     i18n.Printer
+}
+
+// Conform checks the structure of project against template. Only paths are
+// compared, so no file is read.
+func Conform(template, project *Tree) *Conformance {
+	c := &Conformance{names: [2]string{template.root.name, project.root.name}, msg: project.msg}
+	want, have := newCompareSide(template, false), newCompareSide(project, false)
+	dirs, files := want.missingFrom(have)
+	c.Missing = append(dirs, files...)
+	dirs, files = have.missingFrom(want)
+	c.Extra = append(dirs, files...)
+	sort.Strings(c.Missing)
+	sort.Strings(c.Extra)
+	return c
+}
+
+// Write writes the missing and extra paths as indented plain text
+func (c *Conformance) Write(w io.Writer) error {
+	msg := c.msg
+	fmt.Fprintln(w, msg.Sprintf("Checking %s against the template %s", c.names[1], c.names[0]))
+	for _, section := range []struct {
+		title string
+		paths []string
+	}{
+		{msg.Sprintf("Missing:"), c.Missing},
+		{msg.Sprintf("Extra:"), c.Extra},
+	} {
+		if len(section.paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", section.title)
+		for _, p := range section.paths {
+			fmt.Fprintf(w, "  %s\n", p)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", msg.Sprintf("%d missing, %d extra", len(c.Missing), len(c.Extra)))
+	return err
+}
@@ -0,0 +1,197 @@
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// contentCacheVersion changes whenever the cache layout or what it records does
+const contentCacheVersion = 3
+
+// ContentCache remembers, across runs, what was learned from each file of a
+// root: whether it is binary, the hash of binary files and the outline of
+// long ones. Files unchanged in size and modification time are not read
+// again. It is safe for concurrent use.
+type ContentCache struct {
+	mu      sync.Mutex
+	files   map[string]*cachedFile
+	seen    map[string]bool // Files looked up this run, the ones saved
+	reused  int
+	history []RunTotals // Totals of earlier runs, oldest first
+}
+
+// cachedFile is what the cache knows of one version of a file
+type cachedFile struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Sniffed bool   `json:"sniffed,omitempty"` // Binary is known
+	Binary  bool   `json:"binary,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	Lines   int    `json:"lines,omitempty"`   // OutlineOver the outline was made for
+	Outline string `json:"outline,omitempty"` // Outline written instead of the content, "" when short
+	Lang    string `json:"lang,omitempty"`    // Language of the outline's label
+}
+
+// contentCacheFile is the JSON form of a cache
+type contentCacheFile struct {
+	Version int                    `json:"version"`
+	Files   map[string]*cachedFile `json:"files"`
+	History []RunTotals            `json:"history,omitempty"`
+}
+
+// NewContentCache returns an empty cache
+func NewContentCache() *ContentCache {
+	return &ContentCache{files: make(map[string]*cachedFile), seen: make(map[string]bool)}
+}
+
+// LoadContentCache reads the cache saved at path. A missing file, or one
+// written by an incompatible version, gives an empty cache.
+func LoadContentCache(path string) (*ContentCache, error) {
+	c := NewContentCache()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache %s: %v", path, err)
+	}
+	var file contentCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing cache %s: %v", path, err)
+	}
+	if file.Version == contentCacheVersion && file.Files != nil {
+		c.files = file.Files
+	}
+	c.history = file.History
+	return c, nil
+}
+
+// Save writes the files looked up since the cache was loaded to path,
+// replacing any earlier cache atomically, so deleted files drop out
+func (c *ContentCache) Save(path string) error {
+	c.mu.Lock()
+	file := contentCacheFile{Version: contentCacheVersion, Files: make(map[string]*cachedFile, len(c.seen)), History: c.history}
+	for name := range c.seen {
+		file.Files[name] = c.files[name]
+	}
+	data, err := json.Marshal(file)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error encoding cache: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+	return nil
+}
+
+// Reused returns how many times a file was not read thanks to the cache
+func (c *ContentCache) Reused() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reused
+}
+
+// entry returns the record of the version of name described by info,
+// replacing the record of an older version. The caller holds the lock.
+func (c *ContentCache) entry(name string, info fs.FileInfo) *cachedFile {
+	c.seen[name] = true
+	f := c.files[name]
+	if f == nil || f.Size != info.Size() || f.ModTime != info.ModTime().UnixNano() {
+		f = &cachedFile{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		c.files[name] = f
+	}
+	return f
+}
+
+// sniff reports whether the file at name looks binary, see sniffFile
+func (c *ContentCache) sniff(fsys fs.FS, name string, info fs.FileInfo) (bool, error) {
+	if c == nil {
+		return sniffFile(fsys, name)
+	}
+	c.mu.Lock()
+	f := c.entry(name, info)
+	if f.Sniffed {
+		c.reused++
+		c.mu.Unlock()
+		return f.Binary, nil
+	}
+	c.mu.Unlock()
+
+	binary, err := sniffFile(fsys, name)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	f.Sniffed, f.Binary = true, binary
+	c.mu.Unlock()
+	return binary, nil
+}
+
+// hash returns the hash of the file at name, see hashFile
+func (c *ContentCache) hash(name string, info fs.FileInfo, compute func() (string, error)) (string, error) {
+	if c == nil {
+		return compute()
+	}
+	c.mu.Lock()
+	f := c.entry(name, info)
+	if f.Hash != "" {
+		c.reused++
+		c.mu.Unlock()
+		return f.Hash, nil
+	}
+	c.mu.Unlock()
+
+	hash, err := compute()
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	f.Hash = hash
+	c.mu.Unlock()
+	return hash, nil
+}
+
+// outline returns the outline written for the file at name instead of its
+// content over opts.OutlineOver lines, reporting false unless the cache
+// holds one for the current version of the file
+func (c *ContentCache) outline(fsys fs.FS, name string, opts *Options, msg i18n.Printer) (string, bool) {
+	info, err := fs.Stat(fsys, name)
+	if c == nil || err != nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.entry(name, info)
+	if f.Lines != opts.OutlineOver || f.Lang != msg.Lang() || f.Outline == "" {
+		return "", false
+	}
+	c.reused++
+	return f.Outline, true
+}
+
+// storeOutline records the outline of the file at name, "" for none
+func (c *ContentCache) storeOutline(fsys fs.FS, name string, opts *Options, msg i18n.Printer, outline string) {
+	info, err := fs.Stat(fsys, name)
+	if c == nil || err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.entry(name, info)
+	f.Lines, f.Outline, f.Lang = opts.OutlineOver, outline, msg.Lang()
+}
@@ -0,0 +1,55 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeDelimitedFile writes a file section framed by sentinel lines. The begin
+// line gives the exact length of content, which is followed by a newline and
+// the end line.
+func writeDelimitedFile(output io.Writer, name string, content []byte) {
+	fmt.Fprintf(output, "--BEGIN FILE %s len=%d--\n", name, len(content))
+	output.Write(content)
+	fmt.Fprintf(output, "\n--END FILE %s--\n", name)
+}
+
+// delimitedWriter buffers a section written as <Tag attrs> ... </Tag> and, on
+// close, writes it framed by sentinel lines like a file section instead
+type delimitedWriter struct {
+	output io.Writer
+	buf    bytes.Buffer
+}
+
+// delimitSection returns the writer for one section of text output, output
+// itself unless sections are delimited. The returned func must be called once
+// the section has been written.
+func delimitSection(output io.Writer, opts *Options) (io.Writer, func()) {
+	if !opts.Delimited {
+		return output, func() {}
+	}
+	d := &delimitedWriter{output: output}
+	return d, d.close
+}
+
+func (d *delimitedWriter) Write(p []byte) (int, error) {
+	return d.buf.Write(p)
+}
+
+// close writes the buffered section, if any, between sentinel lines naming
+// its tag and attributes in place of the opening and closing tags
+func (d *delimitedWriter) close() {
+	data := d.buf.Bytes()
+	if len(data) == 0 {
+		return
+	}
+	open, body, _ := bytes.Cut(data, []byte("\n"))
+	header := string(bytes.TrimSuffix(bytes.TrimPrefix(open, []byte("<")), []byte(">")))
+	tag, _, _ := strings.Cut(header, " ")
+	body = bytes.TrimSuffix(body, []byte("</"+tag+">\n"))
+	fmt.Fprintf(d.output, "--BEGIN SECTION %s len=%d--\n", header, len(body))
+	d.output.Write(body)
+	fmt.Fprintf(d.output, "\n--END SECTION %s--\n", tag)
+}
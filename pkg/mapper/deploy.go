@@ -0,0 +1,222 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/internal/yaml"
+)
+
+// InfraFile is a detected deployment or infrastructure file and its summary
+type InfraFile struct {
+	path    string
+	kind    string   // dockerfile, compose, kubernetes or terraform
+	summary []string // Short human readable facts
+}
+
+var terraformBlock = regexp.MustCompile(`^(resource|data|module|provider|output|variable)\s+"([^"]+)"(?:\s+"([^"]+)")?`)
+
+// infraKind classifies a file by name, returning "" for non-infra files.
+// YAML files are reported as "yaml" and classified by content later.
+func infraKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case lower == "dockerfile" || strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile"):
+		return "dockerfile"
+	case strings.HasPrefix(lower, "docker-compose") || strings.HasPrefix(lower, "compose.") || lower == "compose.yaml":
+		return "compose"
+	case strings.HasSuffix(lower, ".tf"):
+		return "terraform"
+	case strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml"):
+		return "yaml"
+	}
+	return ""
+}
+
+// collectInfra summarizes the infrastructure files in the tree. When omit is
+// set the detected files are marked so only their summary is emitted.
+func collectInfra(tree *TreeNode, fsys fs.FS, omit bool) []InfraFile {
+	files := make([]InfraFile, 0)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		kind := infraKind(node.name)
+		if kind == "" || node.omitted {
+			return
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			i18n.Warnf("Could not read %s: %v", name, err)
+			return
+		}
+
+		var summary []string
+		switch kind {
+		case "dockerfile":
+			summary = summarizeDockerfile(data)
+		case "compose":
+			summary = summarizeCompose(data)
+		case "terraform":
+			summary = summarizeTerraform(data)
+		case "yaml":
+			summary = summarizeKubernetes(data)
+			if len(summary) == 0 {
+				return
+			}
+			kind = "kubernetes"
+		}
+
+		if omit {
+			node.omitted = true
+		}
+		files = append(files, InfraFile{path: name, kind: kind, summary: summary})
+	})
+	return files
+}
+
+// writeDeploymentSurface writes the infrastructure summary section
+func writeDeploymentSurface(files []InfraFile, output io.Writer) {
+	if len(files) == 0 {
+		return
+	}
+
+	fmt.Fprintln(output, "<Deployment_Surface>")
+	for _, f := range files {
+		fmt.Fprintf(output, "%s (%s)\n", f.path, f.kind)
+		for _, line := range f.summary {
+			fmt.Fprintf(output, "  %s\n", line)
+		}
+	}
+	fmt.Fprintln(output, "</Deployment_Surface>")
+}
+
+// summarizeDockerfile lists base images, exposed ports and the start command
+func summarizeDockerfile(data []byte) []string {
+	summary := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		instruction, args, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(instruction) {
+		case "FROM":
+			summary = append(summary, "base image: "+strings.TrimSpace(args))
+		case "EXPOSE":
+			summary = append(summary, "exposes: "+strings.TrimSpace(args))
+		case "ENTRYPOINT", "CMD":
+			summary = append(summary, strings.ToLower(instruction)+": "+strings.TrimSpace(args))
+		}
+	}
+	return summary
+}
+
+// summarizeCompose lists the services of a compose file with their image and ports
+func summarizeCompose(data []byte) []string {
+	doc, err := yaml.Parse(string(data))
+	if err != nil {
+		return []string{"(could not parse: " + err.Error() + ")"}
+	}
+	root, _ := doc.(map[string]any)
+	services, _ := root["services"].(map[string]any)
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summary := make([]string, 0, len(names))
+	for _, name := range names {
+		line := "service " + name
+		if svc, ok := services[name].(map[string]any); ok {
+			if image, ok := svc["image"].(string); ok {
+				line += ": image " + image
+			} else if _, ok := svc["build"]; ok {
+				line += ": built locally"
+			}
+			if ports, ok := svc["ports"].([]any); ok && len(ports) > 0 {
+				parts := make([]string, 0, len(ports))
+				for _, p := range ports {
+					if s, ok := p.(string); ok {
+						parts = append(parts, s)
+					}
+				}
+				line += ", ports " + strings.Join(parts, " ")
+			}
+		}
+		summary = append(summary, line)
+	}
+	return summary
+}
+
+// summarizeKubernetes lists kind/name for each manifest document, returning
+// nothing when the YAML is not a Kubernetes manifest
+func summarizeKubernetes(data []byte) []string {
+	summary := make([]string, 0)
+	var apiVersion, kind, name string
+	inMetadata := false
+	metadataIndent := 0 // Indentation of metadata's direct children
+
+	flush := func() {
+		if apiVersion != "" && kind != "" {
+			summary = append(summary, fmt.Sprintf("%s %s", kind, name))
+		}
+		apiVersion, kind, name = "", "", ""
+		inMetadata = false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		if strings.HasPrefix(raw, "---") {
+			flush()
+			continue
+		}
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		switch {
+		case indent == 0 && strings.HasPrefix(line, "apiVersion:"):
+			apiVersion = strings.TrimSpace(strings.TrimPrefix(line, "apiVersion:"))
+		case indent == 0 && strings.HasPrefix(line, "kind:"):
+			kind = strings.TrimSpace(strings.TrimPrefix(line, "kind:"))
+		case indent == 0:
+			inMetadata = line == "metadata:"
+			metadataIndent = 0
+		case inMetadata:
+			if metadataIndent == 0 {
+				metadataIndent = indent
+			}
+			if indent == metadataIndent && name == "" && strings.HasPrefix(line, "name:") {
+				name = yaml.Unquote(strings.TrimSpace(strings.TrimPrefix(line, "name:")))
+			}
+		}
+	}
+	flush()
+	return summary
+}
+
+// summarizeTerraform lists the resources, modules and providers declared in a file
+func summarizeTerraform(data []byte) []string {
+	summary := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		m := terraformBlock.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		if m[3] != "" {
+			summary = append(summary, fmt.Sprintf("%s %s.%s", m[1], m[2], m[3]))
+		} else {
+			summary = append(summary, fmt.Sprintf("%s %s", m[1], m[2]))
+		}
+	}
+	return summary
+}
@@ -0,0 +1,264 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Dependency is a single direct dependency declared in a manifest
+type Dependency struct {
+	name    string
+	version string
+	scope   string // "", "dev", "build" or "peer"
+}
+
+// Manifest is a parsed dependency manifest
+type Manifest struct {
+	path      string
+	ecosystem string
+	deps      []Dependency
+}
+
+// manifestParsers maps manifest file names to their parser and ecosystem
+var manifestParsers = map[string]struct {
+	ecosystem string
+	parse     func([]byte) ([]Dependency, error)
+}{
+	"go.mod":           {"go", parseGoMod},
+	"package.json":     {"npm", parsePackageJSON},
+	"requirements.txt": {"pip", parseRequirements},
+	"Cargo.toml":       {"cargo", parseCargoToml},
+}
+
+// lockFiles are generated dependency locks summarized by their manifests
+var lockFiles = map[string]bool{
+	"go.sum":              true,
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"pnpm-lock.yaml":      true,
+	"yarn.lock":           true,
+	"Cargo.lock":          true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+}
+
+// collectManifests parses every dependency manifest present in the tree
+func collectManifests(tree *TreeNode, fsys fs.FS) []Manifest {
+	manifests := make([]Manifest, 0)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		parser, ok := manifestParsers[node.name]
+		if !ok || node.omitted {
+			return
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			i18n.Warnf("Could not read manifest %s: %v", name, err)
+			return
+		}
+		deps, err := parser.parse(data)
+		if err != nil {
+			i18n.Warnf("Could not parse manifest %s: %v", name, err)
+			return
+		}
+		manifests = append(manifests, Manifest{path: name, ecosystem: parser.ecosystem, deps: deps})
+	})
+	return manifests
+}
+
+// writeDependencies writes the normalized dependency section
+func writeDependencies(manifests []Manifest, output io.Writer) {
+	if len(manifests) == 0 {
+		return
+	}
+
+	fmt.Fprintln(output, "<Dependencies>")
+	for _, m := range manifests {
+		fmt.Fprintf(output, "%s (%s, %d direct)\n", m.path, m.ecosystem, len(m.deps))
+		for _, d := range m.deps {
+			line := d.name
+			if d.version != "" {
+				line += " " + d.version
+			}
+			if d.scope != "" {
+				line += " [" + d.scope + "]"
+			}
+			fmt.Fprintf(output, "  %s\n", line)
+		}
+	}
+	fmt.Fprintln(output, "</Dependencies>")
+}
+
+// parseGoMod returns the direct requirements of a go.mod file
+func parseGoMod(data []byte) ([]Dependency, error) {
+	deps := make([]Dependency, 0)
+	inBlock := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || indirect {
+			continue
+		}
+		deps = append(deps, Dependency{name: fields[0], version: fields[1]})
+	}
+	return deps, scanner.Err()
+}
+
+// parsePackageJSON returns the declared dependencies of a package.json file
+func parsePackageJSON(data []byte) ([]Dependency, error) {
+	var pkg struct {
+		Dependencies     map[string]string `json:"dependencies"`
+		DevDependencies  map[string]string `json:"devDependencies"`
+		PeerDependencies map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	deps := make([]Dependency, 0)
+	for _, group := range []struct {
+		scope string
+		deps  map[string]string
+	}{{"", pkg.Dependencies}, {"dev", pkg.DevDependencies}, {"peer", pkg.PeerDependencies}} {
+		names := make([]string, 0, len(group.deps))
+		for name := range group.deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			deps = append(deps, Dependency{name: name, version: group.deps[name], scope: group.scope})
+		}
+	}
+	return deps, nil
+}
+
+// parseRequirements returns the packages of a pip requirements file
+func parseRequirements(data []byte) ([]Dependency, error) {
+	deps := make([]Dependency, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		// Skip blanks and options such as -r, -e or --index-url
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = strings.TrimSpace(line[:i]) // Drop environment markers
+		}
+
+		name, version := line, ""
+		if i := strings.IndexAny(line, "=<>!~"); i >= 0 {
+			name, version = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i:])
+		}
+		deps = append(deps, Dependency{name: name, version: version})
+	}
+	return deps, scanner.Err()
+}
+
+// parseCargoToml returns the dependencies declared in a Cargo.toml file
+func parseCargoToml(data []byte) ([]Dependency, error) {
+	deps := make([]Dependency, 0)
+	scopes := map[string]string{
+		"dependencies":       "",
+		"dev-dependencies":   "dev",
+		"build-dependencies": "build",
+	}
+
+	section := ""
+	var table *Dependency // Set inside [dependencies.name] tables
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if table != nil {
+				deps = append(deps, *table)
+				table = nil
+			}
+			section = strings.Trim(line, "[] ")
+			for prefix, scope := range scopes {
+				if name, ok := strings.CutPrefix(section, prefix+"."); ok {
+					table = &Dependency{name: name, scope: scope}
+				}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if table != nil {
+			if key == "version" {
+				table.version = strings.Trim(value, `"'`)
+			}
+			continue
+		}
+
+		scope, ok := scopes[section]
+		if !ok {
+			continue
+		}
+		deps = append(deps, Dependency{name: key, version: cargoVersion(value), scope: scope})
+	}
+	if table != nil {
+		deps = append(deps, *table)
+	}
+	return deps, scanner.Err()
+}
+
+// cargoVersion extracts the version from "1.0" or { version = "1.0", ... }
+func cargoVersion(value string) string {
+	if !strings.HasPrefix(value, "{") {
+		return strings.Trim(value, `"'`)
+	}
+	for _, field := range strings.Split(strings.Trim(value, "{}"), ",") {
+		key, v, ok := strings.Cut(field, "=")
+		if ok && strings.TrimSpace(key) == "version" {
+			return strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	if strings.Contains(value, "path") {
+		return "(path)"
+	}
+	if strings.Contains(value, "git") {
+		return "(git)"
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diagramNode is a tree node numbered for a diagram
+type diagramNode struct {
+	id     string
+	label  string
+	isDir  bool
+	parent string // Id of the parent, "" for the root
+	hidden int    // Entries left out below the depth limit
+	node   *TreeNode
+}
+
+// diagramNodes numbers the nodes down to opts.DiagramDepth levels below the
+// root, in tree order. Directories at the limit are labeled with how many
+// entries they hold instead.
+func (t *Tree) diagramNodes() []diagramNode {
+	nodes := make([]diagramNode, 0)
+	var visit func(node *TreeNode, parent string, depth int)
+	visit = func(node *TreeNode, parent string, depth int) {
+		d := diagramNode{id: fmt.Sprintf("n%d", len(nodes)), label: node.name, isDir: node.isDir, parent: parent, node: node}
+		if node.isDir {
+			d.label += "/"
+		}
+		limited := t.opts.DiagramDepth > 0 && depth >= t.opts.DiagramDepth
+		if limited && len(node.children) > 0 {
+			d.hidden = len(node.children)
+			d.label += " " + t.msg.Sprintf(plural(d.hidden, "(%d entry)", "(%d entries)"), d.hidden)
+		}
+		nodes = append(nodes, d)
+		if limited {
+			return
+		}
+		for _, child := range node.children {
+			visit(child, d.id, depth+1)
+		}
+	}
+	visit(t.root, "", 0)
+	return nodes
+}
+
+// renderMermaid writes the structure as a Mermaid flowchart
+func (t *Tree) renderMermaid(w io.Writer) error {
+	fmt.Fprintln(w, "flowchart LR")
+	omitted := make([]string, 0)
+	for _, d := range t.diagramNodes() {
+		// Directories get rounded boxes, files rectangles
+		shape := `["%s"]`
+		if d.isDir {
+			shape = `("%s")`
+		}
+		label := fmt.Sprintf(shape, mermaidEscape(d.label))
+		if d.parent == "" {
+			fmt.Fprintf(w, "  %s%s\n", d.id, label)
+		} else {
+			fmt.Fprintf(w, "  %s --> %s%s\n", d.parent, d.id, label)
+		}
+		if d.node.omitted {
+			omitted = append(omitted, d.id)
+		}
+	}
+	if len(omitted) > 0 {
+		fmt.Fprintln(w, "  classDef omitted stroke-dasharray: 4 3,color:#888")
+		fmt.Fprintf(w, "  class %s omitted\n", strings.Join(omitted, ","))
+	}
+	return nil
+}
+
+// mermaidEscape replaces the characters that end a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// renderDOT writes the structure as a Graphviz digraph
+func (t *Tree) renderDOT(w io.Writer) error {
+	fmt.Fprintf(w, "digraph %s {\n", dotQuote(t.root.name))
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, fontname="Helvetica"];`)
+	for _, d := range t.diagramNodes() {
+		attrs := []string{"label=" + dotQuote(d.label)}
+		if d.isDir {
+			attrs = append(attrs, "shape=folder")
+		}
+		if d.node.omitted {
+			attrs = append(attrs, "style=dashed", "fontcolor=gray50")
+		}
+		fmt.Fprintf(w, "  %s [%s];\n", d.id, strings.Join(attrs, ", "))
+		if d.parent != "" {
+			fmt.Fprintf(w, "  %s -> %s;\n", d.parent, d.id)
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// dotQuote returns s as a DOT double-quoted string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,156 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// dirPatternSet holds the pattern files of one name, such as .gitignore,
+// found in the directories walked so far. Each file applies to the entries
+// below its directory, deeper files first.
+type dirPatternSet struct {
+	fsys       fs.FS
+	file       string // Name of the pattern files
+	ignoreCase bool
+	form       NormalForm              // Normal form of the names walked, see Options.Normalize
+	lists      map[string]*PatternList // By directory, nil when it has no pattern file
+}
+
+// newGitignoreSet returns the set for fsys, or nil when opts does not honor .gitignore files
+func newGitignoreSet(fsys fs.FS, opts *Options) *dirPatternSet {
+	if !opts.RespectGitignore {
+		return nil
+	}
+	return &dirPatternSet{fsys: fsys, file: ".gitignore", ignoreCase: opts.IgnoreCase, form: opts.Normalize, lists: make(map[string]*PatternList)}
+}
+
+// newNestedSet returns the set of .project_structure_ignore files in the
+// subdirectories of fsys, or nil when opts does not honor them. The file in
+// the root is left out since it is the one Options.Patterns comes from.
+func newNestedSet(fsys fs.FS, opts *Options) *dirPatternSet {
+	if !opts.NestedPatterns {
+		return nil
+	}
+	set := &dirPatternSet{fsys: fsys, file: ".project_structure_ignore", ignoreCase: opts.IgnoreCase, form: opts.Normalize, lists: make(map[string]*PatternList)}
+	set.lists["."] = nil
+	return set
+}
+
+// match returns the pattern deciding the entry at name, if any. Patterns of
+// deeper files take precedence.
+func (g *dirPatternSet) match(name string, isDir bool) *Pattern {
+	if g == nil {
+		return nil
+	}
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		if list := g.load(dir); list != nil {
+			rel := name
+			if dir != "." {
+				rel = strings.TrimPrefix(name, dir+"/")
+			}
+			if p := list.match(rel, isDir); p != nil {
+				return p
+			}
+		}
+		if dir == "." {
+			return nil
+		}
+	}
+}
+
+// preload reads the pattern files of dir and its parents, so matching
+// entries inside only reads the set
+func (g *dirPatternSet) preload(dir string) {
+	if g == nil {
+		return
+	}
+	for ; dir != "."; dir = path.Dir(dir) {
+		g.load(dir)
+	}
+	g.load(".")
+}
+
+// load reads the pattern file of dir once
+func (g *dirPatternSet) load(dir string) *PatternList {
+	if list, ok := g.lists[dir]; ok {
+		return list
+	}
+	g.lists[dir] = nil
+
+	name := path.Join(dir, g.file)
+	list := &PatternList{basePath: dir, matchType: Ignore}
+	var err error
+	if g.file == ".gitignore" {
+		err = list.loadGitignore(g.fsys, name)
+	} else {
+		err = list.loadFS(g.fsys, name, make(map[string]bool))
+	}
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			i18n.Warnf("Could not read %s: %v", name, err)
+		}
+		return nil
+	}
+
+	if g.ignoreCase {
+		list = list.foldCase()
+	}
+	list = list.normalize(g.form)
+	if len(list.patterns) > 0 {
+		g.lists[dir] = list
+	}
+	return g.lists[dir]
+}
+
+// loadGitignore adds the patterns of a .gitignore file, which supports
+// negation but none of the extensions of pattern files
+func (pl *PatternList) loadGitignore(fsys fs.FS, name string) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := Pattern{text: line}
+		glob := line
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			p.negate, glob = true, rest
+		}
+		if err := pl.addGlob(p, glob); err != nil {
+			i18n.Warnf("Skipping pattern %s:%d: %v", name, lineNo, err)
+			continue
+		}
+		pl.patterns[len(pl.patterns)-1].source = fmt.Sprintf("%s:%d", name, lineNo)
+	}
+	return scanner.Err()
+}
+
+// dirs returns the directories with patterns in walk order
+func (g *dirPatternSet) dirs() []string {
+	if g == nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(g.lists))
+	for dir, list := range g.lists {
+		if list != nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
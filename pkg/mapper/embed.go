@@ -0,0 +1,262 @@
+package mapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// embedBatch is how many texts are sent to an embedder at once
+const embedBatch = 16
+
+// embedMaxBytes is how much of a file's content is embedded, since models
+// accept a limited input length
+const embedMaxBytes = 8 * 1024
+
+// Embedder turns texts into embedding vectors, e.g. by calling a model server
+type Embedder interface {
+	// Name identifies the model, so vectors of different models are never compared
+	Name() string
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint, as served by
+// OpenAI and by local model servers such as Ollama, llama.cpp and vLLM
+type HTTPEmbedder struct {
+	URL    string       // Endpoint, e.g. http://localhost:11434/v1/embeddings
+	Model  string       // Model name sent with each request
+	APIKey string       // Sent as a bearer token when set
+	Client *http.Client // nil for http.DefaultClient
+}
+
+// Name returns the endpoint and model
+func (e *HTTPEmbedder) Name() string {
+	return e.URL + " " + e.Model
+}
+
+// Embed sends texts to the endpoint in a single request
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": e.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating embedding request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling embedding endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding endpoint returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding embeddings: %v", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding endpoint returned %d vectors for %d inputs", len(result.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for i, d := range result.Data {
+		if d.Index >= 0 && d.Index < len(texts) {
+			i = d.Index
+		}
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// embedText is the text embedded for a file: its path followed by the start
+// of its content
+func embedText(fsys fs.FS, name string) string {
+	data, _ := fs.ReadFile(fsys, name)
+	if len(data) > embedMaxBytes {
+		data = data[:embedMaxBytes]
+	}
+	return name + "\n\n" + string(bytes.ToValidUTF8(data, nil))
+}
+
+// embedFiles embeds the named files in batches, returning their vectors in order
+func embedFiles(ctx context.Context, fsys fs.FS, names []string, e Embedder) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(names))
+	for start := 0; start < len(names); start += embedBatch {
+		batch := names[start:min(start+embedBatch, len(names))]
+		texts := make([]string, len(batch))
+		for i, name := range batch {
+			texts[i] = embedText(fsys, name)
+		}
+		embedded, err := e.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// Embed adds the embeddings of the files that lack one, reading them from
+// the tree they were indexed from. Vectors of another embedder are discarded
+// first. It returns how many files were embedded.
+func (ix *Index) Embed(ctx context.Context, t *Tree, e Embedder) (int, error) {
+	if ix.model != e.Name() {
+		ix.model = e.Name()
+		ix.vectors = make([][]float32, len(ix.files))
+	}
+
+	missing := make([]int, 0)
+	names := make([]string, 0)
+	for i, f := range ix.files {
+		if ix.vectors[i] == nil {
+			missing = append(missing, i)
+			names = append(names, f.Path)
+		}
+	}
+	vectors, err := embedFiles(ctx, t.fsys, names, e)
+	if err != nil {
+		return 0, err
+	}
+	for j, i := range missing {
+		ix.vectors[i] = vectors[j]
+	}
+	return len(missing), nil
+}
+
+// vector returns the embedding of a file by model if the index holds the
+// same version of it
+func (ix *Index) vector(f indexedFile, model string) []float32 {
+	if ix == nil || ix.model != model || ix.vectors == nil {
+		return nil
+	}
+	i, ok := ix.byPath[f.Path]
+	if !ok || ix.files[i] != f {
+		return nil
+	}
+	return ix.vectors[i]
+}
+
+// vectorOf returns the indexed embedding of a file of fsys, or nil when the
+// index has none for its current version
+func (ix *Index) vectorOf(fsys fs.FS, name, model string) []float32 {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil
+	}
+	return ix.vector(indexedFile{Path: name, Size: info.Size(), ModTime: info.ModTime().UnixNano()}, model)
+}
+
+// cosine returns the cosine similarity of two vectors, 0 when their lengths differ
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// rankBySimilarity ranks every file with content by the cosine similarity of
+// its embedding to the query's, embedding files the index has no vector for.
+// Files with more commits in churn rank higher. The best files keep their
+// content, the rest are omitted.
+func rankBySimilarity(ctx context.Context, tree *TreeNode, fsys fs.FS, opts *Options, churn map[string]int, msg i18n.Printer) error {
+	e := opts.Embedder
+	if e == nil {
+		return fmt.Errorf("a semantic query needs an embedder")
+	}
+	query, err := e.Embed(ctx, []string{opts.SemanticQuery})
+	if err != nil {
+		return err
+	}
+	if len(query) != 1 {
+		return fmt.Errorf("embedder returned %d vectors for the query", len(query))
+	}
+
+	type scored struct {
+		node   *TreeNode
+		name   string
+		vector []float32
+		score  float64
+	}
+	files := make([]*scored, 0)
+	missing := make([]*scored, 0)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		if node.omitted || node.hoisted {
+			return
+		}
+		f := &scored{node: node, name: name, vector: opts.Index.vectorOf(fsys, name, e.Name())}
+		if f.vector == nil {
+			missing = append(missing, f)
+		}
+		files = append(files, f)
+	})
+
+	names := make([]string, len(missing))
+	for i, f := range missing {
+		names[i] = f.name
+	}
+	vectors, err := embedFiles(ctx, fsys, names, e)
+	if err != nil {
+		return err
+	}
+	for i, f := range missing {
+		f.vector = vectors[i]
+	}
+
+	for _, f := range files {
+		f.score = cosine(query[0], f.vector) * churnBoost(churn[f.name])
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].score != files[j].score {
+			return files[i].score > files[j].score
+		}
+		return files[i].name < files[j].name
+	})
+
+	top := opts.QueryTop
+	if top <= 0 {
+		top = defaultQueryTop
+	}
+	for i, f := range files {
+		if i < top {
+			f.node.addNote(msg.Sprintf("query rank %d", i+1))
+			continue
+		}
+		f.node.omitted = true
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package mapper
+
+import (
+	"os"
+	"strings"
+)
+
+// ExpandEnv replaces ${VAR} and ${VAR:-default} references with environment values.
+// Bare $VAR is left untouched so names like $RECYCLE.BIN keep working as patterns.
+func ExpandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(s[:start])
+		name, fallback, hasFallback := strings.Cut(s[start+2:end], ":-")
+		if value, ok := os.LookupEnv(name); ok && (value != "" || !hasFallback) {
+			b.WriteString(value)
+		} else {
+			b.WriteString(fallback)
+		}
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
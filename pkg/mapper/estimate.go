@@ -0,0 +1,48 @@
+package mapper
+
+import (
+	"io/fs"
+	"path"
+)
+
+// EstimateSize returns roughly how many bytes Render writes in format: the
+// tree and, for the formats carrying contents, the size of every file whose
+// content is included. Escaping may add a little; outlines and queries
+// usually make the output smaller.
+func (t *Tree) EstimateSize(format Format) int64 {
+	withContent := !t.opts.StructureOnly
+	switch format {
+	case FormatMermaid, FormatDOT, FormatProse, FormatParquet:
+		withContent = false
+	}
+	return t.estimateNode(t.root, ".", 0, withContent)
+}
+
+// truncatedLineSize is the length assumed for the lines kept of a file cut
+// to its first and last lines, which are not read to estimate
+const truncatedLineSize = 200
+
+// estimateNode sums the tree line of node, found at name, and its content
+// or entries
+func (t *Tree) estimateNode(node *TreeNode, name string, depth int, withContent bool) int64 {
+	// Indentation, name, brackets and note
+	size := int64(4*depth + len(node.name) + len(node.note) + len(node.link) + 8)
+	if node.isDir {
+		for _, child := range node.children {
+			size += t.estimateNode(child, path.Join(name, child.name), depth+1, withContent)
+		}
+		return size
+	}
+	if withContent && !node.omitted {
+		if info, err := fs.Stat(t.fsys, name); err == nil {
+			// Opening and closing tags or headings around the content
+			size += int64(2*len(node.name) + 8)
+			if node.truncated() {
+				size += min(info.Size(), int64(node.keepLines+node.keepTail+1)*truncatedLineSize)
+			} else {
+				size += info.Size()
+			}
+		}
+	}
+	return size
+}
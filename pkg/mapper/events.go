@@ -0,0 +1,91 @@
+package mapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// EventKind tells what a walk event reports
+type EventKind int
+
+const (
+	EventEnterDir EventKind = iota // A directory is about to be walked
+	EventLeaveDir                  // All entries of a directory were walked
+	EventFile                      // A file was mapped
+	EventSkip                      // An entry was left out, or listed without content
+	EventError                     // A directory could not be read
+)
+
+// String returns the name of the event kind
+func (k EventKind) String() string {
+	switch k {
+	case EventEnterDir:
+		return "enter"
+	case EventLeaveDir:
+		return "leave"
+	case EventFile:
+		return "file"
+	case EventSkip:
+		return "skip"
+	case EventError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Event is something that happened during a walk, see WalkEvents
+type Event struct {
+	Kind   EventKind
+	Path   string // Slash-separated path below the root, "." for the root
+	IsDir  bool
+	Rule   string // For EventSkip, the rule that decided it, as worded by Explain
+	Listed bool   // For EventSkip, the entry stays in the tree without content
+	Err    error  // For EventError
+}
+
+// WalkEvents walks root as Scan does, calling fn with every event as it
+// happens instead of building a tree. Directories are entered and left in
+// walk order, and a directory walked only to look for filter matches may be
+// entered and then skipped.
+//
+// An error returned by fn stops the walk and is returned, except for
+// fs.SkipDir, which skips the rest of the directory being walked (all of it
+// when returned for EventEnterDir), and fs.SkipAll, which stops the walk
+// without error. When fn returns nil for EventError, the walk continues.
+// The post-processing options such as Query and the checkpoint are ignored.
+func WalkEvents(root string, opts *Options, fn func(Event) error) error {
+	return WalkEventsContext(context.Background(), root, opts, fn)
+}
+
+// WalkEventsContext is like WalkEvents but stops with ctx's error once ctx
+// is done
+func WalkEventsContext(ctx context.Context, root string, opts *Options, fn func(Event) error) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("error resolving root: %v", err)
+	}
+	opts, report, err := newScan(ctx, dirFS(root), root, root, opts)
+	if err != nil {
+		return err
+	}
+	walkOpts := *opts
+	walkOpts.Checkpoint = nil
+	report.events = fn
+
+	_, err = createTree(".", walkOpts.Patterns, &walkOpts, report)
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// emit passes e to the callback of WalkEvents, keeping the error it returns
+// to stop the walk
+func (r *ScanReport) emit(e Event) {
+	if r.events != nil && r.stopped == nil {
+		r.stopped = r.events(e)
+	}
+}
@@ -0,0 +1,222 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Explain walks from the root to target and reports the first rule that
+// excludes it, or that it is included. Other patterns matching the path are
+// listed below, since only the last matching one decides.
+func Explain(root, target string, opts *Options, w io.Writer) error {
+	msg, err := i18n.Lookup(opts.Language)
+	if err != nil {
+		return err
+	}
+	patterns := opts.Patterns
+	if opts.IgnoreCase {
+		patterns = patterns.foldCase()
+		folded := *opts
+		folded.Include = opts.Include.foldCase()
+		opts = &folded
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target = filepath.Clean(target)
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside the root %s", target, root)
+	}
+	if rel == "." {
+		msg.Fprintf(w, "%s: included (the root itself)\n", target)
+		return nil
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	fsys := dirFS(root)
+	if opts.Normalize != NormNone {
+		fsys = newNormFS(fsys, opts.Normalize)
+		patterns = patterns.normalize(opts.Normalize)
+		normalized := *opts
+		normalized.Include = opts.Include.normalize(opts.Normalize)
+		opts = &normalized
+		for i, part := range parts {
+			parts[i] = opts.Normalize.Normalize(part)
+		}
+	}
+	gitignores := newGitignoreSet(fsys, opts)
+	nested := newNestedSet(fsys, opts)
+	name := "."
+	isDir := true
+	var decision SkipDecision
+	for i, part := range parts {
+		entry, err := lookupEntry(fsys, name, part)
+		if err != nil {
+			return fmt.Errorf("cannot explain %s: %v", target, err)
+		}
+		name, isDir = path.Join(name, part), entry.IsDir()
+
+		if isOutputPath(name, opts.ExcludePath) {
+			msg.Fprintf(w, "%s: excluded as the output file being written\n", filepath.ToSlash(rel))
+			return nil
+		}
+
+		decision, err = shouldSkipFile(fsys, entry, name, patterns, nested, gitignores, opts)
+		if err != nil {
+			return err
+		}
+		if decision.reason == NotSkipped {
+			if entry.Type()&fs.ModeSymlink != 0 && !opts.ShowSymlinks && symlinkCycle(root, parts[:i+1]) {
+				if i < len(parts)-1 {
+					msg.Fprintf(w, "%s: not mapped, because its parent %s is a symlink cycle, which is not followed\n", filepath.ToSlash(rel), filepath.ToSlash(filepath.Join(parts[:i+1]...)))
+				} else {
+					msg.Fprintf(w, "%s: a symlink cycle leading back into a directory being walked; listed in the tree without content\n", filepath.ToSlash(rel))
+				}
+				return nil
+			}
+			continue
+		}
+		if decision.partial {
+			if i < len(parts)-1 {
+				continue
+			}
+			msg.Fprintf(w, "%s: matches no filter pattern, so it is mapped only if entries inside match\n", filepath.ToSlash(rel))
+			return nil
+		}
+
+		shown := msg.Sprintf("hidden from the tree")
+		if decision.visibility == Listed {
+			shown = msg.Sprintf("listed in the tree without content")
+		}
+		subject := msg.Sprintf("excluded")
+		if i < len(parts)-1 {
+			subject = msg.Sprintf("excluded because its parent %s is excluded", filepath.ToSlash(filepath.Join(parts[:i+1]...)))
+		}
+		msg.Fprintf(w, "%s: %s by %s; %s\n", filepath.ToSlash(rel), subject, describeDecision(decision, msg), shown)
+		writeOtherMatches(w, msg, name, isDir, decision.pattern, patterns, nested, gitignores)
+		return nil
+	}
+
+	detail := ""
+	switch p := decision.pattern; {
+	case p == nil:
+	case !p.negate:
+		detail = msg.Sprintf(" (matches filter pattern %q at %s)", p.text, p.source)
+	default:
+		detail = msg.Sprintf(" (re-included by pattern %q at %s)", p.text, p.source)
+	}
+	if decision.truncated {
+		detail += msg.Sprintf(" with its %s (> %d bytes)", truncatedNote(opts.HeadLines, opts.TailLines, msg), opts.maxFileSize())
+	}
+	msg.Fprintf(w, "%s: included%s\n", filepath.ToSlash(rel), detail)
+	writeOtherMatches(w, msg, name, isDir, decision.pattern, patterns, nested, gitignores)
+	return nil
+}
+
+// writeOtherMatches lists the patterns besides the deciding one that match
+// name or one of its parents, in the order they are evaluated
+func writeOtherMatches(w io.Writer, msg i18n.Printer, name string, isDir bool, decider *Pattern, patterns *PatternList, sets ...*dirPatternSet) {
+	type scoped struct {
+		list *PatternList
+		base string
+	}
+	lists := make([]scoped, 0)
+	if patterns != nil {
+		lists = append(lists, scoped{patterns, "."})
+	}
+	for _, set := range sets {
+		for _, dir := range set.dirs() {
+			if dir == "." || strings.HasPrefix(name, dir+"/") {
+				lists = append(lists, scoped{set.lists[dir], dir})
+			}
+		}
+	}
+
+	for _, s := range lists {
+		for i := range s.list.patterns {
+			p := &s.list.patterns[i]
+			if p == decider {
+				continue
+			}
+			candidate, candidateIsDir := name, isDir
+			for candidate != "." && candidate != s.base {
+				rel := candidate
+				if s.base != "." {
+					rel = strings.TrimPrefix(candidate, s.base+"/")
+				}
+				if (candidateIsDir || !p.dirOnly) && p.matches(rel) {
+					via := ""
+					if candidate != name {
+						via = msg.Sprintf(" via %s", candidate)
+					}
+					msg.Fprintf(w, "  also matched by %q at %s%s\n", p.text, p.source, via)
+					break
+				}
+				candidate, candidateIsDir = path.Dir(candidate), true
+			}
+		}
+	}
+}
+
+// lookupEntry finds an entry of dir the same way the tree walk sees it
+func lookupEntry(fsys fs.FS, dir, base string) (fs.DirEntry, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == base {
+			return entry, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "lookup", Path: path.Join(dir, base), Err: fs.ErrNotExist}
+}
+
+// symlinkCycle reports whether the symlink at the path of parts below root
+// leads to a directory the walk is inside of when reaching it, which the walk
+// lists as a cycle instead of following
+func symlinkCycle(root string, parts []string) bool {
+	target, err := os.Stat(filepath.Join(root, filepath.Join(parts...)))
+	if err != nil || !target.IsDir() {
+		return false
+	}
+	for i := range parts {
+		if dir, err := os.Stat(filepath.Join(root, filepath.Join(parts[:i]...))); err == nil && os.SameFile(dir, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeDecision names the rule behind a skip decision
+func describeDecision(d SkipDecision, msg i18n.Printer) string {
+	switch {
+	case d.pattern != nil && d.pattern.negate:
+		return msg.Sprintf("the negated filter pattern %q at %s", d.pattern.text, d.pattern.source)
+	case d.pattern != nil:
+		return msg.Sprintf("ignore pattern %q at %s", d.pattern.text, d.pattern.source)
+	case d.reason == SkipPattern:
+		return msg.Sprintf("the filter file: no filter pattern matches")
+	case d.reason == SkipTooLarge:
+		return msg.Sprintf("the size limit (%s)", d.rule)
+	case d.reason == SkipUnreadable:
+		return msg.Sprintf("a read failure (%s)", d.rule)
+	case d.reason == SkipSpecial:
+		return msg.Sprintf("the special file rule (a %s is never read)", d.rule)
+	case d.reason == SkipHidden:
+		return msg.Sprintf("the hidden entry rule (--no-hidden)")
+	case d.reason == SkipEntryLimit:
+		return msg.Sprintf("the entry limit (%s)", d.rule)
+	default:
+		return msg.Sprintf("the built-in %s rule %q", d.reason, d.rule)
+	}
+}
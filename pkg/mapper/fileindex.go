@@ -0,0 +1,119 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// lineCounter counts the lines written through it
+type lineCounter struct {
+	w io.Writer
+	n int
+}
+
+func (lc *lineCounter) Write(p []byte) (int, error) {
+	n, err := lc.w.Write(p)
+	lc.n += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
+
+// indexEntry is a file of the index and where its content is found
+type indexEntry struct {
+	path    string
+	line    int    // Line the content starts on, 0 when in a section
+	section string // Section holding the content of a hoisted file, or the heading anchor in Markdown
+}
+
+// fileIndex records where the content of each file starts as the file
+// contents are written, for an alphabetical index at the end
+type fileIndex struct {
+	lines   *lineCounter
+	names   []string // Files whose content is written, in order
+	next    int      // Index in names of the file written next
+	start   int      // Line the next file starts on
+	entries []indexEntry
+}
+
+// newFileIndex prepares the index of the files below root, with lines
+// counting the output so far
+func newFileIndex(root *TreeNode, lines *lineCounter) *fileIndex {
+	x := &fileIndex{lines: lines, start: lines.n + 1}
+	walkFiles(root, func(node *TreeNode, name string) {
+		switch {
+		case node.omitted:
+		case node.hoisted:
+			x.entries = append(x.entries, indexEntry{path: name, section: "Interfaces"})
+		default:
+			x.names = append(x.names, name)
+		}
+	})
+	return x
+}
+
+// wrote records the file just written, leaving out those that wrote nothing
+func (x *fileIndex) wrote() {
+	if x.next >= len(x.names) {
+		return
+	}
+	if x.lines.n >= x.start {
+		x.entries = append(x.entries, indexEntry{path: x.names[x.next], line: x.start})
+	}
+	x.next++
+	x.start = x.lines.n + 1
+}
+
+// sortIndex orders entries alphabetically, ignoring case
+func sortIndex(entries []indexEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := strings.ToLower(entries[i].path), strings.ToLower(entries[j].path)
+		if a != b {
+			return a < b
+		}
+		return entries[i].path < entries[j].path
+	})
+}
+
+// writeFileIndex appends the index of the files and the line or section
+// their content is found on
+func (x *fileIndex) writeFileIndex(msg i18n.Printer, output io.Writer) {
+	if len(x.entries) == 0 {
+		return
+	}
+	sortIndex(x.entries)
+	fmt.Fprintln(output, "<File_Index>")
+	for _, e := range x.entries {
+		where := "<" + e.section + ">"
+		if e.line > 0 {
+			where = msg.Sprintf("line %d", e.line)
+		}
+		fmt.Fprintf(output, "%s: %s\n", e.path, where)
+	}
+	fmt.Fprintln(output, "</File_Index>")
+}
+
+// headingAnchor returns the anchor GitHub gives a Markdown heading of text,
+// numbering repeats as used records them
+func headingAnchor(text string, used map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	anchor := b.String()
+	if n := used[anchor]; n > 0 {
+		used[anchor]++
+		return fmt.Sprintf("%s-%d", anchor, n)
+	}
+	used[anchor] = 1
+	return anchor
+}
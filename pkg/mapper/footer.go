@@ -0,0 +1,90 @@
+package mapper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// footerTag opens the footer ending a text snapshot
+const footerTag = "<Snapshot_Footer>\n"
+
+// SnapshotFooter is what the footer of a text snapshot records about the
+// body above it
+type SnapshotFooter struct {
+	Files  int    // File contents written
+	Bytes  int64  // Length of the body
+	SHA256 string // Hex digest of the body
+}
+
+// newBodyHash returns the hash of a snapshot body, resumed from the state
+// saved in cp when a checkpointed run continues. It returns nil when the
+// earlier part of the body cannot be accounted for.
+func newBodyHash(cp *Checkpoint) hash.Hash {
+	h := sha256.New()
+	if cp == nil || cp.files == 0 {
+		return h
+	}
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(cp.digest); err != nil {
+		return nil
+	}
+	return h
+}
+
+// hashState returns the state of h to save in a checkpoint, nil without one
+func hashState(h hash.Hash) []byte {
+	if h == nil {
+		return nil
+	}
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	return state
+}
+
+// writeFooter appends the footer describing a body of size bytes holding
+// files contents, whose hash is h
+func writeFooter(output io.Writer, files int, size int64, h hash.Hash) {
+	fmt.Fprint(output, footerTag)
+	fmt.Fprintf(output, "files: %d\nbytes: %d\nsha256: %s\n", files, size, hex.EncodeToString(h.Sum(nil)))
+	fmt.Fprintln(output, "</Snapshot_Footer>")
+}
+
+// maxFooter bounds the length of a footer
+const maxFooter = 256
+
+// VerifySnapshot checks the text snapshot r of size bytes against its
+// footer, returning the footer when the body is complete and unchanged
+func VerifySnapshot(r io.ReaderAt, size int64) (SnapshotFooter, error) {
+	var footer SnapshotFooter
+	tail := make([]byte, min(size, maxFooter))
+	if _, err := r.ReadAt(tail, size-int64(len(tail))); err != nil && err != io.EOF {
+		return footer, fmt.Errorf("error reading snapshot: %v", err)
+	}
+	i := bytes.LastIndex(tail, []byte(footerTag))
+	if i < 0 || !bytes.HasSuffix(tail, []byte("</Snapshot_Footer>\n")) {
+		return footer, errors.New("no footer found: the snapshot is truncated or was written without --footer")
+	}
+	var digest string
+	_, err := fmt.Sscanf(string(tail[i+len(footerTag):]), "files: %d\nbytes: %d\nsha256: %s\n</Snapshot_Footer>\n", &footer.Files, &footer.Bytes, &digest)
+	if err != nil {
+		return footer, fmt.Errorf("malformed footer: %v", err)
+	}
+	footer.SHA256 = digest
+
+	body := size - int64(len(tail)) + int64(i)
+	if body != footer.Bytes {
+		return footer, fmt.Errorf("the body has %d bytes but the footer records %d", body, footer.Bytes)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, body)); err != nil {
+		return footer, fmt.Errorf("error reading snapshot: %v", err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != footer.SHA256 {
+		return footer, fmt.Errorf("checksum mismatch: the body hashes to %s but the footer records %s", sum, footer.SHA256)
+	}
+	return footer, nil
+}
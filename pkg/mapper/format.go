@@ -0,0 +1,89 @@
+package mapper
+
+import "fmt"
+
+// Format selects how Render writes a tree
+type Format int
+
+const (
+	// FormatText writes the ASCII tree followed by the sections and file contents
+	FormatText Format = iota
+	// FormatJSON writes the tree as nested JSON objects with file contents as fields
+	FormatJSON
+	// FormatMarkdown writes the tree in a code block and each file as a fenced block under its path
+	FormatMarkdown
+	// FormatHTML writes a self-contained page with a collapsible tree and highlighted contents
+	FormatHTML
+	// FormatYAML writes the structure as nested mappings of directories to files
+	FormatYAML
+	// FormatMermaid writes the structure as a Mermaid flowchart
+	FormatMermaid
+	// FormatDOT writes the structure as a Graphviz digraph
+	FormatDOT
+	// FormatProse describes the structure in indented plain sentences
+	FormatProse
+	// FormatXML writes the tree as well-formed XML valid against XMLSchema
+	FormatXML
+	// FormatProtobuf writes the tree as a binary Snapshot message of ProtoSchema
+	FormatProtobuf
+	// FormatParquet writes a Parquet table of per-file metrics instead of the tree
+	FormatParquet
+)
+
+var formatNames = map[Format]string{
+	FormatText:     "text",
+	FormatJSON:     "json",
+	FormatMarkdown: "markdown",
+	FormatHTML:     "html",
+	FormatYAML:     "yaml",
+	FormatMermaid:  "mermaid",
+	FormatDOT:      "dot",
+	FormatProse:    "prose",
+	FormatXML:      "xml",
+	FormatProtobuf: "protobuf",
+	FormatParquet:  "parquet",
+}
+
+// String implements flag.Value
+func (f *Format) String() string {
+	if f == nil {
+		return formatNames[FormatText]
+	}
+	return formatNames[*f]
+}
+
+// Set implements flag.Value
+func (f *Format) Set(value string) error {
+	for format, name := range formatNames {
+		if name == value {
+			*f = format
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q", value)
+}
+
+// Extension returns the file extension conventionally used for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatJSON:
+		return ".json"
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	case FormatYAML:
+		return ".yaml"
+	case FormatMermaid:
+		return ".mmd"
+	case FormatDOT:
+		return ".dot"
+	case FormatXML:
+		return ".xml"
+	case FormatProtobuf:
+		return ".pb"
+	case FormatParquet:
+		return ".parquet"
+	}
+	return ".txt"
+}
@@ -0,0 +1,97 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// vendoredDirs are the built-in skipped directories holding third-party code
+var vendoredDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+}
+
+// buildOutputDirs are the built-in skipped directories holding build output
+var buildOutputDirs = map[string]bool{
+	"bin":         true,
+	"obj":         true,
+	"build":       true,
+	"dist":        true,
+	"target":      true,
+	".next":       true,
+	"__pycache__": true,
+}
+
+// generatedName matches file names that code generators conventionally produce
+var generatedName = regexp.MustCompile(`(\.pb\.go|\.pb\.gw\.go|_pb2(_grpc)?\.py|\.pb\.(cc|h)|\.g\.dart|\.min\.(js|css)|\.generated\.[a-z]+|_generated\.go|\.designer\.cs)$`)
+
+// generatedMarkers are header comments that declare a file generated
+var generatedMarkers = [][]byte{
+	[]byte("Code generated"), // Go convention, paired with "DO NOT EDIT"
+	[]byte("@generated"),
+	[]byte("<auto-generated"),
+	[]byte("DO NOT EDIT"),
+}
+
+// generatedHeaderSize is how much of a file is searched for a generated marker
+const generatedHeaderSize = 1024
+
+// WriteGitattributes writes suggested .gitattributes entries for the vendored,
+// build output and generated paths found by the scan
+func (t *Tree) WriteGitattributes(w io.Writer) error {
+	lines := make([]string, 0)
+	for _, dir := range t.report.builtinDirs {
+		base := dir[strings.LastIndex(dir, "/")+1:]
+		switch {
+		case vendoredDirs[base]:
+			lines = append(lines, gitattributesPath(dir)+"/** linguist-vendored")
+		case buildOutputDirs[base]:
+			lines = append(lines, gitattributesPath(dir)+"/** linguist-generated export-ignore")
+		}
+	}
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if lockFiles[node.name] || generatedName.MatchString(node.name) || t.hasGeneratedHeader(name) {
+			lines = append(lines, gitattributesPath(name)+" linguist-generated")
+		}
+	})
+	sort.Strings(lines)
+
+	if _, err := fmt.Fprintln(w, "# Suggested by directory-mapper; review before adding to .gitattributes"); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasGeneratedHeader reports whether the start of a file declares it generated
+func (t *Tree) hasGeneratedHeader(name string) bool {
+	file, err := t.fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, generatedHeaderSize)
+	n, _ := io.ReadFull(file, header)
+	for _, marker := range generatedMarkers {
+		if bytes.Contains(header[:n], marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitattributesPath anchors a path to the repository root, escaping the
+// characters .gitattributes treats specially
+func gitattributesPath(name string) string {
+	name = strings.NewReplacer(" ", `[[:space:]]`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(name)
+	return "/" + name
+}
@@ -0,0 +1,173 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// compileGlob translates a gitignore-style pattern into a regular expression
+// matching slash-separated paths relative to the root. A trailing slash
+// restricts the pattern to directories; a pattern containing any other slash
+// is anchored to the root, otherwise it matches names at any depth.
+func compileGlob(pattern string) (re *regexp.Regexp, dirOnly bool, err error) {
+	if trimmed := strings.TrimRight(pattern, "/"); trimmed != pattern {
+		pattern, dirOnly = trimmed, true
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil, false, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**") && (i == 0 || pattern[i-1] == '/') {
+				rest := pattern[i+2:]
+				switch {
+				case rest == "":
+					// Trailing "**" matches everything inside
+					b.WriteString(".*")
+					i++
+					continue
+				case rest[0] == '/':
+					// Leading or inner "**/" matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end, class, err := globClass(pattern, i)
+			if err != nil {
+				return nil, false, err
+			}
+			b.WriteString(class)
+			i = end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+
+	re, err = regexp.Compile(b.String())
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return re, dirOnly, nil
+}
+
+// globClass translates the character class starting at pattern[start],
+// returning the index of its closing bracket
+func globClass(pattern string, start int) (int, string, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		b.WriteString("^/")
+		i++
+	}
+	for first := true; i < len(pattern); i, first = i+1, false {
+		c := pattern[i]
+		switch {
+		case c == ']' && !first:
+			b.WriteString("]")
+			return i, b.String(), nil
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '-':
+			b.WriteString("-")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated character class in %q", pattern)
+}
+
+// globKind is how a compiled pattern is matched. The common literal forms
+// are compared directly, which is several times faster than the expression.
+type globKind int
+
+const (
+	globRegexp globKind = iota // Only the regular expression applies
+	globName                   // The base name equals the literal, e.g. "foo"
+	globSuffix                 // The base name ends with the literal, e.g. "*.log"
+	globPath                   // The path equals the literal, e.g. "src/gen"
+	globPrefix                 // The path is below the literal, e.g. "docs/**"
+)
+
+// literalGlob classifies a pattern accepted by compileGlob, returning the
+// literal its fast path compares
+func literalGlob(pattern string) (globKind, string) {
+	const special = "*?[\\"
+	pattern = strings.TrimRight(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	switch {
+	case !strings.ContainsAny(pattern, special) && anchored:
+		return globPath, pattern
+	case !strings.ContainsAny(pattern, special):
+		return globName, pattern
+	case !anchored && strings.HasPrefix(pattern, "*") && !strings.ContainsAny(pattern[1:], special):
+		return globSuffix, pattern[1:]
+	case anchored:
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok && dir != "" && !strings.ContainsAny(dir, special) {
+			return globPrefix, dir + "/"
+		}
+	}
+	return globRegexp, ""
+}
+
+// matches reports whether the slash path name matches the pattern, leaving
+// directory-only patterns to the caller
+func (p *Pattern) matches(name string) bool {
+	switch p.kind {
+	case globName:
+		return p.equal(name[strings.LastIndexByte(name, '/')+1:], p.literal)
+	case globSuffix:
+		base := name[strings.LastIndexByte(name, '/')+1:]
+		return len(base) >= len(p.literal) && p.equal(base[len(base)-len(p.literal):], p.literal)
+	case globPath:
+		return p.equal(name, p.literal)
+	case globPrefix:
+		return len(name) > len(p.literal) && p.equal(name[:len(p.literal)], p.literal)
+	}
+	return p.glob.MatchString(name)
+}
+
+// equal compares a part of a path with the pattern's literal
+func (p *Pattern) equal(part, literal string) bool {
+	if p.fold {
+		return strings.EqualFold(part, literal)
+	}
+	return part == literal
+}
+
+// foldLiteral makes the fast path of p ignore case. Non-ASCII literals use
+// the expression, since their folded forms may differ in length.
+func (p *Pattern) foldLiteral() {
+	p.fold = true
+	for i := 0; i < len(p.literal); i++ {
+		if p.literal[i] >= utf8.RuneSelf {
+			p.kind = globRegexp
+			return
+		}
+	}
+}
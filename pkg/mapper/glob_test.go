@@ -0,0 +1,148 @@
+package mapper
+
+import "testing"
+
+// globTests agree with git check-ignore for the same .gitignore lines,
+// except for the re: and (?i) extensions
+var globTests = []struct {
+	patterns []string
+	name     string
+	isDir    bool
+	ignored  bool
+}{
+	// Names at any depth
+	{[]string{"*.log"}, "a.log", false, true},
+	{[]string{"*.log"}, "dir/sub/a.log", false, true},
+	{[]string{"*.log"}, "a.log.txt", false, false},
+	{[]string{"foo*bar"}, "dir/fooXbar", false, true},
+	{[]string{"foo*bar"}, "foo/bar", false, false},
+	{[]string{"?.txt"}, "a.txt", false, true},
+	{[]string{"?.txt"}, "ab.txt", false, false},
+
+	// Anchoring
+	{[]string{"/build"}, "build", true, true},
+	{[]string{"/build"}, "src/build", true, false},
+	{[]string{"doc/frotz"}, "doc/frotz", false, true},
+	{[]string{"doc/frotz"}, "a/doc/frotz", false, false},
+	{[]string{"doc/*.md"}, "doc/a.md", false, true},
+	{[]string{"doc/*.md"}, "doc/sub/a.md", false, false},
+
+	// Trailing slash
+	{[]string{"build/"}, "build", true, true},
+	{[]string{"build/"}, "build", false, false},
+	{[]string{"build/"}, "src/build", true, true},
+	{[]string{"build/"}, "build/main.go", false, true},
+	{[]string{"doc/build/"}, "doc/build", true, true},
+	{[]string{"doc/build/"}, "x/doc/build", true, false},
+
+	// Double stars
+	{[]string{"**/foo"}, "foo", false, true},
+	{[]string{"**/foo"}, "a/b/foo", false, true},
+	{[]string{"**/foo/bar"}, "foo/bar", false, true},
+	{[]string{"**/foo/bar"}, "x/y/foo/bar", false, true},
+	{[]string{"abc/**"}, "abc/x", false, true},
+	{[]string{"abc/**"}, "abc/x/y", false, true},
+	{[]string{"abc/**"}, "abc", true, false},
+	{[]string{"a/**/b"}, "a/b", false, true},
+	{[]string{"a/**/b"}, "a/x/b", false, true},
+	{[]string{"a/**/b"}, "a/x/y/b", false, true},
+	{[]string{"a/**/b"}, "ab/b", false, false},
+
+	// Character classes
+	{[]string{"[abc].go"}, "a.go", false, true},
+	{[]string{"[abc].go"}, "d.go", false, false},
+	{[]string{"[!abc].go"}, "d.go", false, true},
+	{[]string{"[!abc].go"}, "a.go", false, false},
+	{[]string{"[a-c]x"}, "bx", false, true},
+	{[]string{"[a-c]x"}, "dx", false, false},
+	{[]string{"[]]x"}, "]x", false, true},
+
+	// Escapes
+	{[]string{`\*.md`}, "*.md", false, true},
+	{[]string{`\*.md`}, "a.md", false, false},
+	{[]string{`\!important`}, "!important", false, true},
+	{[]string{`file\?`}, "file?", false, true},
+	{[]string{`file\?`}, "files", false, false},
+
+	// Negation, the last matching pattern deciding
+	{[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+	{[]string{"*.log", "!keep.log"}, "a.log", false, true},
+	{[]string{"!keep.log", "*.log"}, "keep.log", false, true},
+	{[]string{"logs/*", "!logs/keep.log"}, "logs/keep.log", false, false},
+	{[]string{"logs/*", "!logs/keep.log"}, "logs/a.log", false, true},
+	// Nothing inside an ignored directory can be re-included
+	{[]string{"logs/", "!logs/keep.log"}, "logs/keep.log", false, true},
+	{[]string{"logs/", "!logs/"}, "logs/a.log", false, false},
+
+	// Extensions
+	{[]string{`re:.*\.tmp`}, "a/b.tmp", false, true},
+	{[]string{`re:.*\.tmp`}, "a/b.tmpl", false, false},
+	{[]string{`re:(?i).*\.jpe?g`}, "A.JPEG", false, true},
+	{[]string{"(?i)*.JPG"}, "photos/x.jpg", false, true},
+	{[]string{"(?i)Build/"}, "build", true, true},
+	{[]string{"*.JPG"}, "x.jpg", false, false},
+}
+
+func TestPatternMatch(t *testing.T) {
+	for _, test := range globTests {
+		pl := NewPatterns(".", Ignore)
+		for _, pattern := range test.patterns {
+			if err := pl.AddPattern(pattern); err != nil {
+				t.Fatalf("AddPattern(%q): %v", pattern, err)
+			}
+		}
+		p := pl.match(test.name, test.isDir)
+		if ignored := p != nil && !p.negate; ignored != test.ignored {
+			t.Errorf("%q matching %s (dir %v): ignored = %v, want %v", test.patterns, test.name, test.isDir, ignored, test.ignored)
+		}
+	}
+}
+
+// The literal fast paths agree with the compiled expression
+func TestLiteralGlob(t *testing.T) {
+	for _, test := range globTests {
+		for _, pattern := range test.patterns {
+			pl := NewPatterns(".", Ignore)
+			if err := pl.AddPattern(pattern); err != nil {
+				t.Fatalf("AddPattern(%q): %v", pattern, err)
+			}
+			p := &pl.patterns[0]
+			if p.kind == globRegexp {
+				continue
+			}
+			if got, want := p.matches(test.name), p.glob.MatchString(test.name); got != want {
+				t.Errorf("%q matching %s: fast path %v, expression %v", pattern, test.name, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileGlobErrors(t *testing.T) {
+	for _, pattern := range []string{"/", "[abc", "a[!"} {
+		if _, _, err := compileGlob(pattern); err == nil {
+			t.Errorf("compileGlob(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestLiteralGlobKinds(t *testing.T) {
+	tests := []struct {
+		pattern string
+		kind    globKind
+		literal string
+	}{
+		{"foo", globName, "foo"},
+		{"foo/", globName, "foo"},
+		{"*.log", globSuffix, ".log"},
+		{"src/gen", globPath, "src/gen"},
+		{"/vendor", globPath, "vendor"},
+		{"docs/**", globPrefix, "docs/"},
+		{"*.l?g", globRegexp, ""},
+		{"a/**/b", globRegexp, ""},
+	}
+	for _, test := range tests {
+		if kind, literal := literalGlob(test.pattern); kind != test.kind || literal != test.literal {
+			t.Errorf("literalGlob(%q) = %v, %q, want %v, %q", test.pattern, kind, literal, test.kind, test.literal)
+		}
+	}
+}
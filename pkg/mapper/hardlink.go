@@ -0,0 +1,33 @@
+package mapper
+
+import (
+	"io/fs"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// dedupeHardLinks keeps the content of files with several hard links in the
+// tree, as in pnpm stores or nix profiles, under the first link only. Later
+// links are listed with a reference to it.
+func dedupeHardLinks(tree *TreeNode, fsys fs.FS, msg i18n.Printer) {
+	carriers := make(map[[2]uint64]string) // File -> link carrying its content
+	walkFiles(tree, func(node *TreeNode, name string) {
+		if node.omitted || node.link != "" {
+			return
+		}
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return
+		}
+		id, ok := fileID(info)
+		if !ok {
+			return
+		}
+		if carrier, ok := carriers[id]; ok {
+			node.omitted = true
+			node.addNote(msg.Sprintf("content under %s", carrier))
+			return
+		}
+		carriers[id] = name
+	})
+}
@@ -0,0 +1,60 @@
+package mapper
+
+import (
+	"io/fs"
+	"time"
+)
+
+// maxHistory bounds the runs kept in the history of a cache
+const maxHistory = 1000
+
+// RunTotals are the totals of one run, kept in the history of a ContentCache
+type RunTotals struct {
+	Time   time.Time `json:"time"`
+	Files  int       `json:"files"`  // Files whose content is included
+	Bytes  int64     `json:"bytes"`  // Their size
+	Tokens int64     `json:"tokens"` // Their tokens, see Tree.TokenCounts
+}
+
+// Totals returns the totals of the files whose content is included in the
+// output, timed now
+func (t *Tree) Totals() RunTotals {
+	totals := RunTotals{Time: time.Now()}
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if node.omitted {
+			return
+		}
+		totals.Files++
+		if node.truncated() {
+			// Count what is written of a file cut short, not its size
+			if data, release, err := readContent(t.fsys, name); err == nil {
+				totals.Bytes += int64(len(truncateContent(data, node.keepLines, node.keepTail)))
+				release()
+			}
+		} else if info, err := fs.Stat(t.fsys, name); err == nil {
+			totals.Bytes += info.Size()
+		}
+	})
+	for _, count := range t.TokenCounts() {
+		totals.Tokens += count.Tokens
+	}
+	return totals
+}
+
+// Record appends the totals of a run to the history saved with the cache,
+// dropping the oldest runs beyond maxHistory
+func (c *ContentCache) Record(totals RunTotals) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append(c.history, totals)
+	if len(c.history) > maxHistory {
+		c.history = c.history[len(c.history)-maxHistory:]
+	}
+}
+
+// History returns the totals of the runs recorded, oldest first
+func (c *ContentCache) History() []RunTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]RunTotals(nil), c.history...)
+}
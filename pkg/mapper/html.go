@@ -0,0 +1,197 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// htmlNode is a tree node prepared for the HTML report
+type htmlNode struct {
+	Name     string
+	Path     string
+	IsDir    bool
+	Omitted  bool
+	Note     string
+	More     string // Ellipsis for the entries left out by an entry limit
+	Size     int64
+	Lines    int
+	Anchor   string // Id of the content section, "" when the content is left out
+	Lang     string
+	Content  string
+	Children []*htmlNode
+}
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	Root      *htmlNode
+	Files     []*htmlNode // Files with content, in tree order
+	FileCount int
+	TotalSize int64
+	Lang      string // Language of the labels
+}
+
+// renderHTML writes a self-contained HTML page with a collapsible tree and
+// syntax-highlighted file contents
+func (t *Tree) renderHTML(w io.Writer) error {
+	report := &htmlReport{Lang: t.msg.Lang()}
+	report.Root = t.toHTML(t.root, ".", report)
+	tmpl, err := htmlTemplate.Clone()
+	if err != nil {
+		return fmt.Errorf("error rendering HTML: %v", err)
+	}
+	tmpl.Funcs(template.FuncMap{"tr": t.msg.Sprintf})
+	if err := tmpl.Execute(w, report); err != nil {
+		return fmt.Errorf("error rendering HTML: %v", err)
+	}
+	return nil
+}
+
+// toHTML converts node, found at name within the scanned filesystem, and
+// collects the files whose content is shown
+func (t *Tree) toHTML(node *TreeNode, name string, report *htmlReport) *htmlNode {
+	out := &htmlNode{
+		Name:    node.name,
+		Path:    name,
+		IsDir:   node.isDir,
+		Omitted: node.omitted,
+		Note:    node.note,
+	}
+
+	if node.isDir {
+		if node.more > 0 {
+			out.More = moreLabel(node.more, t.msg)
+		}
+		for _, child := range node.children {
+			out.Children = append(out.Children, t.toHTML(child, path.Join(name, child.name), report))
+		}
+		return out
+	}
+
+	report.FileCount++
+	if info, err := fs.Stat(t.fsys, name); err == nil {
+		out.Size = info.Size()
+		report.TotalSize += out.Size
+	}
+	if node.omitted || t.opts.StructureOnly {
+		return out
+	}
+
+	content, ok := t.fileContent(node, name)
+	if !ok {
+		return out
+	}
+	out.Content = content
+	out.Lines = bytes.Count([]byte(content), []byte("\n"))
+	if content != "" && content[len(content)-1] != '\n' {
+		out.Lines++
+	}
+	out.Lang = languageTag(node.name)
+	out.Anchor = fmt.Sprintf("f%d", len(report.Files)+1)
+	report.Files = append(report.Files, out)
+	return out
+}
+
+// FormatSize renders a byte count for humans, e.g. 1.5 MB
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size": FormatSize,
+	"tr":   fmt.Sprintf, // Replaced by the tree's printer when rendering
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{tr "%s – project structure" .Root.Name}}</title>
+<style>
+body { font: 14px/1.5 system-ui, sans-serif; margin: 0; display: flex; color: #1f2328; }
+nav { width: 340px; flex: none; height: 100vh; overflow: auto; position: sticky; top: 0; border-right: 1px solid #d0d7de; padding: 12px; box-sizing: border-box; background: #f6f8fa; }
+main { flex: 1; min-width: 0; padding: 12px 24px; }
+nav ul { list-style: none; margin: 0; padding-left: 16px; }
+nav > ul { padding-left: 0; }
+summary { cursor: pointer; font-weight: 600; }
+a { color: #0969da; text-decoration: none; }
+.meta { color: #656d76; font-size: 12px; }
+.omitted { color: #8c959f; font-style: italic; }
+section { margin-bottom: 24px; }
+h2 { font-size: 15px; font-family: ui-monospace, monospace; border-bottom: 1px solid #d0d7de; padding-bottom: 4px; }
+pre { background: #f6f8fa; padding: 12px; overflow: auto; font: 12px/1.45 ui-monospace, monospace; }
+.k { color: #cf222e; } .s { color: #0a3069; } .c { color: #6e7781; font-style: italic; } .n { color: #0550ae; }
+</style>
+</head>
+<body>
+<nav>
+<p><strong>{{.Root.Name}}</strong><br><span class="meta">{{tr "%d files, %s" .FileCount (size .TotalSize)}}</span></p>
+<ul>{{template "node" .Root}}</ul>
+</nav>
+<main>
+{{- range .Files}}
+<section id="{{.Anchor}}">
+<h2>{{.Path}} <span class="meta">{{size .Size}} · {{tr "%d lines" .Lines}}</span></h2>
+<pre><code data-lang="{{.Lang}}">{{.Content}}</code></pre>
+</section>
+{{- end}}
+</main>
+<script>
+(function () {
+  var keywords = {
+    go: "break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false",
+    python: "and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False",
+    javascript: "async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new return super switch this throw try typeof var void while yield null undefined true false",
+    rust: "as async await break const continue crate else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while true false",
+    java: "abstract break case catch class const continue default do else enum extends final finally for if implements import instanceof interface new package private protected public return static super switch this throw throws try void while null true false",
+    c: "auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void volatile while",
+    ruby: "begin break case class def do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true unless until when while yield",
+    bash: "if then else elif fi for while do done case esac function in return local export",
+    sql: "select from where insert into values update set delete create table alter add drop column index primary key foreign references not null and or join on as order by group limit"
+  };
+  keywords.typescript = keywords.tsx = keywords.jsx = keywords.javascript + " interface type enum implements private public readonly";
+  keywords.cpp = keywords.csharp = keywords.c + " class namespace public private protected template new delete this using virtual";
+  keywords.kotlin = keywords.swift = keywords.php = keywords.java;
+  var hashComments = { python: 1, ruby: 1, bash: 1, yaml: 1, toml: 1, dockerfile: 1, makefile: 1, elixir: 1, hcl: 1 };
+  var escape = function (s) { return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;"); };
+
+  document.querySelectorAll("code[data-lang]").forEach(function (el) {
+    var lang = el.getAttribute("data-lang");
+    var text = el.textContent;
+    if (!lang || text.length > 500000) return;
+    var words = {};
+    (keywords[lang] || "").split(" ").forEach(function (w) { if (w) words[lang === "sql" ? w.toUpperCase() : w] = 1; });
+    var comment = hashComments[lang] ? "#.*" : lang === "sql" ? "--.*" : "//.*|/\\*[\\s\\S]*?\\*/";
+    var re = new RegExp("(" + comment + ")|(\"(?:\\\\.|[^\"\\\\\\n])*\"|'(?:\\\\.|[^'\\\\\\n])*'|` + "`[^`]*`" + `)|(\\b\\d[\\d.xXa-fA-F_]*\\b)|([A-Za-z_]\\w*)", "g");
+    var out = "", last = 0, m;
+    while ((m = re.exec(text)) !== null) {
+      out += escape(text.slice(last, m.index));
+      var token = escape(m[0]);
+      if (m[1]) out += '<span class="c">' + token + "</span>";
+      else if (m[2]) out += '<span class="s">' + token + "</span>";
+      else if (m[3]) out += '<span class="n">' + token + "</span>";
+      else if (words[lang === "sql" ? m[0].toUpperCase() : m[0]]) out += '<span class="k">' + token + "</span>";
+      else out += token;
+      last = re.lastIndex;
+    }
+    el.innerHTML = out + escape(text.slice(last));
+  });
+})();
+</script>
+</body>
+</html>
+{{define "node" -}}
+{{if .IsDir -}}
+<li><details open><summary>{{.Name}}</summary><ul>{{range .Children}}{{template "node" .}}{{end}}{{if .More}}<li class="meta">{{.More}}</li>{{end}}</ul></details></li>
+{{- else -}}
+<li>{{if .Anchor}}<a href="#{{.Anchor}}">{{.Name}}</a>{{else}}<span class="omitted">{{.Name}}</span>{{end}} <span class="meta">{{size .Size}}{{if .Anchor}} · {{tr "%d lines" .Lines}}{{end}}{{if .Note}} · {{.Note}}{{end}}</span></li>
+{{- end}}
+{{- end}}
+`))
@@ -0,0 +1,208 @@
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+)
+
+// indexVersion changes whenever the index file layout or word splitting does
+const indexVersion = 1
+
+// Index is an inverted index of the words in a tree's files, saved to disk
+// so searches and query ranking need not read every file again
+type Index struct {
+	files    []indexedFile
+	byPath   map[string]int
+	postings map[string][][2]int // Word -> pairs of file number and count
+	words    []map[string]int    // Word counts by file, built on demand
+	read     int                 // Files read while building
+	model    string              // Embedder that produced vectors
+	vectors  [][]float32         // Embedding by file, nil where missing
+}
+
+// indexedFile identifies the version of a file whose words are indexed
+type indexedFile struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+}
+
+// indexFile is the JSON form of an index
+type indexFile struct {
+	Version  int                 `json:"version"`
+	Files    []indexedFile       `json:"files"`
+	Postings map[string][][2]int `json:"postings"`
+	Model    string              `json:"model,omitempty"`
+	Vectors  [][]float32         `json:"vectors,omitempty"`
+}
+
+// SearchResult is a file matching a search, with its relevance score
+type SearchResult struct {
+	Path  string
+	Score float64
+}
+
+// LoadIndex reads the index saved at path. A missing file, or one written by
+// an incompatible version, returns a nil index and no error.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading index %s: %v", path, err)
+	}
+
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %v", path, err)
+	}
+	if file.Version != indexVersion {
+		return nil, nil
+	}
+	ix := &Index{files: file.Files, postings: file.Postings, byPath: make(map[string]int, len(file.Files)), model: file.Model, vectors: file.Vectors}
+	if len(ix.vectors) != len(ix.files) {
+		ix.model, ix.vectors = "", nil
+	}
+	for i, f := range ix.files {
+		ix.byPath[f.Path] = i
+	}
+	return ix, nil
+}
+
+// Save writes the index to path, replacing any earlier index atomically
+func (ix *Index) Save(path string) error {
+	data, err := json.Marshal(indexFile{Version: indexVersion, Files: ix.files, Postings: ix.postings, Model: ix.model, Vectors: ix.vectors})
+	if err != nil {
+		return fmt.Errorf("error encoding index: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	return nil
+}
+
+// BuildIndex indexes the words of every file of the tree whose content is
+// mapped. Files unchanged in size and modification time since previous,
+// which may be nil, are taken from it instead of being read again, along
+// with their embeddings.
+func BuildIndex(t *Tree, previous *Index) *Index {
+	ix := &Index{byPath: make(map[string]int), postings: make(map[string][][2]int)}
+	if previous != nil {
+		ix.model = previous.model
+	}
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if node.omitted {
+			return
+		}
+		info, err := fs.Stat(t.fsys, name)
+		if err != nil {
+			return
+		}
+		f := indexedFile{Path: name, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		counts, ok := previous.lookup(f)
+		if !ok {
+			counts = fileWords(t.fsys, name)
+			ix.read++
+		}
+
+		ix.byPath[name] = len(ix.files)
+		for word, n := range counts {
+			ix.postings[word] = append(ix.postings[word], [2]int{len(ix.files), n})
+		}
+		ix.files = append(ix.files, f)
+		ix.vectors = append(ix.vectors, previous.vector(f, ix.model))
+	})
+	return ix
+}
+
+// Len returns how many files are indexed
+func (ix *Index) Len() int {
+	return len(ix.files)
+}
+
+// Read returns how many files were read while building the index, the rest
+// being unchanged since the previous index
+func (ix *Index) Read() int {
+	return ix.read
+}
+
+// lookup returns the word counts of a file if the index holds the same version of it
+func (ix *Index) lookup(f indexedFile) (map[string]int, bool) {
+	if ix == nil {
+		return nil, false
+	}
+	i, ok := ix.byPath[f.Path]
+	if !ok || ix.files[i] != f {
+		return nil, false
+	}
+	if ix.words == nil {
+		ix.words = make([]map[string]int, len(ix.files))
+		for i := range ix.words {
+			ix.words[i] = make(map[string]int)
+		}
+		for word, list := range ix.postings {
+			for _, p := range list {
+				ix.words[p[0]][word] = p[1]
+			}
+		}
+	}
+	return ix.words[i], true
+}
+
+// wordsOf returns the word counts of a file of fsys, from the index when it
+// is up to date
+func (ix *Index) wordsOf(fsys fs.FS, name string) map[string]int {
+	if ix != nil {
+		if info, err := fs.Stat(fsys, name); err == nil {
+			if counts, ok := ix.lookup(indexedFile{Path: name, Size: info.Size(), ModTime: info.ModTime().UnixNano()}); ok {
+				return counts
+			}
+		}
+	}
+	return fileWords(fsys, name)
+}
+
+// Search ranks the indexed files by relevance to query, the same way as
+// Options.Query, and returns at most top results, best first
+func (ix *Index) Search(query string, top int) []SearchResult {
+	scores := make(map[int]float64)
+	for _, term := range queryTerms(query) {
+		list := ix.postings[term]
+		for _, p := range list {
+			scores[p[0]] += termScore(p[1], len(ix.files), len(list))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for i, score := range scores {
+		results = append(results, SearchResult{Path: ix.files[i].Path, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if top > 0 && len(results) > top {
+		results = results[:top]
+	}
+	return results
+}
+
+// termScore is the relevance a term adds to a file holding it count times,
+// when files of the total hold it. Frequency is dampened so long files do
+// not win by size alone, and rare terms weigh more.
+func termScore(count, files, holding int) float64 {
+	return (1 + math.Log(float64(count))) * math.Log(1+float64(files)/float64(holding))
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package mapper
+
+import "io/fs"
+
+// inode is not supported on this platform, so directories are identified
+// by their resolved path instead
+func inode(info fs.FileInfo) ([2]uint64, bool) {
+	return [2]uint64{}, false
+}
+
+// fileID is not supported on this platform, so hard links are mapped like
+// separate files
+func fileID(info fs.FileInfo) ([2]uint64, bool) {
+	return [2]uint64{}, false
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package mapper
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// inode identifies the file behind info by device and inode
+func inode(info fs.FileInfo) ([2]uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return [2]uint64{}, false
+	}
+	return [2]uint64{uint64(st.Dev), uint64(st.Ino)}, true
+}
+
+// fileID identifies the file behind info by device and inode, reporting
+// false unless other hard links to it exist
+func fileID(info fs.FileInfo) ([2]uint64, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); !ok || st.Nlink < 2 {
+		return [2]uint64{}, false
+	}
+	return inode(info)
+}
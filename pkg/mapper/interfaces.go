@@ -0,0 +1,97 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// interfaceSniffSize is how much of a JSON/YAML file is inspected for an OpenAPI marker
+const interfaceSniffSize = 4096
+
+// interfaceKind reports whether a file is a protobuf or OpenAPI/Swagger
+// definition, returning "" otherwise
+func interfaceKind(fsys fs.FS, name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ext == ".proto" {
+		return "proto"
+	}
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return ""
+	}
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, interfaceSniffSize)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+
+	for _, marker := range []string{"openapi", "swagger"} {
+		if ext == ".json" {
+			if bytes.Contains(head, []byte(`"`+marker+`"`)) {
+				return "openapi"
+			}
+			continue
+		}
+		for _, line := range strings.Split(string(head), "\n") {
+			if strings.HasPrefix(line, marker+":") {
+				return "openapi"
+			}
+		}
+	}
+	return ""
+}
+
+// hoistInterfaces writes the Interfaces section with the full content of every
+// proto and OpenAPI file, marking them so they are not repeated later
+func hoistInterfaces(tree *TreeNode, fsys fs.FS, output io.Writer) {
+	type iface struct {
+		node *TreeNode
+		name string
+		kind string
+	}
+
+	found := make([]iface, 0)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		if node.omitted {
+			return
+		}
+		if kind := interfaceKind(fsys, name); kind != "" {
+			found = append(found, iface{node, name, kind})
+		}
+	})
+	if len(found) == 0 {
+		return
+	}
+
+	fmt.Fprintln(output, "<Interfaces>")
+	for _, f := range found {
+		file, err := fsys.Open(f.name)
+		if err != nil {
+			i18n.Warnf("Could not read file %s: %v", f.name, err)
+			continue
+		}
+		fmt.Fprintf(output, "<%s kind=\"%s\">\n", f.name, f.kind)
+		readErr, err := copyContent(output, file)
+		file.Close()
+		if err != nil {
+			// The caller reports the failed write or the cancelled run
+			return
+		}
+		if readErr != nil {
+			i18n.Warnf("Could not copy file %s: %v", f.name, readErr)
+		}
+		fmt.Fprintf(output, "\n\n</%s>\n", f.name)
+		f.node.hoisted = true
+	}
+	fmt.Fprintln(output, "</Interfaces>")
+}
@@ -0,0 +1,123 @@
+package mapper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// BinaryAsset describes a file whose content was excluded as binary
+type BinaryAsset struct {
+	path     string
+	fileType string
+	size     int64
+	hash     string
+}
+
+// ScanReport collects information gathered while walking the tree
+type ScanReport struct {
+	ctx         context.Context
+	fsys        fs.FS  // Filesystem being scanned
+	origin      string // Where fsys comes from, e.g. the root directory
+	dir         string // Directory of fsys on disk, "" when it is not the OS filesystem
+	assets      []BinaryAsset
+	patternHits map[*Pattern]int   // Entries matched per user pattern
+	ruleHits    map[string]int     // Entries skipped per built-in rule
+	skips       map[SkipReason]int // Entries skipped per reason, including limits
+	unreadable  int                // Files skipped for lack of read permission
+	files       int                // Files mapped so far, for MaxFiles
+	omissions   []Omission         // Entries left out because of limits
+	gitignores  *dirPatternSet     // The .gitignore files honored, nil unless enabled
+	nested      *dirPatternSet     // The pattern files of subdirectories, nil unless enabled
+	builtinDirs []string           // Directories skipped by the built-in rules
+	cache       *SharedCache       // Optional cache shared between runs
+	nodes       nodeArena          // Allocator for the nodes of the scanned tree
+	events      func(Event) error  // Callback of WalkEvents, nil for a scan
+	stopped     error              // The error returned by events, which stops the walk
+	walking     map[string]bool    // Directories being walked when following symlinks, see dirKey
+	pool        *walkPool          // Reads and checks entries concurrently when Options.Jobs > 1
+	content     *ContentCache      // Options.ContentCache
+	msg         i18n.Printer       // Translates the notes added during the walk
+}
+
+// addBinaryAsset records an extension-skipped file in the binary inventory
+func (r *ScanReport) addBinaryAsset(entry fs.DirEntry, name string) {
+	info, err := entry.Info()
+	if err != nil {
+		i18n.Warnf("Cannot stat binary file %s: %v", name, err)
+		return
+	}
+
+	asset := BinaryAsset{
+		path:     name,
+		fileType: binaryFileType(entry.Name()),
+		size:     info.Size(),
+		hash:     "-",
+	}
+
+	// Hashing is skipped for files too large to be worth reading
+	if info.Size() <= defaultMaxFileSize {
+		hash, err := r.content.hash(name, info, func() (string, error) {
+			return r.cache.fileHash(r.fsys, r.origin, name, info)
+		})
+		if err != nil {
+			i18n.Warnf("Cannot hash binary file %s: %v", name, err)
+		} else {
+			asset.hash = hash
+		}
+	}
+
+	r.assets = append(r.assets, asset)
+}
+
+// binaryFileType returns the MIME type for a file name, falling back to its extension
+func binaryFileType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		// Drop parameters such as "; charset=utf-8"
+		if i := strings.Index(mimeType, ";"); i >= 0 {
+			mimeType = mimeType[:i]
+		}
+		return mimeType
+	}
+	if ext == "" {
+		return "application/octet-stream"
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// hashFile returns the hex-encoded SHA-256 of a file
+func hashFile(fsys fs.FS, name string) (string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeBinaryInventory writes the section listing excluded binary files
+func writeBinaryInventory(report *ScanReport, output io.Writer) {
+	if len(report.assets) == 0 {
+		return
+	}
+
+	fmt.Fprintln(output, "<Binary_Inventory>")
+	for _, a := range report.assets {
+		fmt.Fprintf(output, "%s | %s | %d bytes | sha256:%s\n", a.path, a.fileType, a.size, a.hash)
+	}
+	fmt.Fprintln(output, "</Binary_Inventory>")
+}
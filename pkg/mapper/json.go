@@ -0,0 +1,73 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// jsonNode is the JSON form of a tree node
+type jsonNode struct {
+	Name        string       `json:"name"`
+	Path        string       `json:"path"`
+	IsDir       bool         `json:"isDir"`
+	Size        *int64       `json:"size,omitempty"` // Files only
+	Omitted     bool         `json:"omitted,omitempty"`
+	Excluded    bool         `json:"excluded,omitempty"`
+	Note        string       `json:"note,omitempty"`
+	Link        string       `json:"link,omitempty"` // Target of a symlink that is not followed
+	More        int          `json:"more,omitempty"` // Entries left out by an entry limit, directories only
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Findings    []Annotation `json:"findings,omitempty"`
+	Content     *string      `json:"content,omitempty"`
+	Children    []*jsonNode  `json:"children,omitempty"`
+}
+
+// renderJSON writes the tree as a single JSON document. File contents are
+// included unless only the structure was requested.
+func (t *Tree) renderJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(t.toJSON(t.root, ".")); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
+
+// toJSON converts node, found at name within the scanned filesystem
+func (t *Tree) toJSON(node *TreeNode, name string) *jsonNode {
+	out := &jsonNode{
+		Name:        node.name,
+		Path:        name,
+		IsDir:       node.isDir,
+		Omitted:     node.omitted,
+		Excluded:    node.excluded,
+		Note:        node.note,
+		Link:        node.link,
+		More:        node.more,
+		Annotations: t.annotations[name],
+		Findings:    t.findings[name],
+	}
+
+	if node.isDir {
+		out.Children = make([]*jsonNode, 0, len(node.children))
+		for _, child := range node.children {
+			out.Children = append(out.Children, t.toJSON(child, path.Join(name, child.name)))
+		}
+		return out
+	}
+
+	if info, err := fs.Stat(t.fsys, name); err == nil {
+		size := info.Size()
+		out.Size = &size
+	}
+	if !node.omitted && !t.opts.StructureOnly {
+		if content, ok := t.fileContent(node, name); ok {
+			out.Content = &content
+		}
+	}
+	return out
+}
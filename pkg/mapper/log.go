@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"log/slog"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// SetLogger sends the warnings of scans and renders, such as files that
+// could not be read, to logger instead of writing them to stderr. Each
+// record's "id" attribute holds the English message format, which does not
+// change with the language. A nil logger restores the default.
+func SetLogger(logger *slog.Logger) {
+	i18n.Logger = logger
+}
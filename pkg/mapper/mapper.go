@@ -0,0 +1,1093 @@
+// Package mapper scans a directory and renders its structure and file
+// contents as a single text snapshot.
+//
+//	tree, err := mapper.Scan(root, mapper.NewOptions(mapper.WithOutline(200)))
+//	if err != nil {
+//		return err
+//	}
+//	return tree.Render(os.Stdout, mapper.FormatText)
+//
+// The exported API follows semantic versioning from v1.0.0: within a major
+// version, exported identifiers are neither removed nor changed, and new
+// Options fields keep the previous behavior at their zero value.
+package mapper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Pattern is a single line of an ignore or filter file
+type Pattern struct {
+	glob       *regexp.Regexp // Compiled glob or "re:" expression, matched against slash paths
+	dirOnly    bool           // Set by a trailing slash, matches only directories
+	negate     bool           // Set by a leading "!", re-includes what earlier patterns matched
+	visibility Visibility     // Set by @show/@hide, overrides the tree policy
+	text       string         // The pattern as written
+	source     string         // Where the pattern came from, e.g. "file:line"
+	kind       globKind       // How the pattern is matched, see literalGlob
+	literal    string         // The literal compared unless kind is globRegexp
+	fold       bool           // The literal is compared ignoring case
+}
+
+// PatternList represents an ordered list of patterns
+type PatternList struct {
+	patterns  []Pattern
+	basePath  string
+	matchType PatternType
+}
+
+// TreeNode represents a file or directory in the tree structure
+type TreeNode struct {
+	name      string
+	isDir     bool
+	omitted   bool   // Shown in the tree but its content is left out
+	excluded  bool   // Skipped by a pattern or built-in list, shown for ShowExcluded
+	hoisted   bool   // Content already emitted in an earlier section
+	note      string // Annotation rendered next to the name in the tree
+	link      string // Target of a symlink shown rather than followed
+	more      int    // Entries left out by an entry limit, shown as an ellipsis
+	keepLines int    // Lines of content kept, the rest replaced by a marker; 0 keeps all
+	keepTail  int    // Lines kept from the end as well, after the marker
+	children  []*TreeNode
+}
+
+// addNote appends an annotation shown next to the node in the tree
+func (n *TreeNode) addNote(note string) {
+	if n.note != "" {
+		n.note += "; "
+	}
+	n.note += note
+}
+
+// Options controls how a directory is scanned and rendered. The zero value
+// maps everything not excluded by the built-in rules.
+type Options struct {
+	Patterns              *PatternList      // Ignore or filter patterns, nil for none
+	Include               *PatternList      // Filter applied on top of Patterns, e.g. from --include; nil for none
+	TreePolicy            TreePolicy        // How entries of each skip reason are rendered
+	OutlineOver           int               // Outline files longer than this many lines, 0 disables
+	Dependencies          bool              // Summarize dependency manifests in their own section
+	Deployment            bool              // Summarize infrastructure files in their own section
+	InfraSummaryOnly      bool              // Emit only the summary of infrastructure files
+	Interfaces            bool              // Hoist proto and OpenAPI files into their own section
+	ConsolidateMigrations bool              // Replace migration directories with a consolidated schema
+	PairTests             bool              // Annotate source files with their tests and vice versa
+	HoistReadmes          bool              // Describe directories by their README and put it first among their files
+	FileIndex             bool              // End with an alphabetical index of the files and where their content starts
+	Delimited             bool              // Frame text output sections with sentinel lines giving their length, for parsers
+	ShowExcluded          bool              // List entries skipped by patterns, built-in lists or as hidden in the tree, marked as excluded
+	Footer                bool              // End text output with the file count, length and SHA-256 of the body, see VerifySnapshot
+	SummaryHeader         bool              // Start text output with the counts of included and skipped entries, see WriteSummary
+	Workers               int               // Files transformed concurrently while rendering, 0 for one
+	Jobs                  int               // Directories read and entries checked concurrently while scanning, 0 for one
+	StructureOnly         bool              // Render only the directory structure, without sections or contents
+	Checkpoint            *Checkpoint       // Optional progress file for resuming interrupted runs
+	ExcludePath           string            // Slash-separated path below the root never mapped, typically the output file, along with its parts
+	Cache                 *SharedCache      // Optional cache shared between scans
+	ContentCache          *ContentCache     // Optional cache of file checks and outlines kept between runs
+	OnUnreadable          func(name string) // Called with the path below the root of every file skipped as unreadable
+	Language              string            // Language of notes, summaries and labels in the output, e.g. "es" or "ja"; empty for English
+	DiagramDepth          int               // Levels below the root drawn by the diagram formats, 0 for all
+	RespectGitignore      bool              // Also skip entries excluded by .gitignore files at any level
+	MaxFileSize           int64             // Files larger than this many bytes are listed without content, 0 for 50 MB
+	HeadLines             int               // Text files over MaxFileSize keep this many first lines instead, with TailLines
+	TailLines             int               // Text files over MaxFileSize keep this many last lines instead, with HeadLines
+	MaxFiles              int               // Entries mapped after this many files are left out, 0 for no limit
+	MaxEntriesPerDir      int               // Entries of a directory after this many are left out, 0 for no limit
+	IgnoreCase            bool              // Match patterns and .gitignore files case-insensitively
+	Normalize             NormalForm        // Unicode normal form of names, for patterns and output; NormNone keeps them as stored
+	NestedPatterns        bool              // Also apply .project_structure_ignore files of subdirectories, relative to them
+	Query                 string            // Keep the content of only the files most relevant to this query
+	QueryTop              int               // Files kept by Query, 0 for 10
+	Index                 *Index            // Optional word index used by Query instead of reading every file
+	SemanticQuery         string            // Keep the content of only the files whose embeddings are closest to this query's
+	Embedder              Embedder          // Embeds files and SemanticQuery; vectors saved in Index are reused
+	ShowSymlinks          bool              // List symlinks as "name -> target" instead of following them
+	SymlinkContent        bool              // With ShowSymlinks, include the content of each file target once
+	SkipLists             *SkipLists        // Built-in directory, extension and file skip lists, nil for DefaultSkipLists
+	Hidden                HiddenPolicy      // Whether entries whose name starts with a dot are mapped
+	Annotations           []Annotation      // Findings of external tools merged into the entries of their paths
+	Findings              []Annotation      // Findings counted per file, e.g. from LoadSARIF
+	FindingsAppendix      bool              // List every finding in a Findings section
+	MaxTokens             int64             // Drop and cut files so the tree and contents fit in this many tokens, 0 for no limit
+	ChurnDays             int               // Annotate files with their commits in this many days and rank busy files higher, 0 disables; needs Scan and git
+	Tokenizer             Tokenizer         // Counts the tokens of files in summaries and metrics; nil estimates them from their size
+
+	// OnSkip is called with every entry left out and the rule that decided
+	// it, as worded by Explain
+	OnSkip func(name string, isDir bool, rule string)
+
+	// OnProgress is called as the walk enters each directory, with its path
+	// and the number of files mapped so far
+	OnProgress func(dir string, files int)
+}
+
+// maxFileSize returns the size above which file contents are left out
+func (o *Options) maxFileSize() int64 {
+	if o.MaxFileSize > 0 {
+		return o.MaxFileSize
+	}
+	return defaultMaxFileSize
+}
+
+// PatternType indicates whether patterns are for ignoring or filtering
+type PatternType int
+
+const (
+	Ignore PatternType = iota
+	Filter
+)
+
+// determinePatternType checks which pattern file exists and should be used.
+// When neither exists and createMissing is false, an empty name is returned.
+func determinePatternType(ignoreFile, filterFile string, createMissing bool) (string, PatternType, error) {
+	ignoreExists := false
+	filterExists := false
+
+	if _, err := os.Stat(ignoreFile); err == nil {
+		ignoreExists = true
+	}
+	if _, err := os.Stat(filterFile); err == nil {
+		filterExists = true
+	}
+
+	// If both exist, use ignore file
+	if ignoreExists {
+		return ignoreFile, Ignore, nil
+	}
+	// If only filter exists, use filter file
+	if filterExists {
+		return filterFile, Filter, nil
+	}
+	if !createMissing {
+		return "", Ignore, nil
+	}
+	// If neither exists, create and use ignore file
+	if err := os.WriteFile(ignoreFile, []byte{}, 0644); err != nil {
+		return "", Ignore, fmt.Errorf("error creating ignore file: %v", err)
+	}
+	return ignoreFile, Ignore, nil
+}
+
+// NewPatternList creates a new pattern list from a file
+func NewPatternList(filename string, basePath string, matchType PatternType) (*PatternList, error) {
+	pl := &PatternList{
+		patterns:  make([]Pattern, 0),
+		basePath:  basePath,
+		matchType: matchType,
+	}
+
+	if err := pl.loadFile(filename, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+// NewPatterns creates an empty pattern list for patterns given directly,
+// such as on the command line
+func NewPatterns(basePath string, matchType PatternType) *PatternList {
+	return &PatternList{basePath: basePath, matchType: matchType}
+}
+
+// LoadPatterns reads the ignore or filter file of root, preferring the
+// ignore file when both exist. Without either an empty ignore list is
+// returned, after creating the ignore file when createMissing is set.
+func LoadPatterns(root string, createMissing bool) (*PatternList, error) {
+	ignoreFile := filepath.Join(root, ".project_structure_ignore")
+	filterFile := filepath.Join(root, ".project_structure_filter")
+
+	patternFile, patternType, err := determinePatternType(ignoreFile, filterFile, createMissing)
+	if err != nil {
+		return nil, fmt.Errorf("error determining pattern type: %v", err)
+	}
+
+	if patternFile == "" {
+		return &PatternList{basePath: root, matchType: patternType}, nil
+	}
+	patterns, err := NewPatternList(patternFile, root, patternType)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing patterns: %v", err)
+	}
+	return patterns, nil
+}
+
+// LoadPatternFiles is like LoadPatterns, but when both files exist the
+// filter file is returned as well, for use as Options.Include. The filter
+// then decides which entries are candidates and the ignore file removes
+// entries from them, an ignore match taking precedence. filter is nil
+// unless both files exist.
+func LoadPatternFiles(root string, createMissing bool) (patterns, filter *PatternList, err error) {
+	patterns, err = LoadPatterns(root, createMissing)
+	if err != nil || patterns.matchType == Filter {
+		return patterns, nil, err
+	}
+
+	filterFile := filepath.Join(root, ".project_structure_filter")
+	if _, err := os.Stat(filterFile); err != nil {
+		return patterns, nil, nil
+	}
+	filter, err = NewPatternList(filterFile, root, Filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error initializing patterns: %v", err)
+	}
+	return patterns, filter, nil
+}
+
+// Type returns whether the list holds ignore or filter patterns
+func (pl *PatternList) Type() PatternType {
+	return pl.matchType
+}
+
+// loadFile adds the patterns of a file, following @include directives.
+// Included paths are resolved relative to the including file.
+func (pl *PatternList) loadFile(filename string, visiting map[string]bool) error {
+	absName, err := filepath.Abs(filename)
+	if err != nil {
+		return fmt.Errorf("error resolving file %s: %v", filename, err)
+	}
+	if visiting[absName] {
+		return fmt.Errorf("include cycle detected at %s", filename)
+	}
+	visiting[absName] = true
+	defer delete(visiting, absName)
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	return pl.parse(file, filepath.Base(filename), func(included string) error {
+		if !filepath.IsAbs(included) {
+			included = filepath.Join(filepath.Dir(absName), included)
+		}
+		return pl.loadFile(included, visiting)
+	})
+}
+
+// loadFS is like loadFile for a file of fsys, such as the pattern file of a
+// subdirectory. Included paths must be relative.
+func (pl *PatternList) loadFS(fsys fs.FS, name string, visiting map[string]bool) error {
+	if visiting[name] {
+		return fmt.Errorf("include cycle detected at %s", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return pl.parse(file, name, func(included string) error {
+		return pl.loadFS(fsys, path.Join(path.Dir(name), included), visiting)
+	})
+}
+
+// parse adds the patterns read from r, calling include for @include
+// directives. Patterns record their line in the file named label.
+func (pl *PatternList) parse(r io.Reader, label string, include func(name string) error) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if pattern = ExpandEnv(pattern); pattern == "" {
+			continue
+		}
+
+		if included, ok := strings.CutPrefix(pattern, "@include "); ok {
+			if err := include(strings.TrimSpace(included)); err != nil {
+				return fmt.Errorf("%s:%d: %v", label, lineNo, err)
+			}
+			continue
+		}
+
+		if err := pl.AddPattern(pattern); err != nil {
+			return fmt.Errorf("error adding pattern %s: %v", pattern, err)
+		}
+		pl.patterns[len(pl.patterns)-1].source = fmt.Sprintf("%s:%d", label, lineNo)
+	}
+
+	return scanner.Err()
+}
+
+// AddPattern adds a new pattern to the list
+func (pl *PatternList) AddPattern(pattern string) error {
+	p := Pattern{text: pattern}
+
+	// Handle visibility directives (@show pattern, @hide pattern)
+	if directive, rest, ok := strings.Cut(pattern, " "); ok {
+		switch directive {
+		case "@show":
+			p.visibility = Listed
+			pattern = strings.TrimSpace(rest)
+		case "@hide":
+			p.visibility = Hidden
+			pattern = strings.TrimSpace(rest)
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		p.negate = true
+		pattern = rest
+	}
+
+	// Regular expressions must match the whole relative path
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regular expression: %v", err)
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid regular expression: %v", err)
+		}
+		p.glob = re
+		pl.patterns = append(pl.patterns, p)
+		return nil
+	}
+
+	// A "(?i)" prefix makes a single glob case-insensitive, as in expressions
+	if rest, ok := strings.CutPrefix(pattern, "(?i)"); ok {
+		if err := pl.addGlob(p, rest); err != nil {
+			return err
+		}
+		last := &pl.patterns[len(pl.patterns)-1]
+		last.glob = regexp.MustCompile("(?i)" + last.glob.String())
+		last.foldLiteral()
+		return nil
+	}
+	return pl.addGlob(p, pattern)
+}
+
+// Extend returns a copy of the list with more patterns added after its own,
+// so they take precedence. The source names where they came from in rule
+// statistics and explanations. The list itself is left unchanged since it
+// may be shared, e.g. through a SharedCache.
+func (pl *PatternList) Extend(source string, patterns ...string) (*PatternList, error) {
+	extended := *pl
+	extended.patterns = append(pl.patterns[:len(pl.patterns):len(pl.patterns)], make([]Pattern, 0, len(patterns))...)
+	for _, pattern := range patterns {
+		if err := extended.AddPattern(pattern); err != nil {
+			return nil, fmt.Errorf("error adding pattern %s: %v", pattern, err)
+		}
+		extended.patterns[len(extended.patterns)-1].source = source
+	}
+	return &extended, nil
+}
+
+// Concat returns a list holding the patterns of pl followed by those of
+// other, which therefore take precedence. The type and base path are pl's.
+func (pl *PatternList) Concat(other *PatternList) *PatternList {
+	joined := *pl
+	joined.patterns = append(pl.patterns[:len(pl.patterns):len(pl.patterns)], other.patterns...)
+	return &joined
+}
+
+// foldCase returns a copy of the list whose patterns ignore case
+func (pl *PatternList) foldCase() *PatternList {
+	if pl == nil {
+		return nil
+	}
+	folded := *pl
+	folded.patterns = make([]Pattern, len(pl.patterns))
+	for i, p := range pl.patterns {
+		p.glob = regexp.MustCompile("(?i)" + p.glob.String())
+		p.foldLiteral()
+		folded.patterns[i] = p
+	}
+	return &folded
+}
+
+// addGlob compiles a gitignore-style pattern into p and adds it to the list
+func (pl *PatternList) addGlob(p Pattern, pattern string) error {
+	glob, dirOnly, err := compileGlob(pattern)
+	if err != nil {
+		return err
+	}
+	p.glob, p.dirOnly = glob, dirOnly
+	p.kind, p.literal = literalGlob(pattern)
+
+	pl.patterns = append(pl.patterns, p)
+	return nil
+}
+
+// Matches checks if a path matches any pattern in the list
+func (pl *PatternList) Matches(path string) bool {
+	if len(pl.patterns) == 0 {
+		return pl.matchType == Filter // If no patterns and Filter mode, nothing matches
+	}
+	p := pl.Match(path)
+	return p != nil && !p.negate
+}
+
+// Match returns the pattern deciding path, or nil if none matches it. The
+// result may be a negated pattern re-including path. A trailing slash marks
+// path as a directory for directory-only patterns.
+func (pl *PatternList) Match(path string) *Pattern {
+	isDir := strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator))
+
+	// Convert path to relative and clean
+	relPath := path
+	if filepath.IsAbs(path) {
+		var err error
+		relPath, err = filepath.Rel(pl.basePath, path)
+		if err != nil {
+			return nil
+		}
+	}
+	return pl.match(filepath.ToSlash(filepath.Clean(relPath)), isDir)
+}
+
+// match returns the pattern deciding the slash path name: the last one
+// matching it, or else the one deciding its parent directory
+func (pl *PatternList) match(name string, isDir bool) *Pattern {
+	var parent *Pattern
+	if dir := path.Dir(name); dir != "." && dir != "/" {
+		parent = pl.match(dir, true)
+		// As in git, nothing inside an ignored directory can be re-included
+		if parent != nil && !parent.negate && pl.matchType == Ignore {
+			return parent
+		}
+	}
+	for i := len(pl.patterns) - 1; i >= 0; i-- {
+		p := &pl.patterns[i]
+		if (isDir || !p.dirOnly) && p.matches(name) {
+			return p
+		}
+	}
+	return parent
+}
+
+// Common file patterns and directories to skip, see SkipLists
+var (
+	skipDirs = map[string]bool{
+		".git":         true,
+		"node_modules": true,
+		"bin":          true,
+		"obj":          true,
+		"build":        true,
+		"dist":         true,
+		"target":       true,
+		".idea":        true,
+		".vscode":      true,
+		"__pycache__":  true,
+		".next":        true,
+		"vendor":       true,
+	}
+
+	skipExtensions = map[string]bool{
+		".exe":    true,
+		".dll":    true,
+		".so":     true,
+		".dylib":  true,
+		".bin":    true,
+		".obj":    true,
+		".class":  true,
+		".pyc":    true,
+		".pdb":    true,
+		".cache":  true,
+		".jpg":    true,
+		".jpeg":   true,
+		".png":    true,
+		".gif":    true,
+		".ico":    true,
+		".pdf":    true,
+		".zip":    true,
+		".tar":    true,
+		".gz":     true,
+		".rar":    true,
+		".7z":     true,
+		".db":     true,
+		".sqlite": true,
+		".mdb":    true,
+		".iso":    true,
+		".img":    true,
+		".lock":   true,
+	}
+
+	// toolFiles are the tool's own files, skipped even without the built-in
+	// lists: the default output of every format and the files it reads
+	toolFiles = ownFiles(
+		".project_structure_ignore",
+		".project_structure_filter",
+		".project_structure_index",
+		".project_structure_cache",
+		".directory-mapper.yaml",
+	)
+
+	skipFiles = map[string]bool{
+		".gitignore": true,
+		".env":       true,
+		".env.local": true,
+	}
+
+	defaultMaxFileSize = int64(50 * 1024 * 1024)
+)
+
+// SkipReason records why an entry was left out of the output
+type SkipReason int
+
+const (
+	NotSkipped SkipReason = iota
+	SkipPattern
+	SkipBuiltinFile
+	SkipBuiltinDir
+	SkipBinaryExtension
+	SkipTooLarge
+	SkipUnreadable
+	SkipLockfile
+	SkipEntryLimit
+	SkipHidden
+	SkipSpecial
+	SkipTokenBudget
+)
+
+// excludingReasons are the skip reasons of entries left out as irrelevant
+// rather than for their content, listed as excluded with ShowExcluded
+var excludingReasons = map[SkipReason]bool{
+	SkipPattern:     true,
+	SkipBuiltinFile: true,
+	SkipBuiltinDir:  true,
+	SkipHidden:      true,
+}
+
+// SkipDecision describes whether an entry is skipped and how it is rendered
+type SkipDecision struct {
+	reason     SkipReason
+	visibility Visibility
+	pattern    *Pattern // The user pattern that matched, if any
+	rule       string   // The built-in rule that matched, if any
+	size       int64    // Size of a file skipped by a limit
+	truncated  bool     // A file over the size limit mapped with its first and last lines
+	partial    bool     // A directory missed by a filter, kept if entries inside match
+}
+
+// ownFiles returns names together with the default output name of every
+// format, so a new format cannot be forgotten
+func ownFiles(names ...string) map[string]bool {
+	files := make(map[string]bool, len(names)+len(formatNames))
+	for format := range formatNames {
+		files["project_structure"+format.Extension()] = true
+	}
+	for _, name := range names {
+		files[name] = true
+	}
+	return files
+}
+
+// shouldSkipFile decides whether the entry at name within fsys is left out
+// The pattern files of subdirectories in nested take precedence over patterns.
+func shouldSkipFile(fsys fs.FS, entry fs.DirEntry, name string, patterns *PatternList, nested, gitignores *dirPatternSet, opts *Options) (SkipDecision, error) {
+	skip := func(reason SkipReason, rule string) (SkipDecision, error) {
+		visibility := opts.TreePolicy.visibility(reason)
+		if opts.ShowExcluded && excludingReasons[reason] {
+			visibility = Listed
+		}
+		return SkipDecision{reason: reason, visibility: visibility, rule: rule}, nil
+	}
+	ignored := func(p *Pattern) (SkipDecision, error) {
+		decision, _ := skip(SkipPattern, "")
+		decision.pattern = p
+		if p.visibility != VisibilityDefault {
+			decision.visibility = p.visibility
+		}
+		return decision, nil
+	}
+
+	// The tool's own files are skipped before patterns, so a filter
+	// missing them does not claim them
+	if toolFiles[entry.Name()] || toolFiles[unsplitPath(entry.Name())] {
+		return skip(SkipBuiltinFile, entry.Name())
+	}
+
+	local := nested.match(name, entry.IsDir())
+	if local != nil && !local.negate {
+		return ignored(local)
+	}
+
+	var matched *Pattern
+	partial := false
+	if patterns != nil {
+		matched = patterns.match(name, entry.IsDir())
+		if patterns.matchType == Ignore {
+			if local != nil {
+				// Re-included by the pattern file of a subdirectory
+				matched = local
+			} else if matched != nil && !matched.negate {
+				return ignored(matched)
+			}
+		} else if len(patterns.patterns) > 0 && (matched == nil || matched.negate) {
+			// Directories are still walked since entries inside may match
+			if matched != nil || !entry.IsDir() {
+				decision, _ := skip(SkipPattern, "filter miss")
+				decision.pattern = matched
+				return decision, nil
+			}
+			partial = true
+		}
+	}
+	if matched == nil {
+		matched = local
+	}
+	if include := opts.Include; include != nil && len(include.patterns) > 0 {
+		p := include.match(name, entry.IsDir())
+		switch {
+		case p != nil && !p.negate:
+			if matched == nil {
+				matched = p
+			}
+		case p != nil || !entry.IsDir():
+			decision, _ := skip(SkipPattern, "filter miss")
+			decision.pattern = p
+			return decision, nil
+		default:
+			partial = true
+		}
+	}
+
+	if matched := gitignores.match(name, entry.IsDir()); matched != nil && !matched.negate {
+		decision, _ := skip(SkipPattern, "")
+		decision.pattern = matched
+		return decision, nil
+	}
+
+	hidden := strings.HasPrefix(entry.Name(), ".")
+	if hidden && opts.Hidden == HiddenSkipped {
+		return skip(SkipHidden, ".*")
+	}
+
+	// With HiddenShown the lists no longer name dotfiles, except .git
+	lists := opts.skipLists()
+	listed := !hidden || opts.Hidden != HiddenShown || entry.Name() == ".git"
+	if listed && lists.Files[entry.Name()] {
+		return skip(SkipBuiltinFile, entry.Name())
+	}
+
+	if listed && entry.IsDir() && lists.Dirs[entry.Name()] {
+		return skip(SkipBuiltinDir, entry.Name()+"/")
+	}
+
+	truncated := false
+	if !entry.IsDir() && !followedDir(fsys, entry, name, opts) {
+		// Pipes, sockets and devices are never opened, since reading may block
+		mode := entry.Type()
+		if mode&fs.ModeSymlink != 0 && !opts.ShowSymlinks {
+			if info, err := fs.Stat(fsys, name); err == nil {
+				mode = info.Mode().Type()
+			}
+		}
+		if kind := specialKind(mode); kind != "" {
+			return skip(SkipSpecial, kind)
+		}
+
+		// Lock files are summarized by the dependency section
+		if opts.Dependencies && lockFiles[entry.Name()] {
+			return skip(SkipLockfile, entry.Name())
+		}
+
+		// Known binary extensions save reading the file
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if lists.Extensions[ext] {
+			return skip(SkipBinaryExtension, ext)
+		}
+
+		// Only entries that survive the name based checks are stat'ed
+		info, err := entry.Info()
+		if err != nil {
+			return SkipDecision{}, fmt.Errorf("error getting file info: %v", err)
+		}
+		if limit := opts.maxFileSize(); info.Size() > limit {
+			if opts.HeadLines <= 0 && opts.TailLines <= 0 {
+				decision, _ := skip(SkipTooLarge, fmt.Sprintf("> %d bytes", limit))
+				decision.size = info.Size()
+				return decision, nil
+			}
+			// Its first and last lines are mapped, if it holds text
+			truncated = true
+		}
+
+		// A symlink that is only shown may dangle
+		shownLink := opts.ShowSymlinks && entry.Type()&fs.ModeSymlink != 0
+		binary, err := opts.ContentCache.sniff(fsys, name, info)
+		if err != nil && !shownLink {
+			i18n.Warnf("Cannot read file %s: %v", name, err)
+			decision, _ := skip(SkipUnreadable, readFailure(err))
+			decision.size = info.Size()
+			return decision, nil
+		}
+		if binary && !shownLink {
+			return skip(SkipBinaryExtension, "binary content")
+		}
+	}
+
+	if partial {
+		decision, _ := skip(SkipPattern, "filter miss")
+		decision.partial = true
+		return decision, nil
+	}
+
+	// In filter mode the matching pattern is kept for statistics
+	return SkipDecision{reason: NotSkipped, pattern: matched, truncated: truncated}, nil
+}
+
+// readFailure names why a file could not be read, for the rule of its
+// SkipUnreadable decision
+func readFailure(err error) string {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return "permission denied"
+	case errors.Is(err, fs.ErrNotExist):
+		return "missing, e.g. a dangling symlink"
+	case errors.As(err, &pathErr):
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// followedDir reports whether entry is a symlink to a directory that the
+// walk follows, so the checks of file contents do not apply to it
+func followedDir(fsys fs.FS, entry fs.DirEntry, name string, opts *Options) bool {
+	if entry.Type()&fs.ModeSymlink == 0 || opts.ShowSymlinks {
+		return false
+	}
+	info, err := fs.Stat(fsys, name)
+	return err == nil && info.IsDir()
+}
+
+// createTree builds the tree below name, the root being "."
+func createTree(name string, ignoreMatcher *PatternList, opts *Options, report *ScanReport) (*TreeNode, error) {
+	rootInfo, err := fs.Stat(report.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting root info: %v", err)
+	}
+
+	rootNode := report.nodes.newNode()
+	rootNode.name = path.Base(name)
+	rootNode.isDir = rootInfo.IsDir()
+
+	if !rootInfo.IsDir() {
+		return rootNode, nil
+	}
+	if restored := opts.Checkpoint.restore(name, report, ignoreMatcher); restored != nil {
+		return restored, nil
+	}
+	if err := addChildren(rootNode, name, ignoreMatcher, opts, report); err != nil {
+		return nil, err
+	}
+	return rootNode, nil
+}
+
+// addChildren reads the directory at name and adds its mapped entries to node
+func addChildren(node *TreeNode, name string, ignoreMatcher *PatternList, opts *Options, report *ScanReport) error {
+	report.emit(Event{Kind: EventEnterDir, Path: name, IsDir: true})
+	if report.stopped == fs.SkipDir {
+		report.stopped = nil
+		return nil
+	}
+	if report.stopped != nil {
+		return report.stopped
+	}
+	if !opts.ShowSymlinks {
+		defer report.enterDir(name)()
+	}
+	if opts.OnProgress != nil {
+		opts.OnProgress(name, report.files)
+	}
+	entries, err := report.readDir(name)
+	if err != nil && report.events != nil {
+		report.emit(Event{Kind: EventError, Path: name, IsDir: true, Err: err})
+		return report.stopped
+	}
+	if err != nil {
+		return fmt.Errorf("error reading directory: %v", err)
+	}
+	node.children = make([]*TreeNode, 0, len(entries))
+
+	cp := opts.Checkpoint
+	var since reportMark
+	if cp != nil {
+		since = cp.mark(report)
+	}
+
+	decide := report.decider(entries, name, ignoreMatcher, opts)
+	for i, entry := range entries {
+		if err := report.ctx.Err(); err != nil {
+			return err
+		}
+		if report.stopped == fs.SkipDir {
+			report.stopped = nil
+			break
+		}
+		if report.stopped != nil {
+			return report.stopped
+		}
+		childPath := path.Join(name, entry.Name())
+
+		// Never map the snapshot being written
+		if isOutputPath(childPath, opts.ExcludePath) {
+			continue
+		}
+
+		decision, err := decide(i)
+		if err != nil {
+			return fmt.Errorf("error checking file %s: %v", childPath, err)
+		}
+		if decision.partial {
+			child, err := addChild(entry, childPath, ignoreMatcher, opts, report)
+			if err != nil {
+				return err
+			}
+			if len(child.children) > 0 && !overLimit(node, entry, childPath, opts, report) {
+				node.children = append(node.children, child)
+				continue
+			}
+		}
+		report.recordDecision(decision)
+		report.recordOmission(childPath, decision)
+		if decision.reason != NotSkipped {
+			report.skipped(childPath, entry.IsDir(), decision, opts)
+		}
+		if decision.reason == SkipUnreadable && opts.OnUnreadable != nil {
+			opts.OnUnreadable(childPath)
+		}
+		if decision.reason == SkipBinaryExtension {
+			report.addBinaryAsset(entry, childPath)
+		}
+		if decision.reason == SkipBuiltinDir {
+			report.builtinDirs = append(report.builtinDirs, childPath)
+		}
+		if decision.reason != NotSkipped {
+			// Listed entries stay in the tree without content or children
+			if decision.visibility == Listed && !overLimit(node, entry, childPath, opts, report) {
+				listed := report.nodes.newNode()
+				listed.name = entry.Name()
+				listed.isDir = entry.IsDir()
+				listed.omitted = true
+				listed.excluded = opts.ShowExcluded && excludingReasons[decision.reason]
+				if decision.reason == SkipSpecial {
+					listed.addNote(report.msg.Sprintf(decision.rule))
+				}
+				node.children = append(node.children, listed)
+			}
+			continue
+		}
+		if overLimit(node, entry, childPath, opts, report) {
+			continue
+		}
+
+		child, err := addChild(entry, childPath, ignoreMatcher, opts, report)
+		if err != nil {
+			return err
+		}
+		if decision.truncated {
+			child.keepLines, child.keepTail = opts.HeadLines, opts.TailLines
+			child.addNote(truncatedNote(opts.HeadLines, opts.TailLines, report.msg))
+		}
+		node.children = append(node.children, child)
+		switch {
+		case child.omitted:
+			// A symlink cycle is listed without being walked
+			report.emit(Event{Kind: EventSkip, Path: childPath, Rule: "symlink cycle", Listed: true})
+		case !child.isDir:
+			report.emit(Event{Kind: EventFile, Path: childPath})
+		}
+	}
+	if report.stopped != nil && report.stopped != fs.SkipDir {
+		return report.stopped
+	}
+	report.stopped = nil
+	report.emit(Event{Kind: EventLeaveDir, Path: name, IsDir: true})
+
+	if cp != nil {
+		cp.completed(name, node, since, report, ignoreMatcher)
+	}
+	return nil
+}
+
+// skipped reports an entry left out by decision to OnSkip and the walk events
+func (r *ScanReport) skipped(name string, isDir bool, decision SkipDecision, opts *Options) {
+	if opts.OnSkip == nil && r.events == nil {
+		return
+	}
+	rule := describeDecision(decision, r.msg)
+	if opts.OnSkip != nil {
+		opts.OnSkip(name, isDir, rule)
+	}
+	r.emit(Event{Kind: EventSkip, Path: name, IsDir: isDir, Rule: rule, Listed: decision.visibility == Listed})
+}
+
+// overLimit reports whether an entry of node to be shown is cut by
+// MaxEntriesPerDir or MaxFiles, counting it in the ellipsis of node, and
+// otherwise counts it towards the limits
+func overLimit(node *TreeNode, entry fs.DirEntry, childPath string, opts *Options, report *ScanReport) bool {
+	rule := ""
+	switch {
+	case opts.MaxEntriesPerDir > 0 && len(node.children) >= opts.MaxEntriesPerDir:
+		rule = fmt.Sprintf("%d entries per directory", opts.MaxEntriesPerDir)
+	case opts.MaxFiles > 0 && report.files >= opts.MaxFiles:
+		rule = fmt.Sprintf("%d files", opts.MaxFiles)
+	default:
+		if !entry.IsDir() {
+			report.files++
+		}
+		return false
+	}
+	node.more++
+	decision := SkipDecision{reason: SkipEntryLimit, rule: rule}
+	if info, err := entry.Info(); err == nil && !entry.IsDir() {
+		decision.size = info.Size()
+	}
+	report.recordOmission(childPath, decision)
+	report.countSkip(decision.reason)
+	report.skipped(childPath, entry.IsDir(), decision, opts)
+	return true
+}
+
+// addChild creates the node of a mapped entry, walking it if it is a directory
+func addChild(entry fs.DirEntry, childPath string, ignoreMatcher *PatternList, opts *Options, report *ScanReport) (*TreeNode, error) {
+	child := report.nodes.newNode()
+	child.name = entry.Name()
+	child.isDir = entry.IsDir()
+	if entry.Type()&fs.ModeSymlink != 0 {
+		if opts.ShowSymlinks {
+			child.isDir, child.link = false, report.readLink(childPath)
+			return child, nil
+		}
+		// Symlinked directories are followed, which needs the target's type,
+		// unless they lead back into a directory being walked
+		if info, err := fs.Stat(report.fsys, childPath); err == nil {
+			child.isDir = info.IsDir()
+			if child.isDir && report.isCycle(childPath, info) {
+				child.isDir, child.link, child.omitted = false, report.readLink(childPath), true
+				child.addNote(report.msg.Sprintf("symlink cycle"))
+				return child, nil
+			}
+		}
+	}
+	if target, ok := report.junction(childPath, entry); ok {
+		// Junctions are listed or followed like symlinks to directories
+		if opts.ShowSymlinks {
+			child.isDir, child.link = false, target
+			return child, nil
+		}
+		if info, err := fs.Stat(report.fsys, childPath); err == nil && report.isCycle(childPath, info) {
+			child.isDir, child.link, child.omitted = false, target, true
+			child.addNote(report.msg.Sprintf("symlink cycle"))
+			return child, nil
+		}
+	}
+	if child.isDir {
+		if restored := opts.Checkpoint.restore(childPath, report, ignoreMatcher); restored != nil {
+			walkFiles(restored, func(*TreeNode, string) { report.files++ })
+			return restored, nil
+		}
+		if err := addChildren(child, childPath, ignoreMatcher, opts, report); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
+func printTree(node *TreeNode, prefix string, isLast bool, output io.Writer, msg i18n.Printer) {
+	var currentPrefix string
+	if prefix == "" {
+		currentPrefix = ""
+	} else {
+		if isLast {
+			currentPrefix = prefix + "└── "
+		} else {
+			currentPrefix = prefix + "├── "
+		}
+	}
+
+	var displayName string
+	if node.isDir {
+		displayName = fmt.Sprintf("[%s]", node.name)
+	} else {
+		displayName = node.name
+	}
+	if node.link != "" {
+		displayName += " -> " + node.link
+	} else if node.excluded {
+		displayName += " [excluded]"
+	} else if node.omitted {
+		displayName += " [omitted]"
+	}
+	if node.note != "" {
+		displayName += " (" + node.note + ")"
+	}
+	fmt.Fprintln(output, currentPrefix+displayName)
+
+	var childPrefix string
+	if prefix == "" {
+		childPrefix = "    "
+	} else {
+		if isLast {
+			childPrefix = prefix + "    "
+		} else {
+			childPrefix = prefix + "│   "
+		}
+	}
+
+	for i, child := range node.children {
+		isLastChild := i == len(node.children)-1 && node.more == 0
+		printTree(child, childPrefix, isLastChild, output, msg)
+	}
+	if node.more > 0 {
+		fmt.Fprintln(output, childPrefix+"└── "+moreLabel(node.more, msg))
+	}
+}
+
+// moreLabel describes the entries left out of a directory by an entry limit
+func moreLabel(more int, msg i18n.Printer) string {
+	return msg.Sprintf(plural(more, "… %d more entry", "… %d more entries"), more)
+}
+
+// walkFiles calls fn for every file node below the root node with its
+// slash-separated path relative to the root, which is also its name in the
+// scanned filesystem
+func walkFiles(root *TreeNode, fn func(node *TreeNode, name string)) {
+	walkFilesFrom(root, ".", fn)
+}
+
+func walkFilesFrom(node *TreeNode, name string, fn func(node *TreeNode, name string)) {
+	if !node.isDir {
+		fn(node, name)
+		return
+	}
+
+	for _, child := range node.children {
+		walkFilesFrom(child, path.Join(name, child.name), fn)
+	}
+}
+
+// writeFileContents writes the content of every file not omitted or hoisted,
+// leaving out the first skip files, with labels in the language of msg.
+// written is called after each file.
+func writeFileContents(node *TreeNode, fsys fs.FS, output io.Writer, opts *Options, msg i18n.Printer, skip int, written func()) error {
+	jobs := make([]contentJob, 0)
+	walkFiles(node, func(n *TreeNode, name string) {
+		if !n.omitted && !n.hoisted {
+			jobs = append(jobs, contentJob{node: n, fsys: fsys, name: name, msg: msg})
+		}
+	})
+	jobs = jobs[min(skip, len(jobs)):]
+	return runContentPipeline(jobs, output, opts, written)
+}
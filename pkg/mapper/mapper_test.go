@@ -0,0 +1,48 @@
+package mapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// The default output of every format is left out of later snapshots
+func TestToolFilesSkipEarlierOutputs(t *testing.T) {
+	fsys := fstest.MapFS{"main.go": {Data: []byte("package main\n")}}
+	for format := range formatNames {
+		fsys["project_structure"+format.Extension()] = &fstest.MapFile{Data: []byte("earlier snapshot\n")}
+	}
+	tree, err := ScanFS(fsys, "project", &Options{TreePolicy: DefaultTreePolicy()})
+	if err != nil {
+		t.Fatalf("ScanFS: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tree.Render(&out, FormatText); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(out.String(), "project_structure") || strings.Contains(out.String(), "earlier snapshot") {
+		t.Errorf("earlier outputs were mapped:\n%s", out.String())
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("disk error") }
+
+// A cancelled run fails the write, which is not blamed on the file
+func TestCopyContentErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	readErr, writeErr := copyContent(&contextWriter{ctx: ctx, w: io.Discard}, strings.NewReader("content"))
+	if readErr != nil || !errors.Is(writeErr, context.Canceled) {
+		t.Errorf("cancelled write: read error %v, write error %v", readErr, writeErr)
+	}
+	readErr, writeErr = copyContent(io.Discard, failingReader{})
+	if readErr == nil || writeErr != nil {
+		t.Errorf("failed read: read error %v, write error %v", readErr, writeErr)
+	}
+}
@@ -0,0 +1,139 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// languageTags maps file extensions to the info string of a fenced code block
+var languageTags = map[string]string{
+	".go":         "go",
+	".py":         "python",
+	".js":         "javascript",
+	".mjs":        "javascript",
+	".cjs":        "javascript",
+	".jsx":        "jsx",
+	".ts":         "typescript",
+	".tsx":        "tsx",
+	".java":       "java",
+	".kt":         "kotlin",
+	".rb":         "ruby",
+	".rs":         "rust",
+	".c":          "c",
+	".h":          "c",
+	".cc":         "cpp",
+	".cpp":        "cpp",
+	".hpp":        "cpp",
+	".cs":         "csharp",
+	".php":        "php",
+	".swift":      "swift",
+	".ex":         "elixir",
+	".exs":        "elixir",
+	".sh":         "bash",
+	".bash":       "bash",
+	".ps1":        "powershell",
+	".sql":        "sql",
+	".html":       "html",
+	".css":        "css",
+	".scss":       "scss",
+	".json":       "json",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".toml":       "toml",
+	".xml":        "xml",
+	".md":         "markdown",
+	".proto":      "protobuf",
+	".tf":         "hcl",
+	".dockerfile": "dockerfile",
+}
+
+// languageNames maps well-known extensionless file names to their language
+var languageNames = map[string]string{
+	"Dockerfile":  "dockerfile",
+	"Makefile":    "makefile",
+	"Gemfile":     "ruby",
+	"Rakefile":    "ruby",
+	"Jenkinsfile": "groovy",
+}
+
+// languageTag returns the fenced code block language of a file, or "" when unknown
+func languageTag(name string) string {
+	if tag, ok := languageNames[name]; ok {
+		return tag
+	}
+	return languageTags[strings.ToLower(path.Ext(name))]
+}
+
+// codeFence returns a backtick fence longer than any backtick run in content
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for i := 0; i < len(content); i++ {
+		if content[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// renderMarkdown writes the tree in a code block followed by a heading and a
+// fenced block per file
+func (t *Tree) renderMarkdown(w io.Writer) error {
+	fmt.Fprintf(w, "# %s\n\n", t.root.name)
+	fmt.Fprintln(w, "```text")
+	printTree(t.root, "", true, w, t.msg)
+	fmt.Fprintln(w, "```")
+	if t.opts.StructureOnly {
+		return nil
+	}
+
+	var err error
+	index := make([]indexEntry, 0)
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if err != nil || node.omitted {
+			return
+		}
+		content, ok := t.fileContent(node, name)
+		if !ok {
+			return
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		fence := codeFence(content)
+		_, err = fmt.Fprintf(w, "\n### %s\n\n%s%s\n%s%s\n", name, fence, languageTag(node.name), content, fence)
+		index = append(index, indexEntry{path: name})
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(t.report.omissions) > 0 {
+		fmt.Fprintf(w, "\n## %s\n\n```text\n", t.msg.Sprintf("Omissions"))
+		writeOmissionSummary(t.report, t.msg, w)
+		fmt.Fprintln(w, "```")
+	}
+	if t.opts.FindingsAppendix && len(t.findings) > 0 {
+		fmt.Fprintf(w, "\n## %s\n\n```text\n", t.msg.Sprintf("Findings"))
+		writeFindings(t.findings, w)
+		fmt.Fprintln(w, "```")
+	}
+	if t.opts.FileIndex && len(index) > 0 {
+		// Anchors are numbered in the order of the headings
+		used := make(map[string]int)
+		headingAnchor(t.root.name, used)
+		for i := range index {
+			index[i].section = headingAnchor(index[i].path, used)
+		}
+		sortIndex(index)
+		fmt.Fprintf(w, "\n## %s\n\n", t.msg.Sprintf("File Index"))
+		for _, e := range index {
+			fmt.Fprintf(w, "- [%s](#%s)\n", e.path, e.section)
+		}
+	}
+	return nil
+}
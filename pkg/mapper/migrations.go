@@ -0,0 +1,639 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// MigrationSet is a directory of incremental schema migrations
+type MigrationSet struct {
+	path   string   // Directory path relative to the root
+	format string   // flyway, golang-migrate, sql, rails or django
+	files  []string // Full paths of the migrations in apply order
+	node   *TreeNode
+	schema *Schema // Filled in by consolidateMigrations
+}
+
+// SchemaTable is a table of the consolidated schema
+type SchemaTable struct {
+	name        string
+	columns     []SchemaColumn
+	constraints []string
+}
+
+// SchemaColumn is a column of a consolidated table
+type SchemaColumn struct {
+	name       string
+	definition string
+}
+
+// Schema is the state obtained by replaying a migration set
+type Schema struct {
+	tables  []*SchemaTable
+	indexes map[string]string // Index name to its CREATE statement
+}
+
+var (
+	golangMigrateFile = regexp.MustCompile(`^(\d+)_.+\.(up|down)\.sql$`)
+	flywayFile        = regexp.MustCompile(`^V(\d+(?:[._]\d+)*)__.+\.sql$`)
+	numberedSQLFile   = regexp.MustCompile(`^(\d+).*\.sql$`)
+	railsFile         = regexp.MustCompile(`^(\d{14})_.+\.rb$`)
+	djangoFile        = regexp.MustCompile(`^(\d{4})_.+\.py$`)
+
+	sqlIdentifier = `[\w."` + "`" + `\[\]]+`
+	createTableRe = regexp.MustCompile(`(?is)^create\s+(?:temporary\s+)?table\s+(?:if\s+not\s+exists\s+)?(` + sqlIdentifier + `)\s*\((.*)\)`)
+	alterTableRe  = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(` + sqlIdentifier + `)\s+(.*)$`)
+	dropTableRe   = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?(.+?)(?:\s+cascade|\s+restrict)?$`)
+	createIndexRe = regexp.MustCompile(`(?is)^create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?(` + sqlIdentifier + `)\s+on\s+`)
+	dropIndexRe   = regexp.MustCompile(`(?is)^drop\s+index\s+(?:concurrently\s+)?(?:if\s+exists\s+)?(` + sqlIdentifier + `)`)
+
+	railsCreateRe  = regexp.MustCompile(`^create_table\s+[:"']?(\w+)`)
+	railsColumnRe  = regexp.MustCompile(`^t\.(\w+)\s+[:"']?(\w+)["']?(.*)$`)
+	railsCallRe    = regexp.MustCompile(`^(add_column|remove_column|drop_table|rename_column|rename_table|change_column|add_index|remove_index)\s*\(?\s*(.*?)\)?$`)
+	railsSymbolRe  = regexp.MustCompile(`[:"']?(\w+)["']?`)
+	djangoOpRe     = regexp.MustCompile(`migrations\.(CreateModel|DeleteModel|AddField|RemoveField|AlterField|RenameField|RenameModel)\(`)
+	djangoKwargRe  = regexp.MustCompile(`(\w+)\s*=\s*['"](\w+)['"]`)
+	djangoFieldRe  = regexp.MustCompile(`\(\s*['"](\w+)['"]\s*,\s*((?:models|django\.db\.models)\.[\w.]+\([^\n]*?\))\s*\)`)
+	djangoFieldArg = regexp.MustCompile(`field\s*=\s*((?:models|django\.db\.models)\.[\w.]+\(.*\))`)
+)
+
+// detectMigrations finds migration directories in the tree
+func detectMigrations(node *TreeNode, name string) []MigrationSet {
+	sets := make([]MigrationSet, 0)
+	if !node.isDir {
+		return sets
+	}
+
+	if set, ok := classifyMigrationDir(node, name); ok {
+		set.path = name
+		return append(sets, set)
+	}
+
+	for _, child := range node.children {
+		sets = append(sets, detectMigrations(child, path.Join(name, child.name))...)
+	}
+	return sets
+}
+
+// classifyMigrationDir decides whether a directory holds migrations and in which format
+func classifyMigrationDir(node *TreeNode, name string) (MigrationSet, bool) {
+	type versioned struct {
+		version []int
+		path    string
+	}
+
+	formats := []struct {
+		name    string
+		pattern *regexp.Regexp
+		dirs    []string // Required directory names, nil for any
+	}{
+		{"golang-migrate", golangMigrateFile, nil},
+		{"flyway", flywayFile, nil},
+		{"rails", railsFile, []string{"migrate"}},
+		{"django", djangoFile, []string{"migrations"}},
+		{"sql", numberedSQLFile, []string{"migrations", "migration", "migrate", "sql"}},
+	}
+
+	for _, format := range formats {
+		if format.dirs != nil && !containsString(format.dirs, strings.ToLower(node.name)) {
+			continue
+		}
+
+		files := make([]versioned, 0)
+		for _, child := range node.children {
+			if child.isDir || child.omitted {
+				continue
+			}
+			m := format.pattern.FindStringSubmatch(child.name)
+			if m == nil {
+				continue
+			}
+			// Only up migrations contribute to the current schema
+			if format.name == "golang-migrate" && m[2] == "down" {
+				continue
+			}
+			files = append(files, versioned{parseVersion(m[1]), path.Join(name, child.name)})
+		}
+
+		if len(files) < 2 {
+			continue
+		}
+
+		sort.SliceStable(files, func(i, j int) bool {
+			return compareVersions(files[i].version, files[j].version) < 0
+		})
+		set := MigrationSet{format: format.name, node: node}
+		for _, f := range files {
+			set.files = append(set.files, f.path)
+		}
+		return set, true
+	}
+	return MigrationSet{}, false
+}
+
+// parseVersion splits a version such as "1_2" or "20200101000000" into numbers
+func parseVersion(s string) []int {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '_' })
+	version := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, _ := strconv.Atoi(p)
+		version = append(version, n)
+	}
+	return version
+}
+
+// compareVersions orders numeric versions component by component
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// consolidateMigrations replays every migration set in the tree and collapses
+// the migration directories, returning the sets with their schemas
+func consolidateMigrations(tree *TreeNode, fsys fs.FS, msg i18n.Printer) []MigrationSet {
+	sets := detectMigrations(tree, ".")
+	for i := range sets {
+		set := &sets[i]
+		set.schema = &Schema{indexes: make(map[string]string)}
+		for _, file := range set.files {
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				i18n.Warnf("Could not read migration %s: %v", file, err)
+				continue
+			}
+			switch set.format {
+			case "rails":
+				set.schema.applyRails(string(data))
+			case "django":
+				// The app is the directory holding migrations/, which may be the root
+				app := path.Dir(path.Dir(file))
+				if app == "." {
+					app = tree.name
+				}
+				set.schema.applyDjango(string(data), path.Base(app))
+			default:
+				set.schema.applySQL(string(data))
+			}
+		}
+
+		// Keep the directory in the tree but drop the individual migrations
+		set.node.children = nil
+		set.node.omitted = true
+		set.node.addNote(msg.Sprintf("%d migrations consolidated into Schema", len(set.files)))
+	}
+	return sets
+}
+
+// writeSchemas writes a Schema section for each consolidated migration set
+func writeSchemas(sets []MigrationSet, output io.Writer) {
+	for _, set := range sets {
+		fmt.Fprintf(output, "<Schema source=\"%s\" format=\"%s\" migrations=\"%d\">\n", set.path, set.format, len(set.files))
+		set.schema.write(output)
+		fmt.Fprintln(output, "</Schema>")
+	}
+}
+
+// table returns the table with the given name, or nil
+func (s *Schema) table(name string) *SchemaTable {
+	name = normalizeIdentifier(name)
+	for _, t := range s.tables {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ensureTable returns the named table, creating it when missing
+func (s *Schema) ensureTable(name string) *SchemaTable {
+	if t := s.table(name); t != nil {
+		return t
+	}
+	t := &SchemaTable{name: normalizeIdentifier(name)}
+	s.tables = append(s.tables, t)
+	return t
+}
+
+// dropTable removes a table and its indexes
+func (s *Schema) dropTable(name string) {
+	name = normalizeIdentifier(name)
+	for i, t := range s.tables {
+		if t.name == name {
+			s.tables = append(s.tables[:i], s.tables[i+1:]...)
+			break
+		}
+	}
+	for idx, stmt := range s.indexes {
+		if strings.Contains(strings.ToLower(stmt), " on "+strings.ToLower(name)) {
+			delete(s.indexes, idx)
+		}
+	}
+}
+
+// setColumn adds or replaces a column definition
+func (t *SchemaTable) setColumn(name, definition string) {
+	name = normalizeIdentifier(name)
+	for i := range t.columns {
+		if t.columns[i].name == name {
+			t.columns[i].definition = definition
+			return
+		}
+	}
+	t.columns = append(t.columns, SchemaColumn{name: name, definition: definition})
+}
+
+// dropColumn removes a column if present
+func (t *SchemaTable) dropColumn(name string) {
+	name = normalizeIdentifier(name)
+	for i := range t.columns {
+		if t.columns[i].name == name {
+			t.columns = append(t.columns[:i], t.columns[i+1:]...)
+			return
+		}
+	}
+}
+
+// renameColumn renames a column if present
+func (t *SchemaTable) renameColumn(from, to string) {
+	from = normalizeIdentifier(from)
+	for i := range t.columns {
+		if t.columns[i].name == from {
+			t.columns[i].name = normalizeIdentifier(to)
+			return
+		}
+	}
+}
+
+// normalizeIdentifier strips SQL quoting from an identifier
+func normalizeIdentifier(name string) string {
+	return strings.Trim(strings.TrimSpace(name), "\"`[]")
+}
+
+// applySQL replays the DDL statements of a SQL migration
+func (s *Schema) applySQL(sql string) {
+	for _, stmt := range splitSQLStatements(stripSQLComments(sql)) {
+		switch {
+		case createTableRe.MatchString(stmt):
+			m := createTableRe.FindStringSubmatch(stmt)
+			t := s.ensureTable(m[1])
+			t.columns, t.constraints = nil, nil
+			for _, item := range splitTopLevel(m[2], ',') {
+				item = strings.TrimSpace(item)
+				if item == "" {
+					continue
+				}
+				if isTableConstraint(item) {
+					t.constraints = append(t.constraints, item)
+					continue
+				}
+				name, def, _ := strings.Cut(item, " ")
+				t.setColumn(name, strings.TrimSpace(def))
+			}
+
+		case alterTableRe.MatchString(stmt):
+			m := alterTableRe.FindStringSubmatch(stmt)
+			for _, action := range splitTopLevel(m[2], ',') {
+				s.applyAlter(m[1], strings.TrimSpace(action))
+			}
+
+		case dropTableRe.MatchString(stmt):
+			m := dropTableRe.FindStringSubmatch(stmt)
+			for _, name := range strings.Split(m[1], ",") {
+				s.dropTable(name)
+			}
+
+		case createIndexRe.MatchString(stmt):
+			m := createIndexRe.FindStringSubmatch(stmt)
+			s.indexes[normalizeIdentifier(m[1])] = collapseSpace(stmt)
+
+		case dropIndexRe.MatchString(stmt):
+			m := dropIndexRe.FindStringSubmatch(stmt)
+			delete(s.indexes, normalizeIdentifier(m[1]))
+		}
+	}
+}
+
+// applyAlter applies a single ALTER TABLE action
+func (s *Schema) applyAlter(tableName, action string) {
+	t := s.ensureTable(tableName)
+	fields := strings.Fields(action)
+	if len(fields) == 0 {
+		return
+	}
+	verb := strings.ToUpper(fields[0])
+
+	// Skips the optional COLUMN keyword and IF [NOT] EXISTS guards
+	rest := fields[1:]
+	trim := func() {
+		for len(rest) > 0 {
+			switch strings.ToUpper(rest[0]) {
+			case "COLUMN", "IF", "NOT", "EXISTS":
+				rest = rest[1:]
+			default:
+				return
+			}
+		}
+	}
+
+	switch verb {
+	case "ADD":
+		if len(rest) > 0 && isTableConstraint(strings.Join(rest, " ")) {
+			t.constraints = append(t.constraints, strings.Join(rest, " "))
+			return
+		}
+		trim()
+		if len(rest) > 0 {
+			t.setColumn(rest[0], strings.Join(rest[1:], " "))
+		}
+	case "DROP":
+		if len(rest) > 0 && strings.EqualFold(rest[0], "CONSTRAINT") {
+			return
+		}
+		trim()
+		if len(rest) > 0 {
+			t.dropColumn(rest[0])
+		}
+	case "RENAME":
+		trim()
+		if len(rest) >= 2 && strings.EqualFold(rest[0], "TO") {
+			t.name = normalizeIdentifier(rest[1])
+		} else if len(rest) >= 3 && strings.EqualFold(rest[1], "TO") {
+			t.renameColumn(rest[0], rest[2])
+		}
+	case "ALTER", "MODIFY", "CHANGE":
+		trim()
+		if len(rest) >= 2 && strings.EqualFold(rest[1], "TYPE") {
+			t.updateColumnType(rest[0], strings.Join(rest[2:], " "))
+		} else if verb == "MODIFY" && len(rest) >= 2 {
+			t.setColumn(rest[0], strings.Join(rest[1:], " "))
+		} else if verb == "CHANGE" && len(rest) >= 3 {
+			t.renameColumn(rest[0], rest[1])
+			t.setColumn(rest[1], strings.Join(rest[2:], " "))
+		}
+	}
+}
+
+// updateColumnType replaces the type of a column, keeping its other attributes
+func (t *SchemaTable) updateColumnType(name, newType string) {
+	name = normalizeIdentifier(name)
+	for i := range t.columns {
+		if t.columns[i].name == name {
+			fields := strings.Fields(t.columns[i].definition)
+			if len(fields) > 0 {
+				fields[0] = newType
+				t.columns[i].definition = strings.Join(fields, " ")
+			} else {
+				t.columns[i].definition = newType
+			}
+			return
+		}
+	}
+}
+
+// isTableConstraint reports whether a CREATE TABLE item is a table-level constraint
+func isTableConstraint(item string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(item))
+	for _, prefix := range []string{"CONSTRAINT ", "PRIMARY KEY", "UNIQUE ", "UNIQUE(", "FOREIGN KEY", "CHECK ", "CHECK(", "INDEX ", "KEY "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripSQLComments removes -- line comments and /* */ block comments
+func stripSQLComments(sql string) string {
+	var b strings.Builder
+	for len(sql) > 0 {
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			if i := strings.IndexByte(sql, '\n'); i >= 0 {
+				sql = sql[i:]
+			} else {
+				sql = ""
+			}
+		case strings.HasPrefix(sql, "/*"):
+			if i := strings.Index(sql, "*/"); i >= 0 {
+				sql = sql[i+2:]
+			} else {
+				sql = ""
+			}
+		default:
+			b.WriteByte(sql[0])
+			sql = sql[1:]
+		}
+	}
+	return b.String()
+}
+
+// splitSQLStatements splits SQL on semicolons outside quotes
+func splitSQLStatements(sql string) []string {
+	stmts := make([]string, 0)
+	for _, stmt := range splitTopLevel(sql, ';') {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// splitTopLevel splits s on sep outside parentheses and quotes
+func splitTopLevel(s string, sep byte) []string {
+	parts := make([]string, 0)
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// collapseSpace joins all whitespace runs into single spaces
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// applyRails replays the schema statements of a Rails migration
+func (s *Schema) applyRails(source string) {
+	var current *SchemaTable
+	for _, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(raw)
+
+		if m := railsCreateRe.FindStringSubmatch(line); m != nil {
+			current = s.ensureTable(m[1])
+			current.columns = nil
+			if !strings.Contains(line, "id: false") {
+				current.setColumn("id", "primary_key")
+			}
+			continue
+		}
+
+		if current != nil {
+			if line == "end" {
+				current = nil
+				continue
+			}
+			if line == "t.timestamps" || strings.HasPrefix(line, "t.timestamps ") {
+				current.setColumn("created_at", "datetime")
+				current.setColumn("updated_at", "datetime")
+				continue
+			}
+			if m := railsColumnRe.FindStringSubmatch(line); m != nil {
+				def := m[1]
+				if opts := strings.TrimSpace(strings.TrimPrefix(m[3], ",")); opts != "" {
+					def += " " + opts
+				}
+				if m[1] == "references" || m[1] == "belongs_to" {
+					current.setColumn(m[2]+"_id", "bigint")
+					continue
+				}
+				current.setColumn(m[2], def)
+			}
+			continue
+		}
+
+		m := railsCallRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		args := railsSymbolRe.FindAllStringSubmatch(m[2], -1)
+		arg := func(i int) string {
+			if i < len(args) {
+				return args[i][1]
+			}
+			return ""
+		}
+
+		switch m[1] {
+		case "add_column", "change_column":
+			s.ensureTable(arg(0)).setColumn(arg(1), arg(2))
+		case "remove_column":
+			s.ensureTable(arg(0)).dropColumn(arg(1))
+		case "rename_column":
+			s.ensureTable(arg(0)).renameColumn(arg(1), arg(2))
+		case "rename_table":
+			s.ensureTable(arg(0)).name = arg(1)
+		case "drop_table":
+			s.dropTable(arg(0))
+		case "add_index":
+			s.indexes[arg(0)+"_"+arg(1)] = "add_index " + collapseSpace(m[2])
+		case "remove_index":
+			delete(s.indexes, arg(0)+"_"+arg(1))
+		}
+	}
+}
+
+// applyDjango replays the model operations of a Django migration
+func (s *Schema) applyDjango(source, app string) {
+	locs := djangoOpRe.FindAllStringSubmatchIndex(source, -1)
+	for i, loc := range locs {
+		end := len(source)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		op := source[loc[2]:loc[3]]
+		body := source[loc[1]:end]
+
+		kwargs := make(map[string]string)
+		for _, kv := range djangoKwargRe.FindAllStringSubmatch(body, -1) {
+			if _, seen := kwargs[kv[1]]; !seen {
+				kwargs[kv[1]] = kv[2]
+			}
+		}
+		tableName := func(model string) string {
+			return strings.ToLower(app + "_" + model)
+		}
+
+		switch op {
+		case "CreateModel":
+			t := s.ensureTable(tableName(kwargs["name"]))
+			t.columns = nil
+			for _, f := range djangoFieldRe.FindAllStringSubmatch(body, -1) {
+				t.setColumn(f[1], f[2])
+			}
+		case "DeleteModel":
+			s.dropTable(tableName(kwargs["name"]))
+		case "AddField", "AlterField":
+			def := ""
+			if m := djangoFieldArg.FindStringSubmatch(body); m != nil {
+				def = strings.TrimSuffix(strings.TrimSpace(m[1]), ",")
+			}
+			s.ensureTable(tableName(kwargs["model_name"])).setColumn(kwargs["name"], def)
+		case "RemoveField":
+			s.ensureTable(tableName(kwargs["model_name"])).dropColumn(kwargs["name"])
+		case "RenameField":
+			s.ensureTable(tableName(kwargs["model_name"])).renameColumn(kwargs["old_name"], kwargs["new_name"])
+		case "RenameModel":
+			s.ensureTable(tableName(kwargs["old_name"])).name = tableName(kwargs["new_name"])
+		}
+	}
+}
+
+// write renders the schema as CREATE statements
+func (s *Schema) write(output io.Writer) {
+	for _, t := range s.tables {
+		fmt.Fprintf(output, "CREATE TABLE %s (\n", t.name)
+		items := make([]string, 0, len(t.columns)+len(t.constraints))
+		for _, c := range t.columns {
+			items = append(items, strings.TrimSpace(c.name+" "+collapseSpace(c.definition)))
+		}
+		for _, c := range t.constraints {
+			items = append(items, collapseSpace(c))
+		}
+		for i, item := range items {
+			sep := ","
+			if i == len(items)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(output, "  %s%s\n", item, sep)
+		}
+		fmt.Fprintln(output, ");")
+	}
+
+	names := make([]string, 0, len(s.indexes))
+	for name := range s.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(output, "%s;\n", s.indexes[name])
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package mapper
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is not supported on this platform, so files are always read into buffers
+func mmapFile(file *os.File, size int64) ([]byte, func(), error) {
+	return nil, nil, errors.New("memory mapping not supported")
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package mapper
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps a file read-only, returning its content and a function
+// releasing the mapping. The file may be closed while the mapping is in use.
+func mmapFile(file *os.File, size int64) ([]byte, func(), error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,240 @@
+package mapper
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// NormalForm is a Unicode normalization form applied to file names, so the
+// same name matches patterns and reads the same whichever system wrote it
+type NormalForm int
+
+const (
+	NormNone NormalForm = iota // Names are used as stored
+	NormNFC                    // Composed characters, as most Linux and Windows tools write them
+	NormNFD                    // Decomposed characters, as macOS HFS+ stores names
+)
+
+var normalFormNames = map[NormalForm]string{
+	NormNone: "none",
+	NormNFC:  "nfc",
+	NormNFD:  "nfd",
+}
+
+// String implements flag.Value
+func (f *NormalForm) String() string {
+	if f == nil {
+		return normalFormNames[NormNone]
+	}
+	return normalFormNames[*f]
+}
+
+// Set implements flag.Value
+func (f *NormalForm) Set(value string) error {
+	for form, name := range normalFormNames {
+		if name == value {
+			*f = form
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown normalization form %q", value)
+}
+
+// Hangul syllables are composed and decomposed arithmetically
+const (
+	hangulBase  = 0xAC00
+	hangulCount = 11172
+	leadBase    = 0x1100
+	vowelBase   = 0x1161
+	vowelCount  = 21
+	trailBase   = 0x11A7
+	trailCount  = 28
+)
+
+// Normalize returns s in the normal form f
+func (f NormalForm) Normalize(s string) string {
+	if f == NormNone || isASCII(s) {
+		return s
+	}
+	runes := decompose(s)
+	if f == NormNFC {
+		runes = compose(runes)
+	}
+	if normalized := string(runes); normalized != s {
+		return normalized
+	}
+	return s
+}
+
+// isASCII reports whether s holds only ASCII characters, which every form leaves alone
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decompose returns the canonical decomposition of s, with the combining
+// marks following each starter in canonical order
+func decompose(s string) []rune {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		out = decomposeRune(r, out)
+	}
+	for i := 1; i < len(out); i++ {
+		class := combiningClasses[out[i]]
+		if class == 0 {
+			continue
+		}
+		for j := i; j > 0 && combiningClasses[out[j-1]] > class; j-- {
+			out[j-1], out[j] = out[j], out[j-1]
+		}
+	}
+	return out
+}
+
+// decomposeRune appends the full canonical decomposition of r to out
+func decomposeRune(r rune, out []rune) []rune {
+	if r >= hangulBase && r < hangulBase+hangulCount {
+		i := r - hangulBase
+		out = append(out, leadBase+i/(vowelCount*trailCount), vowelBase+i%(vowelCount*trailCount)/trailCount)
+		if t := i % trailCount; t > 0 {
+			out = append(out, trailBase+t)
+		}
+		return out
+	}
+	d, ok := decompositions[r]
+	if !ok {
+		return append(out, r)
+	}
+	out = decomposeRune(d[0], out)
+	if d[1] != 0 {
+		out = decomposeRune(d[1], out)
+	}
+	return out
+}
+
+// compose combines a decomposed string into primary composites wherever no
+// mark of the same or a lower combining class stands in between
+func compose(runes []rune) []rune {
+	out := make([]rune, 0, len(runes))
+	starter, lastClass := -1, uint8(0)
+	for _, r := range runes {
+		class := combiningClasses[r]
+		if starter >= 0 && (len(out)-1 == starter || lastClass < class) {
+			if composite, ok := composePair(out[starter], r); ok {
+				out[starter] = composite
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		lastClass = class
+		out = append(out, r)
+	}
+	return out
+}
+
+// composePair returns the primary composite of a and b, if any
+func composePair(a, b rune) (rune, bool) {
+	switch {
+	case a >= leadBase && a < leadBase+19 && b >= vowelBase && b < vowelBase+vowelCount:
+		return hangulBase + ((a-leadBase)*vowelCount+b-vowelBase)*trailCount, true
+	case a >= hangulBase && a < hangulBase+hangulCount && (a-hangulBase)%trailCount == 0 && b > trailBase && b < trailBase+trailCount:
+		return a + b - trailBase, true
+	}
+	composite, ok := compositions[[2]rune{a, b}]
+	return composite, ok
+}
+
+// normFS presents the names of fsys in a normal form. It remembers the
+// stored path of every entry it lists whose name changed, so the entry can
+// still be opened by its normalized path.
+type normFS struct {
+	fsys fs.FS
+	form NormalForm
+	mu   sync.RWMutex
+	real map[string]string // Normalized path -> path in fsys, where they differ
+}
+
+func newNormFS(fsys fs.FS, form NormalForm) *normFS {
+	return &normFS{fsys: fsys, form: form, real: make(map[string]string)}
+}
+
+// realName returns the path in fsys of the normalized path name
+func (n *normFS) realName(name string) string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for dir, rest := name, ""; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if real, ok := n.real[dir]; ok {
+			return path.Join(real, rest)
+		}
+		rest = path.Join(path.Base(dir), rest)
+	}
+	return name
+}
+
+func (n *normFS) Open(name string) (fs.File, error) {
+	return n.fsys.Open(n.realName(name))
+}
+
+func (n *normFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(n.fsys, n.realName(name))
+}
+
+func (n *normFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(n.fsys, n.realName(name))
+}
+
+func (n *normFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	realDir := n.realName(name)
+	entries, err := fs.ReadDir(n.fsys, realDir)
+	for i, entry := range entries {
+		normalized := n.form.Normalize(entry.Name())
+		if normalized == entry.Name() && realDir == name {
+			continue
+		}
+		n.mu.Lock()
+		n.real[path.Join(name, normalized)] = path.Join(realDir, entry.Name())
+		n.mu.Unlock()
+		entries[i] = normEntry{entry, normalized}
+	}
+	return entries, err
+}
+
+// normEntry is a directory entry under its normalized name
+type normEntry struct {
+	fs.DirEntry
+	name string
+}
+
+func (e normEntry) Name() string {
+	return e.name
+}
+
+// normalize returns the list with the literal characters of its patterns in
+// the normal form, or the list itself when nothing changes
+func (pl *PatternList) normalize(form NormalForm) *PatternList {
+	if pl == nil || form == NormNone {
+		return pl
+	}
+	normalized := *pl
+	normalized.patterns = make([]Pattern, len(pl.patterns))
+	for i, p := range pl.patterns {
+		if expr := form.Normalize(p.glob.String()); expr != p.glob.String() {
+			// A mark composed into a class could change its meaning, so those stay
+			if glob, err := regexp.Compile(expr); err == nil {
+				p.glob, p.literal = glob, form.Normalize(p.literal)
+			}
+		}
+		normalized.patterns[i] = p
+	}
+	return &normalized
+}
@@ -0,0 +1,173 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// largestOmissions is how many of the biggest omitted entries are listed
+const largestOmissions = 10
+
+// Omission is an entry left out of the snapshot, or a file cut short,
+// because of a limit rather than by a pattern or built-in rule
+type Omission struct {
+	path   string
+	reason SkipReason
+	rule   string // The limit that was hit, e.g. "> 52428800 bytes"
+	size   int64
+	kept   int // For a file cut short, the lines kept of its lines
+	lines  int
+}
+
+// cut reports whether the file is in the snapshot with only some of its lines
+func (o Omission) cut() bool {
+	return o.lines > 0
+}
+
+// limitReasons lists the skip reasons caused by limits
+var limitReasons = map[SkipReason]bool{
+	SkipTooLarge:    true,
+	SkipUnreadable:  true,
+	SkipEntryLimit:  true,
+	SkipTokenBudget: true,
+}
+
+// recordOmission keeps entries skipped by a limit for the Omissions section
+func (r *ScanReport) recordOmission(name string, d SkipDecision) {
+	if !limitReasons[d.reason] {
+		return
+	}
+	r.omissions = append(r.omissions, Omission{path: name, reason: d.reason, rule: d.rule, size: d.size})
+}
+
+// writeOmissions appends the section telling the consumer the snapshot is partial
+func writeOmissions(report *ScanReport, msg i18n.Printer, output io.Writer) {
+	if len(report.omissions) == 0 {
+		return
+	}
+	fmt.Fprintln(output, "<Omissions>")
+	writeOmissionSummary(report, msg, output)
+	fmt.Fprintln(output, "</Omissions>")
+}
+
+// writeOmissionSummary writes the counts per reason, the largest omitted
+// entries and the files cut short
+func writeOmissionSummary(report *ScanReport, msg i18n.Printer, output io.Writer) {
+	omitted := make([]Omission, 0, len(report.omissions))
+	cuts := make([]Omission, 0)
+	for _, o := range report.omissions {
+		if o.cut() {
+			cuts = append(cuts, o)
+		} else {
+			omitted = append(omitted, o)
+		}
+	}
+	if len(omitted) > 0 {
+		writeOmitted(omitted, msg, output)
+	} else {
+		msg.Fprintf(output, plural(len(cuts),
+			"This snapshot is partial: %d file was cut short by limits.\n",
+			"This snapshot is partial: %d files were cut short by limits.\n"), len(cuts))
+	}
+	if len(cuts) == 0 {
+		return
+	}
+	sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].size > cuts[j].size })
+	fmt.Fprintln(output, msg.Sprintf("Files cut short:"))
+	for _, o := range cuts {
+		msg.Fprintf(output, "%s | %s | %d of %d lines, %d bytes\n", o.path, o.reason, o.kept, o.lines, o.size)
+	}
+}
+
+// writeOmitted writes the counts per reason and the largest of the entries
+// left out
+func writeOmitted(omissions []Omission, msg i18n.Printer, output io.Writer) {
+	type group struct {
+		reason SkipReason
+		rule   string
+		count  int
+		size   int64
+	}
+	groups := make([]*group, 0)
+	byKey := make(map[string]*group)
+	for _, o := range omissions {
+		key := o.reason.String() + "\x00" + o.rule
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{reason: o.reason, rule: o.rule}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.count++
+		g.size += o.size
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].count > groups[j].count })
+
+	msg.Fprintf(output, plural(len(omissions),
+		"This snapshot is partial: %d entry was left out by limits.\n",
+		"This snapshot is partial: %d entries were left out by limits.\n"), len(omissions))
+	for _, g := range groups {
+		msg.Fprintf(output, plural(g.count, "%s (%s): %d entry, %d bytes\n", "%s (%s): %d entries, %d bytes\n"), g.reason, g.rule, g.count, g.size)
+	}
+
+	largest := append([]Omission(nil), omissions...)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].size > largest[j].size })
+	if len(largest) > largestOmissions {
+		largest = largest[:largestOmissions]
+	}
+	fmt.Fprintln(output, msg.Sprintf("Largest omitted entries:"))
+	for _, o := range largest {
+		msg.Fprintf(output, "%s | %s | %d bytes\n", o.path, o.reason, o.size)
+	}
+}
+
+// plural picks the singular or plural form for n
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// OverLimits returns the number of entries left out of the output by
+// MaxFileSize, MaxFiles, MaxEntriesPerDir or MaxTokens, or cut short by MaxTokens
+func (t *Tree) OverLimits() int {
+	n := 0
+	for _, o := range t.report.omissions {
+		if o.reason == SkipTooLarge || o.reason == SkipEntryLimit || o.reason == SkipTokenBudget {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTruncated lists the directories cut short by MaxFiles or
+// MaxEntriesPerDir, writing nothing when no limit was hit
+func (t *Tree) WriteTruncated(w io.Writer) {
+	total := 0
+	dirs := make([]string, 0)
+	counts := make([]int, 0)
+	var visit func(node *TreeNode, name string)
+	visit = func(node *TreeNode, name string) {
+		if node.more > 0 {
+			total += node.more
+			dirs = append(dirs, name)
+			counts = append(counts, node.more)
+		}
+		for _, child := range node.children {
+			visit(child, path.Join(name, child.name))
+		}
+	}
+	visit(t.root, ".")
+	if total == 0 {
+		return
+	}
+	t.msg.Fprintf(w, plural(total, "Entry limits left out %d entry:\n", "Entry limits left out %d entries:\n"), total)
+	for i, dir := range dirs {
+		fmt.Fprintf(w, "  %s/ %s\n", dir, moreLabel(counts[i], t.msg))
+	}
+}
@@ -0,0 +1,274 @@
+package mapper
+
+// Version is the version of the mapper API, following semantic versioning
+const Version = "1.1.0"
+
+// Option sets one aspect of Options, see NewOptions
+type Option func(*Options)
+
+// NewOptions returns Options with the default tree policy and the given
+// options applied in order. Unlike a literal Options, the result keeps
+// working unchanged as fields are added in later versions.
+func NewOptions(options ...Option) *Options {
+	opts := &Options{TreePolicy: DefaultTreePolicy()}
+	for _, option := range options {
+		option(opts)
+	}
+	return opts
+}
+
+// WithPatterns uses an ignore or filter pattern list, e.g. from LoadPatterns
+func WithPatterns(patterns *PatternList) Option {
+	return func(o *Options) { o.Patterns = patterns }
+}
+
+// WithInclude maps only the entries matching include as well, on top of
+// the ignore or filter patterns
+func WithInclude(include *PatternList) Option {
+	return func(o *Options) { o.Include = include }
+}
+
+// WithGitignore also skips entries excluded by .gitignore files
+func WithGitignore() Option {
+	return func(o *Options) { o.RespectGitignore = true }
+}
+
+// WithNestedPatterns also applies the .project_structure_ignore files of
+// subdirectories, relative to their directory
+func WithNestedPatterns() Option {
+	return func(o *Options) { o.NestedPatterns = true }
+}
+
+// WithIgnoreCase matches patterns and .gitignore files case-insensitively
+func WithIgnoreCase() Option {
+	return func(o *Options) { o.IgnoreCase = true }
+}
+
+// WithNormalForm brings names and patterns into the Unicode normal form, so
+// names written by macOS match patterns written elsewhere
+func WithNormalForm(form NormalForm) Option {
+	return func(o *Options) { o.Normalize = form }
+}
+
+// WithVisibility renders the entries skipped for reason as shown or hidden
+func WithVisibility(reason SkipReason, visibility Visibility) Option {
+	return func(o *Options) {
+		if o.TreePolicy == nil {
+			o.TreePolicy = DefaultTreePolicy()
+		}
+		o.TreePolicy[reason] = visibility
+	}
+}
+
+// WithMaxFileSize lists files larger than size bytes without their content
+func WithMaxFileSize(size int64) Option {
+	return func(o *Options) { o.MaxFileSize = size }
+}
+
+// WithHeadTail includes the first head and last tail lines of text files
+// larger than MaxFileSize instead of leaving their content out
+func WithHeadTail(head, tail int) Option {
+	return func(o *Options) { o.HeadLines, o.TailLines = head, tail }
+}
+
+// WithOutline emits an outline instead of the content of files longer than lines
+func WithOutline(lines int) Option {
+	return func(o *Options) { o.OutlineOver = lines }
+}
+
+// WithWorkers transforms up to n files concurrently while rendering
+func WithWorkers(n int) Option {
+	return func(o *Options) { o.Workers = n }
+}
+
+// WithJobs reads directories and checks their entries on up to n goroutines
+// while scanning; the tree is the same for any n
+func WithJobs(n int) Option {
+	return func(o *Options) { o.Jobs = n }
+}
+
+// WithQuery keeps the content of only the top files most relevant to query,
+// or of the default 10 when top is 0
+func WithQuery(query string, top int) Option {
+	return func(o *Options) { o.Query, o.QueryTop = query, top }
+}
+
+// WithIndex reads the word counts used by WithQuery from index where it is up to date
+func WithIndex(index *Index) Option {
+	return func(o *Options) { o.Index = index }
+}
+
+// WithSemanticQuery keeps the content of only the top files whose embeddings
+// by e are most similar to the query's, or of the default 10 when top is 0
+func WithSemanticQuery(query string, top int, e Embedder) Option {
+	return func(o *Options) { o.SemanticQuery, o.QueryTop, o.Embedder = query, top, e }
+}
+
+// WithChurn annotates files with the number of commits touching them in
+// the last days, which also raises their rank for queries. It reads the git
+// history, so it only applies to Scan.
+func WithChurn(days int) Option {
+	return func(o *Options) { o.ChurnDays = days }
+}
+
+// WithSymlinksShown lists symlinks with their target instead of following
+// them, including the content of each file target once if withContent is set
+func WithSymlinksShown(withContent bool) Option {
+	return func(o *Options) { o.ShowSymlinks, o.SymlinkContent = true, withContent }
+}
+
+// WithEntryLimits caps the files mapped and the entries shown per directory,
+// leaving the rest out as an ellipsis; 0 means no limit
+func WithEntryLimits(maxFiles, maxEntriesPerDir int) Option {
+	return func(o *Options) { o.MaxFiles, o.MaxEntriesPerDir = maxFiles, maxEntriesPerDir }
+}
+
+// WithAnnotations merges findings of external tools, such as those read by
+// LoadAnnotations, into the entries of their paths
+func WithAnnotations(annotations ...Annotation) Option {
+	return func(o *Options) { o.Annotations = append(o.Annotations, annotations...) }
+}
+
+// WithFindings counts the findings of linters and scanners, such as those
+// read by LoadSARIF, on their files, also listing them all when appendix is set
+func WithFindings(appendix bool, findings ...Annotation) Option {
+	return func(o *Options) { o.Findings, o.FindingsAppendix = append(o.Findings, findings...), appendix }
+}
+
+// WithHidden sets whether entries whose name starts with a dot are mapped
+func WithHidden(policy HiddenPolicy) Option {
+	return func(o *Options) { o.Hidden = policy }
+}
+
+// WithSkipLists replaces the built-in lists of skipped directories,
+// extensions and files, see DefaultSkipLists
+func WithSkipLists(lists *SkipLists) Option {
+	return func(o *Options) { o.SkipLists = lists }
+}
+
+// WithDependencies adds the Dependencies section
+func WithDependencies() Option {
+	return func(o *Options) { o.Dependencies = true }
+}
+
+// WithDeployment adds the Deployment_Surface section, omitting the content of
+// the summarized files when summaryOnly is set
+func WithDeployment(summaryOnly bool) Option {
+	return func(o *Options) { o.Deployment, o.InfraSummaryOnly = true, summaryOnly }
+}
+
+// WithInterfaces hoists proto and OpenAPI files into the Interfaces section
+func WithInterfaces() Option {
+	return func(o *Options) { o.Interfaces = true }
+}
+
+// WithConsolidatedMigrations replaces migration directories with a Schema section
+func WithConsolidatedMigrations() Option {
+	return func(o *Options) { o.ConsolidateMigrations = true }
+}
+
+// WithTestPairs annotates source files with their tests and vice versa
+func WithTestPairs() Option {
+	return func(o *Options) { o.PairTests = true }
+}
+
+// WithReadmes describes each directory by the first heading or paragraph of
+// its README and puts the README first within the directory
+func WithReadmes() Option {
+	return func(o *Options) { o.HoistReadmes = true }
+}
+
+// WithFileIndex ends the output with an alphabetical index of the files and
+// the line or heading their content starts at
+func WithFileIndex() Option {
+	return func(o *Options) { o.FileIndex = true }
+}
+
+// WithDelimited frames each section of text output with --BEGIN and --END
+// sentinel lines giving its length instead of tags, so parsers need not guess
+// where content ends
+func WithDelimited() Option {
+	return func(o *Options) { o.Delimited = true }
+}
+
+// WithFooter ends text output with a footer recording the files, length and
+// checksum of the body, so VerifySnapshot can detect a truncated snapshot
+func WithFooter() Option {
+	return func(o *Options) { o.Footer = true }
+}
+
+// WithShowExcluded lists the entries skipped by patterns, the built-in lists
+// or as hidden in the tree, marked as excluded, without their contents
+func WithShowExcluded() Option {
+	return func(o *Options) { o.ShowExcluded = true }
+}
+
+// WithSummaryHeader starts text output with a summary of the files included
+// and the entries skipped for each reason
+func WithSummaryHeader() Option {
+	return func(o *Options) { o.SummaryHeader = true }
+}
+
+// WithStructureOnly renders only the directory structure
+func WithStructureOnly() Option {
+	return func(o *Options) { o.StructureOnly = true }
+}
+
+// WithDiagramDepth limits the diagram formats to depth levels below the root
+func WithDiagramDepth(depth int) Option {
+	return func(o *Options) { o.DiagramDepth = depth }
+}
+
+// WithLanguage writes notes, summaries and labels in lang, e.g. "es" or "ja"
+func WithLanguage(lang string) Option {
+	return func(o *Options) { o.Language = lang }
+}
+
+// WithCheckpoint saves progress to cp so an interrupted run can resume
+func WithCheckpoint(cp *Checkpoint) Option {
+	return func(o *Options) { o.Checkpoint = cp }
+}
+
+// WithCache shares file hashes and pattern files between scans
+func WithCache(cache *SharedCache) Option {
+	return func(o *Options) { o.Cache = cache }
+}
+
+// WithMaxTokens drops and cuts files so the tree and contents fit in
+// budget tokens, see Options.MaxTokens
+func WithMaxTokens(budget int64) Option {
+	return func(o *Options) { o.MaxTokens = budget }
+}
+
+// WithTokenizer counts tokens with tok instead of estimating them from sizes
+func WithTokenizer(tok Tokenizer) Option {
+	return func(o *Options) { o.Tokenizer = tok }
+}
+
+// WithContentCache reuses what cache learned of unchanged files in earlier
+// runs, such as whether they are binary and their outlines, see LoadContentCache
+func WithContentCache(cache *ContentCache) Option {
+	return func(o *Options) { o.ContentCache = cache }
+}
+
+// WithSkipHandler calls fn with every entry left out of the tree and the
+// rule that decided it, as worded by Explain
+func WithSkipHandler(fn func(name string, isDir bool, rule string)) Option {
+	return func(o *Options) { o.OnSkip = fn }
+}
+
+// WithExcludePath never maps the slash-separated path below the root
+func WithExcludePath(name string) Option {
+	return func(o *Options) { o.ExcludePath = name }
+}
+
+// WithUnreadableHandler calls fn with the path of every file skipped as unreadable
+func WithUnreadableHandler(fn func(name string)) Option {
+	return func(o *Options) { o.OnUnreadable = fn }
+}
+
+// WithProgressHandler calls fn as the walk enters each directory, e.g. to
+// show how far a long scan got
+func WithProgressHandler(fn func(dir string, files int)) Option {
+	return func(o *Options) { o.OnProgress = fn }
+}
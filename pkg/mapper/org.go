@@ -0,0 +1,216 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// OrgSummary aggregates the trees of many repositories into a cross-repo
+// summary of their sizes, languages and dependency versions
+type OrgSummary struct {
+	repos []repoSummary
+	msg   i18n.Printer
+}
+
+// repoSummary is what an OrgSummary keeps of one repository
+type repoSummary struct {
+	name      string
+	files     int
+	bytes     int64
+	languages map[string]*languageStats // By language tag, "" when unknown
+	manifests []Manifest
+}
+
+// languageStats counts the files of one language
+type languageStats struct {
+	files int
+	bytes int64
+}
+
+// NewOrgSummary returns an empty summary
+func NewOrgSummary() *OrgSummary {
+	return &OrgSummary{}
+}
+
+// Add summarizes the mapped files of tree as the repository called name
+func (s *OrgSummary) Add(name string, tree *Tree) {
+	if len(s.repos) == 0 {
+		s.msg = tree.msg
+	}
+	repo := repoSummary{name: name, languages: make(map[string]*languageStats)}
+	walkFiles(tree.root, func(node *TreeNode, path string) {
+		var size int64
+		if info, err := fs.Stat(tree.fsys, path); err == nil {
+			size = info.Size()
+		}
+		repo.files++
+		repo.bytes += size
+		lang := languageTag(node.name)
+		stats := repo.languages[lang]
+		if stats == nil {
+			stats = &languageStats{}
+			repo.languages[lang] = stats
+		}
+		stats.files++
+		stats.bytes += size
+	})
+	repo.manifests = collectManifests(tree.root, tree.fsys)
+	s.repos = append(s.repos, repo)
+}
+
+// Write writes the summary as Markdown: a table of the repositories, one of
+// the languages across them and the dependencies declared by more than one,
+// with the versions each repository asks for
+func (s *OrgSummary) Write(w io.Writer) error {
+	msg := s.msg
+	fmt.Fprintf(w, "# %s\n\n", msg.Sprintf("Organization Summary"))
+
+	var files int
+	var bytes int64
+	fmt.Fprintf(w, "| %s | %s | %s | %s |\n|---|---:|---:|---|\n", msg.Sprintf("Repository"), msg.Sprintf("Files"), msg.Sprintf("Size"), msg.Sprintf("Main languages"))
+	for _, repo := range s.repos {
+		files += repo.files
+		bytes += repo.bytes
+		fmt.Fprintf(w, "| %s | %d | %s | %s |\n", repo.name, repo.files, FormatSize(repo.bytes), strings.Join(mainLanguages(repo.languages, 3), ", "))
+	}
+	fmt.Fprintf(w, "| **%s** | **%d** | **%s** | |\n", msg.Sprintf("Total"), files, FormatSize(bytes))
+
+	s.writeLanguages(w)
+	s.writeSharedDependencies(w)
+	return nil
+}
+
+// mainLanguages returns the known languages of the most bytes, at most n
+func mainLanguages(languages map[string]*languageStats, n int) []string {
+	names := make([]string, 0, len(languages))
+	for lang := range languages {
+		if lang != "" {
+			names = append(names, lang)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := languages[names[i]], languages[names[j]]
+		if a.bytes != b.bytes {
+			return a.bytes > b.bytes
+		}
+		return names[i] < names[j]
+	})
+	return names[:min(n, len(names))]
+}
+
+// writeLanguages writes the files and bytes of each language summed over
+// the repositories, largest first
+func (s *OrgSummary) writeLanguages(w io.Writer) {
+	msg := s.msg
+	total := make(map[string]*languageStats)
+	repos := make(map[string]int)
+	for _, repo := range s.repos {
+		for lang, stats := range repo.languages {
+			t := total[lang]
+			if t == nil {
+				t = &languageStats{}
+				total[lang] = t
+			}
+			t.files += stats.files
+			t.bytes += stats.bytes
+			repos[lang]++
+		}
+	}
+	if len(total) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n## %s\n\n", msg.Sprintf("Languages"))
+	fmt.Fprintf(w, "| %s | %s | %s | %s |\n|---|---:|---:|---:|\n", msg.Sprintf("Language"), msg.Sprintf("Repositories"), msg.Sprintf("Files"), msg.Sprintf("Size"))
+	names := mainLanguages(total, len(total))
+	if total[""] != nil {
+		names = append(names, "")
+	}
+	for _, lang := range names {
+		label := lang
+		if label == "" {
+			label = msg.Sprintf("(other)")
+		}
+		fmt.Fprintf(w, "| %s | %d | %d | %s |\n", label, repos[lang], total[lang].files, FormatSize(total[lang].bytes))
+	}
+}
+
+// writeSharedDependencies lists the dependencies declared by more than one
+// repository, with the repositories asking for each version. Those asked for
+// at different versions are in bold.
+func (s *OrgSummary) writeSharedDependencies(w io.Writer) {
+	type key struct{ ecosystem, name string }
+	versions := make(map[key]map[string][]string) // Repositories by version
+	for _, repo := range s.repos {
+		seen := make(map[key]map[string]bool)
+		for _, m := range repo.manifests {
+			for _, d := range m.deps {
+				k := key{m.ecosystem, d.name}
+				if seen[k] == nil {
+					seen[k] = make(map[string]bool)
+				}
+				if seen[k][d.version] {
+					continue
+				}
+				seen[k][d.version] = true
+				if versions[k] == nil {
+					versions[k] = make(map[string][]string)
+				}
+				versions[k][d.version] = append(versions[k][d.version], repo.name)
+			}
+		}
+	}
+
+	var shared []key
+	for k, byVersion := range versions {
+		repos := make(map[string]bool)
+		for _, names := range byVersion {
+			for _, name := range names {
+				repos[name] = true
+			}
+		}
+		if len(repos) > 1 {
+			shared = append(shared, k)
+		}
+	}
+	if len(shared) == 0 {
+		return
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].ecosystem != shared[j].ecosystem {
+			return shared[i].ecosystem < shared[j].ecosystem
+		}
+		return shared[i].name < shared[j].name
+	})
+
+	msg := s.msg
+	fmt.Fprintf(w, "\n## %s\n\n", msg.Sprintf("Shared Dependencies"))
+	fmt.Fprintf(w, "| %s | %s | %s |\n|---|---|---|\n", msg.Sprintf("Dependency"), msg.Sprintf("Ecosystem"), msg.Sprintf("Versions"))
+	for _, k := range shared {
+		byVersion := versions[k]
+		list := make([]string, 0, len(byVersion))
+		for version := range byVersion {
+			list = append(list, version)
+		}
+		sort.Strings(list)
+		parts := make([]string, 0, len(list))
+		for _, version := range list {
+			label := version
+			if label == "" {
+				label = msg.Sprintf("(unversioned)")
+			}
+			parts = append(parts, fmt.Sprintf("%s (%s)", label, strings.Join(byVersion[version], ", ")))
+		}
+		name := k.name
+		if len(list) > 1 {
+			name = "**" + name + "**"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", name, k.ecosystem, strings.Join(parts, "; "))
+	}
+	fmt.Fprintf(w, "\n%s\n", msg.Sprintf("Dependencies in bold are asked for at more than one version."))
+}
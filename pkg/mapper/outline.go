@@ -0,0 +1,210 @@
+package mapper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// OutlineEntry is a single line kept from a file when it is outlined
+type OutlineEntry struct {
+	line   int
+	text   string
+	member bool // Indented within a class or similar, not top-level
+}
+
+// declarationPrefixes lists the line prefixes treated as top-level declarations per extension
+var declarationPrefixes = map[string][]string{
+	".go":    {"package ", "import ", "func ", "type ", "var ", "const "},
+	".py":    {"def ", "async def ", "class ", "import ", "from "},
+	".js":    {"function ", "async function ", "class ", "export ", "import ", "const ", "let ", "var "},
+	".jsx":   {"function ", "async function ", "class ", "export ", "import ", "const ", "let ", "var "},
+	".ts":    {"function ", "async function ", "class ", "export ", "import ", "interface ", "type ", "enum ", "const "},
+	".tsx":   {"function ", "async function ", "class ", "export ", "import ", "interface ", "type ", "enum ", "const "},
+	".rs":    {"fn ", "pub ", "struct ", "enum ", "trait ", "impl ", "mod ", "use ", "type ", "const ", "static "},
+	".java":  {"public ", "private ", "protected ", "class ", "interface ", "enum ", "package ", "import "},
+	".kt":    {"fun ", "class ", "object ", "interface ", "data class ", "package ", "import "},
+	".rb":    {"def ", "class ", "module ", "require "},
+	".c":     {"#include", "#define", "struct ", "typedef ", "static ", "void ", "int ", "char "},
+	".h":     {"#include", "#define", "struct ", "typedef ", "extern "},
+	".cpp":   {"#include", "#define", "class ", "struct ", "namespace ", "template", "void ", "int "},
+	".cs":    {"using ", "namespace ", "public ", "private ", "internal ", "class ", "interface "},
+	".php":   {"function ", "class ", "interface ", "trait ", "namespace ", "use "},
+	".sh":    {"function "},
+	".swift": {"func ", "class ", "struct ", "enum ", "protocol ", "extension ", "import "},
+}
+
+// memberPrefixes lists the prefixes of indented lines treated as the
+// declarations of class members, such as methods, per extension
+var memberPrefixes = map[string][]string{
+	".py":    {"def ", "async def ", "class "},
+	".rb":    {"def ", "class ", "module "},
+	".js":    {"static ", "async ", "get ", "set ", "constructor("},
+	".jsx":   {"static ", "async ", "get ", "set ", "constructor("},
+	".ts":    {"public ", "private ", "protected ", "static ", "async ", "readonly ", "get ", "set ", "constructor("},
+	".tsx":   {"public ", "private ", "protected ", "static ", "async ", "readonly ", "get ", "set ", "constructor("},
+	".rs":    {"fn ", "pub fn ", "pub(crate) fn ", "async fn ", "pub async fn "},
+	".java":  {"public ", "private ", "protected ", "static ", "abstract "},
+	".kt":    {"fun ", "override fun ", "private fun ", "protected fun ", "internal fun ", "public fun ", "suspend fun ", "class ", "object ", "data class "},
+	".cs":    {"public ", "private ", "protected ", "internal ", "static ", "override "},
+	".php":   {"public ", "private ", "protected ", "static ", "abstract ", "function "},
+	".swift": {"func ", "init(", "static func ", "class func ", "private func ", "public func ", "internal func ", "override func ", "mutating func "},
+}
+
+// headerExtensions lists file types whose outline is built from section headers
+var headerExtensions = map[string]string{
+	".md":       "#",
+	".markdown": "#",
+	".rst":      "==",
+	".ini":      "[",
+	".toml":     "[",
+	".cfg":      "[",
+}
+
+// countLines returns the number of lines in r
+func countLines(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+	}
+	return lines, scanner.Err()
+}
+
+// buildOutline collects the declaration and header lines of a file, with
+// the declarations of class members kept indented
+func buildOutline(name string, r io.Reader) ([]OutlineEntry, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	prefixes := declarationPrefixes[ext]
+	members := memberPrefixes[ext]
+	header, isHeaderType := headerExtensions[ext]
+
+	entries := make([]OutlineEntry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if isHeaderType {
+			if strings.HasPrefix(trimmed, header) {
+				entries = append(entries, OutlineEntry{line: lineNo, text: trimmed})
+			}
+			continue
+		}
+
+		// Only unindented lines count as top-level
+		if line[0] == ' ' || line[0] == '\t' {
+			for _, prefix := range members {
+				if strings.HasPrefix(trimmed, prefix) {
+					entries = append(entries, OutlineEntry{line: lineNo, text: strings.TrimRight(line, " \t\r"), member: true})
+					break
+				}
+			}
+			continue
+		}
+
+		if prefixes == nil {
+			// Unknown type: keep lines that open a block
+			if strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, ":") {
+				entries = append(entries, OutlineEntry{line: lineNo, text: trimmed})
+			}
+			continue
+		}
+
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(trimmed, prefix) {
+				entries = append(entries, OutlineEntry{line: lineNo, text: trimmed})
+				break
+			}
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// commentPrefixes start the unindented comment and annotation lines kept
+// with the declaration below them
+var commentPrefixes = []string{"//", "/*", "*", "#", "@", "--"}
+
+// declarationStarts returns the lines, counting from 1, where the top-level
+// declarations of a file start, as its outline finds them, moved up over the
+// comments right above each, so content can be cut between declarations
+func declarationStarts(name string, content []byte) []int {
+	entries, err := buildOutline(name, bytes.NewReader(content))
+	if err != nil || len(entries) == 0 {
+		return nil
+	}
+	_, isHeaderType := headerExtensions[strings.ToLower(filepath.Ext(name))]
+	lines := bytes.Split(content, []byte("\n"))
+	starts := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if entry.member {
+			continue
+		}
+		start := entry.line
+		for !isHeaderType && start > 1 && isCommentLine(lines[start-2]) {
+			start--
+		}
+		if len(starts) == 0 || start > starts[len(starts)-1] {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// isCommentLine reports whether line is a comment or annotation at the top level
+func isCommentLine(line []byte) bool {
+	trimmed := string(bytes.TrimRight(line, " \t\r"))
+	if strings.HasPrefix(trimmed, " *") {
+		trimmed = trimmed[1:] // Inside a block comment
+	}
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutline writes the outline of a file in place of its content
+func writeOutline(output io.Writer, entries []OutlineEntry, totalLines int, msg i18n.Printer) {
+	msg.Fprintf(output, "[outline of %d lines, %d entries]\n", totalLines, len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(output, "%d: %s\n", e.line, e.text)
+	}
+}
+
+// writeOutlineIfLong writes an outline when content exceeds the configured line count
+// and reports whether it did so
+func writeOutlineIfLong(output io.Writer, name string, content []byte, opts *Options, msg i18n.Printer) bool {
+	if opts.OutlineOver <= 0 {
+		return false
+	}
+
+	lines, err := countLines(bytes.NewReader(content))
+	if err != nil || lines <= opts.OutlineOver {
+		return false
+	}
+
+	entries, err := buildOutline(path.Base(name), bytes.NewReader(content))
+	if err != nil {
+		i18n.Warnf("Could not outline file %s: %v", name, err)
+		return false
+	}
+
+	writeOutline(output, entries, lines, msg)
+	return true
+}
@@ -0,0 +1,346 @@
+package mapper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+)
+
+// parquetMagic starts and ends a Parquet file
+const parquetMagic = "PAR1"
+
+// Parquet physical types, repetitions and encodings used by FormatParquet
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+// parquetColumn holds the values of one column of the file inventory. Nil
+// entries of an optional column are nulls.
+type parquetColumn struct {
+	name     string
+	kind     int
+	optional bool
+	text     bool // UTF-8 strings rather than raw bytes
+	values   []any
+}
+
+// fileMetrics is one row of the inventory written by FormatParquet
+type fileMetrics struct {
+	path     string
+	size     int64
+	lines    any // int64, nil when the content was not read
+	language any // string, nil when unknown
+	churn    any // int64, nil unless churn was collected
+	tokens   any // int64, nil when the content was not read
+	omitted  bool
+}
+
+// renderParquet writes a Parquet file with one row per file: its path,
+// size, line count, language, commits in the churn window and estimated
+// tokens. Directories are left out.
+func (t *Tree) renderParquet(w io.Writer) error {
+	var rows []fileMetrics
+	t.walkEntries(t.root, ".", func(node *TreeNode, name string) {
+		if !node.isDir {
+			rows = append(rows, t.fileMetrics(node, name))
+		}
+	})
+
+	columns := []*parquetColumn{
+		{name: "path", kind: parquetByteArray, text: true},
+		{name: "size", kind: parquetInt64},
+		{name: "lines", kind: parquetInt64, optional: true},
+		{name: "language", kind: parquetByteArray, optional: true, text: true},
+		{name: "churn", kind: parquetInt64, optional: true},
+		{name: "tokens", kind: parquetInt64, optional: true},
+		{name: "omitted", kind: parquetBoolean},
+	}
+	for _, r := range rows {
+		for i, v := range []any{r.path, r.size, r.lines, r.language, r.churn, r.tokens, r.omitted} {
+			columns[i].values = append(columns[i].values, v)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString(parquetMagic)
+	chunks := make([]parquetChunk, len(columns))
+	for i, c := range columns {
+		chunks[i] = c.writeChunk(&out)
+	}
+	meta := parquetFileMetaData(columns, chunks, int64(len(rows)))
+	out.Write(meta)
+	out.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta))))
+	out.WriteString(parquetMagic)
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// fileMetrics measures the file node, found at name
+func (t *Tree) fileMetrics(node *TreeNode, name string) fileMetrics {
+	m := fileMetrics{path: name, omitted: node.omitted}
+	if info, err := fs.Stat(t.fsys, name); err == nil {
+		m.size = info.Size()
+	}
+	if lang := languageTag(node.name); lang != "" {
+		m.language = lang
+	}
+	if t.churn != nil {
+		m.churn = int64(t.churn[name])
+	}
+	if node.omitted {
+		return m
+	}
+	data, release, err := readContent(t.fsys, name)
+	if err != nil {
+		return m
+	}
+	defer release()
+	if lines, err := countLines(bytes.NewReader(data)); err == nil {
+		m.lines = int64(lines)
+	}
+	m.tokens = t.opts.countTokens(data)
+	return m
+}
+
+// parquetChunk locates a written column chunk
+type parquetChunk struct {
+	offset int64 // Of the page header
+	size   int64 // Page header and page
+	values int
+}
+
+// writeChunk appends the column as a single uncompressed PLAIN data page
+func (c *parquetColumn) writeChunk(out *bytes.Buffer) parquetChunk {
+	var page []byte
+	if c.optional {
+		levels := parquetDefinitionLevels(c.values)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+	page = c.appendValues(page)
+
+	var header thriftWriter
+	header.i32(1, 0) // DATA_PAGE
+	header.i32(2, int32(len(page)))
+	header.i32(3, int32(len(page)))
+	header.beginStruct(5)
+	header.i32(1, int32(len(c.values)))
+	header.i32(2, parquetPlain)
+	header.i32(3, parquetRLE)
+	header.i32(4, parquetRLE)
+	header.endStruct()
+	header.stop()
+
+	chunk := parquetChunk{offset: int64(out.Len()), size: int64(len(header.buf) + len(page)), values: len(c.values)}
+	out.Write(header.buf)
+	out.Write(page)
+	return chunk
+}
+
+// appendValues appends the non-null values of the column in PLAIN encoding
+func (c *parquetColumn) appendValues(page []byte) []byte {
+	var bits, nbits byte
+	for _, v := range c.values {
+		switch v := v.(type) {
+		case string:
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+			page = append(page, v...)
+		case int64:
+			page = binary.LittleEndian.AppendUint64(page, uint64(v))
+		case bool:
+			// Booleans are bit-packed, least significant bit first
+			if v {
+				bits |= 1 << nbits
+			}
+			if nbits++; nbits == 8 {
+				page = append(page, bits)
+				bits, nbits = 0, 0
+			}
+		}
+	}
+	if nbits > 0 {
+		page = append(page, bits)
+	}
+	return page
+}
+
+// parquetDefinitionLevels encodes whether each value is present as runs of
+// the RLE/bit-packing hybrid encoding with a bit width of 1
+func parquetDefinitionLevels(values []any) []byte {
+	var out []byte
+	for i := 0; i < len(values); {
+		level := byte(0)
+		if values[i] != nil {
+			level = 1
+		}
+		j := i + 1
+		for j < len(values) && (values[j] != nil) == (level == 1) {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		out = append(out, level)
+		i = j
+	}
+	return out
+}
+
+// parquetFileMetaData encodes the footer describing a single row group
+func parquetFileMetaData(columns []*parquetColumn, chunks []parquetChunk, rows int64) []byte {
+	var m thriftWriter
+	m.i32(1, 1) // Version
+
+	m.beginList(2, thriftStruct, len(columns)+1)
+	m.beginElement()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(columns)))
+	m.endStruct()
+	for _, c := range columns {
+		m.beginElement()
+		m.i32(1, int32(c.kind))
+		repetition := parquetRequired
+		if c.optional {
+			repetition = parquetOptional
+		}
+		m.i32(3, int32(repetition))
+		m.binary(4, c.name)
+		if c.text {
+			m.i32(6, 0) // UTF8
+			m.beginStruct(10)
+			m.beginStruct(1) // STRING
+			m.endStruct()
+			m.endStruct()
+		}
+		m.endStruct()
+	}
+
+	m.i64(3, rows)
+
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	m.beginList(4, thriftStruct, 1)
+	m.beginElement()
+	m.beginList(1, thriftStruct, len(columns))
+	for i, c := range columns {
+		ch := chunks[i]
+		m.beginElement()
+		m.i64(2, ch.offset)
+		m.beginStruct(3)
+		m.i32(1, int32(c.kind))
+		m.beginList(2, thriftI32, 2)
+		m.listI32(parquetPlain)
+		m.listI32(parquetRLE)
+		m.beginList(3, thriftBinary, 1)
+		m.listBinary(c.name)
+		m.i32(4, 0) // UNCOMPRESSED
+		m.i64(5, int64(ch.values))
+		m.i64(6, ch.size)
+		m.i64(7, ch.size)
+		m.i64(9, ch.offset)
+		m.endStruct()
+		m.endStruct()
+	}
+	m.i64(2, total)
+	m.i64(3, rows)
+	m.endStruct()
+
+	m.binary(6, "dirMapper")
+	m.stop()
+	return m.buf
+}
+
+// Thrift compact protocol types used in Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter appends structs in the Thrift compact protocol. Field ids are
+// written as deltas from the previous field of the same struct, so the last
+// id of every enclosing struct is kept.
+type thriftWriter struct {
+	buf  []byte
+	last []int
+	id   int
+}
+
+func (t *thriftWriter) field(id, kind int) {
+	if delta := id - t.id; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta<<4|kind))
+	} else {
+		t.buf = append(t.buf, byte(kind))
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	t.id = id
+}
+
+func (t *thriftWriter) i32(id int, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+// beginStruct starts a struct field, ended by endStruct
+func (t *thriftWriter) beginStruct(id int) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+// beginElement starts a struct that is a list element, ended by endStruct
+func (t *thriftWriter) beginElement() {
+	t.last = append(t.last, t.id)
+	t.id = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.id = t.last[len(t.last)-1]
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop ends the fields of the current struct
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
+
+// beginList starts a list field of n elements of kind, which follow it
+func (t *thriftWriter) beginList(id, kind, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n<<4|kind))
+	} else {
+		t.buf = append(t.buf, byte(0xF0|kind))
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) listBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
@@ -0,0 +1,259 @@
+package mapper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// thriftReader decodes the Thrift compact protocol independently of
+// thriftWriter. Structs decode to maps by field id, lists to slices,
+// integers to int64 and binaries to strings.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		panic(fmt.Sprintf("bad varint at %d", r.pos))
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(kind byte) any {
+	switch kind {
+	case 1, 2: // Booleans are held in the field type
+		return kind == 1
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0F)
+		}
+		return list
+	case thriftStruct:
+		return r.fields()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d at %d", kind, r.pos))
+}
+
+func (r *thriftReader) fields() map[int]any {
+	fields := make(map[int]any)
+	id := 0
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		if delta := int(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int(r.zigzag())
+		}
+		fields[id] = r.value(header & 0x0F)
+	}
+}
+
+// parquetRow is what the test expects of a row, tokens being checked only
+// for presence
+type parquetRow struct {
+	path     string
+	size     int64
+	lines    any
+	language any
+	tokens   bool
+	omitted  bool
+}
+
+// readParquet decodes the footer and every column page of a file written by
+// renderParquet, returning the schema names and the columns' values
+func readParquet(t *testing.T, file []byte) (names []string, columns [][]any, rows int64) {
+	t.Helper()
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatal("missing PAR1 magic")
+	}
+	n := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := &thriftReader{data: file[len(file)-8-n : len(file)-8]}
+	meta := footer.fields()
+	if footer.pos != n {
+		t.Fatalf("footer decoded %d of %d bytes", footer.pos, n)
+	}
+	if meta[1] != int64(1) {
+		t.Errorf("version = %v, want 1", meta[1])
+	}
+	rows = meta[3].(int64)
+
+	schema := meta[2].([]any)
+	root := schema[0].(map[int]any)
+	if root[4] != "schema" || root[5] != int64(len(schema)-1) {
+		t.Errorf("root schema element = %v", root)
+	}
+	var optional []bool
+	for _, e := range schema[1:] {
+		element := e.(map[int]any)
+		names = append(names, element[4].(string))
+		optional = append(optional, element[3] == int64(parquetOptional))
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("%d row groups, want 1", len(groups))
+	}
+	group := groups[0].(map[int]any)
+	if group[3] != rows {
+		t.Errorf("row group has %v rows, want %d", group[3], rows)
+	}
+	var total int64
+	for i, c := range group[1].([]any) {
+		chunk := c.(map[int]any)[3].(map[int]any)
+		if path := chunk[3].([]any); len(path) != 1 || path[0] != names[i] {
+			t.Errorf("column %d has path %v, want [%s]", i, path, names[i])
+		}
+		if chunk[5] != rows {
+			t.Errorf("column %s has %v values, want %d", names[i], chunk[5], rows)
+		}
+		offset, size := chunk[9].(int64), chunk[7].(int64)
+		total += size
+
+		page := &thriftReader{data: file[offset : offset+size]}
+		header := page.fields()
+		if header[1] != int64(0) || header[2] != header[3] {
+			t.Errorf("column %s has page header %v", names[i], header)
+		}
+		if int64(page.pos)+header[3].(int64) != size {
+			t.Errorf("column %s: header of %d bytes and page of %v do not make the chunk of %d", names[i], page.pos, header[3], size)
+		}
+		columns = append(columns, decodePage(page.data[page.pos:], chunk[1].(int64), optional[i], int(rows)))
+	}
+	if group[2] != total {
+		t.Errorf("row group size = %v, want %d", group[2], total)
+	}
+	return names, columns, rows
+}
+
+// decodePage reads the PLAIN values of a data page, after its definition
+// levels when the column is optional
+func decodePage(page []byte, kind int64, optional bool, rows int) []any {
+	present := make([]bool, rows)
+	for i := range present {
+		present[i] = true
+	}
+	if optional {
+		n := int(binary.LittleEndian.Uint32(page))
+		levels := &thriftReader{data: page[4 : 4+n]}
+		for i := 0; levels.pos < n; {
+			run := int(levels.uvarint() >> 1)
+			level := levels.byte()
+			for ; run > 0; run-- {
+				present[i] = level == 1
+				i++
+			}
+		}
+		page = page[4+n:]
+	}
+
+	values := make([]any, rows)
+	pos, bit := 0, 0
+	for i := range values {
+		if !present[i] {
+			continue
+		}
+		switch kind {
+		case parquetByteArray:
+			n := int(binary.LittleEndian.Uint32(page[pos:]))
+			values[i] = string(page[pos+4 : pos+4+n])
+			pos += 4 + n
+		case parquetInt64:
+			values[i] = int64(binary.LittleEndian.Uint64(page[pos:]))
+			pos += 8
+		case parquetBoolean:
+			values[i] = page[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		}
+	}
+	return values
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logo.png":  {Data: []byte("\x89PNG\r\n\x1a\n")},
+		"notes.xyz": {Data: []byte("one\ntwo\n")},
+	}
+	want := []parquetRow{
+		{"logo.png", 8, nil, nil, false, true},
+		{"notes.xyz", 8, int64(2), nil, true, false},
+	}
+	// More rows than fit in a byte of booleans
+	for i := range 9 {
+		name := fmt.Sprintf("src/f%d.go", i)
+		content := "package src\n" + strings.Repeat("// line\n", i)
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+		want = append(want, parquetRow{name, int64(len(content)), int64(i + 1), "go", true, false})
+	}
+
+	tree, err := ScanFS(fsys, "project", &Options{TreePolicy: DefaultTreePolicy()})
+	if err != nil {
+		t.Fatalf("ScanFS: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tree.Render(&out, FormatParquet); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	names, columns, rows := readParquet(t, out.Bytes())
+
+	if wantNames := []string{"path", "size", "lines", "language", "churn", "tokens", "omitted"}; !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("columns = %v, want %v", names, wantNames)
+	}
+	if rows != int64(len(want)) {
+		t.Fatalf("%d rows, want %d", rows, len(want))
+	}
+	byPath := make(map[string]int)
+	for i, p := range columns[0] {
+		byPath[p.(string)] = i
+	}
+	for _, w := range want {
+		i, ok := byPath[w.path]
+		if !ok {
+			t.Errorf("no row for %s", w.path)
+			continue
+		}
+		got := parquetRow{columns[0][i].(string), columns[1][i].(int64), columns[2][i], columns[3][i], columns[5][i] != nil, columns[6][i].(bool)}
+		if got != w {
+			t.Errorf("row %+v, want %+v", got, w)
+		}
+		if columns[4][i] != nil {
+			t.Errorf("%s has churn %v without churn collected", w.path, columns[4][i])
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package mapper
+
+import (
+	"io/fs"
+	"os"
+)
+
+// dirFS returns the filesystem of the directory root
+func dirFS(root string) fs.FS {
+	return os.DirFS(root)
+}
+
+// junction reports false, since only Windows has junctions; symlinks to
+// directories are handled as such
+func (r *ScanReport) junction(name string, entry fs.DirEntry) (string, bool) {
+	return "", false
+}
+
+// volumeFileID is only needed on Windows, where inode is not supported
+func volumeFileID(name string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,69 @@
+package mapper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// dirFS returns the filesystem of the directory root. Its paths carry the
+// \\?\ prefix, so entries deeper than MAX_PATH (260 characters) can be
+// opened whether or not long paths are enabled on the system.
+func dirFS(root string) fs.FS {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return os.DirFS(root)
+	}
+	switch {
+	case strings.HasPrefix(abs, `\\?\`):
+		return os.DirFS(abs)
+	case strings.HasPrefix(abs, `\\`):
+		return os.DirFS(`\\?\UNC\` + abs[2:]) // \\server\share
+	}
+	return os.DirFS(`\\?\` + abs)
+}
+
+// junction returns the target of the directory at name when it is a
+// junction or another mount point, which Windows lists as a directory
+func (r *ScanReport) junction(name string, entry fs.DirEntry) (string, bool) {
+	if r.dir == "" || !entry.IsDir() {
+		return "", false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return "", false
+	}
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok || data.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT == 0 {
+		return "", false
+	}
+	// Other reparse points, such as cloud placeholders, are plain directories
+	target, err := os.Readlink(r.osPath(name))
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(target), true
+}
+
+// volumeFileID identifies the directory at name by volume serial number
+// and file index, through any junctions and symlinks leading to it
+func volumeFileID(name string) (string, bool) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return "", false
+	}
+	// Directories are only opened with backup semantics
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return "", false
+	}
+	defer syscall.CloseHandle(h)
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d:%d", d.VolumeSerialNumber, d.FileIndexHigh, d.FileIndexLow), true
+}
@@ -0,0 +1,131 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Visibility controls how a skipped entry appears in the tree
+type Visibility int
+
+const (
+	VisibilityDefault Visibility = iota // Defer to the tree policy
+	Hidden                              // Removed from the tree entirely
+	Listed                              // Shown in the tree, content omitted
+)
+
+// skipReasonNames maps each skip reason to the rule name used on the command line
+var skipReasonNames = map[SkipReason]string{
+	SkipPattern:         "pattern",
+	SkipBuiltinFile:     "file",
+	SkipBuiltinDir:      "dir",
+	SkipBinaryExtension: "binary",
+	SkipTooLarge:        "size",
+	SkipUnreadable:      "unreadable",
+	SkipLockfile:        "lockfile",
+	SkipEntryLimit:      "limit",
+	SkipHidden:          "hidden",
+	SkipSpecial:         "special",
+	SkipTokenBudget:     "tokens",
+}
+
+// String returns the rule name of a skip reason
+func (r SkipReason) String() string {
+	if name, ok := skipReasonNames[r]; ok {
+		return name
+	}
+	return "none"
+}
+
+// HiddenPolicy controls entries whose name starts with a dot
+type HiddenPolicy int
+
+const (
+	HiddenDefault HiddenPolicy = iota // Dotfiles are mapped unless a skip list names them
+	HiddenShown                       // Dotfiles are mapped even when a built-in list names them, except .git
+	HiddenSkipped                     // Dotfiles and dot-directories are skipped
+)
+
+// TreePolicy maps skip reasons to how their entries are rendered
+type TreePolicy map[SkipReason]Visibility
+
+// DefaultTreePolicy lists binaries, oversized, unreadable and lock files while hiding everything else
+func DefaultTreePolicy() TreePolicy {
+	return TreePolicy{
+		SkipPattern:         Hidden,
+		SkipBuiltinFile:     Hidden,
+		SkipBuiltinDir:      Hidden,
+		SkipBinaryExtension: Listed,
+		SkipTooLarge:        Listed,
+		SkipUnreadable:      Listed,
+		SkipLockfile:        Listed,
+		SkipHidden:          Hidden,
+		SkipSpecial:         Listed,
+		SkipTokenBudget:     Listed,
+	}
+}
+
+// visibility returns how entries skipped for reason are rendered
+func (tp TreePolicy) visibility(reason SkipReason) Visibility {
+	if v, ok := tp[reason]; ok && v != VisibilityDefault {
+		return v
+	}
+	return Hidden
+}
+
+// String implements flag.Value
+func (tp *TreePolicy) String() string {
+	if tp == nil || *tp == nil {
+		return ""
+	}
+	rules := make([]string, 0, len(*tp))
+	for reason, v := range *tp {
+		state := "hide"
+		if v == Listed {
+			state = "show"
+		}
+		rules = append(rules, reason.String()+"="+state)
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
+}
+
+// Set implements flag.Value, accepting comma separated rule=show|hide pairs
+func (tp *TreePolicy) Set(value string) error {
+	if *tp == nil {
+		*tp = DefaultTreePolicy()
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		rule, state, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return fmt.Errorf("invalid tree policy %q, expected rule=show|hide", item)
+		}
+
+		reason, err := parseSkipReason(rule)
+		if err != nil {
+			return err
+		}
+
+		switch state {
+		case "show":
+			(*tp)[reason] = Listed
+		case "hide":
+			(*tp)[reason] = Hidden
+		default:
+			return fmt.Errorf("invalid visibility %q for rule %s, expected show or hide", state, rule)
+		}
+	}
+	return nil
+}
+
+// parseSkipReason looks up a skip reason by its rule name
+func parseSkipReason(name string) (SkipReason, error) {
+	for reason, n := range skipReasonNames {
+		if n == name {
+			return reason, nil
+		}
+	}
+	return NotSkipped, fmt.Errorf("unknown rule %q", name)
+}
@@ -0,0 +1,52 @@
+package mapper
+
+import (
+	"bytes"
+	"sync"
+)
+
+// nodeChunkSize is the number of tree nodes allocated together
+const nodeChunkSize = 1024
+
+// maxPooledBuffer is the largest buffer returned to the pool; bigger ones are
+// left to the garbage collector so one huge file doesn't pin its memory
+const maxPooledBuffer = 4 * 1024 * 1024
+
+// nodeArena hands out tree nodes from large chunks, replacing one allocation
+// per entry with one per chunk
+type nodeArena struct {
+	chunk []TreeNode
+}
+
+// newNode returns a zeroed node from the current chunk
+func (a *nodeArena) newNode() *TreeNode {
+	if a == nil {
+		return &TreeNode{}
+	}
+	if len(a.chunk) == 0 {
+		a.chunk = make([]TreeNode, nodeChunkSize)
+	}
+	n := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return n
+}
+
+// bufferPool recycles the buffers used to read and render file contents
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool
+func putBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
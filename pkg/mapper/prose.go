@@ -0,0 +1,76 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// renderProse describes the structure in indented plain sentences, one per
+// directory, for screen readers that stumble over box-drawing characters
+func (t *Tree) renderProse(w io.Writer) error {
+	t.writeProseDir(w, t.root, 0)
+	return nil
+}
+
+// writeProseDir writes the sentence describing a directory, then its subdirectories
+func (t *Tree) writeProseDir(w io.Writer, node *TreeNode, depth int) {
+	msg := t.msg
+	indent := strings.Repeat("  ", depth)
+	name := t.proseName(node)
+
+	if len(node.children) == 0 {
+		if node.omitted {
+			fmt.Fprintln(w, indent+msg.Sprintf("Directory %s is listed without its contents.", name))
+		} else {
+			fmt.Fprintln(w, indent+msg.Sprintf("Directory %s is empty.", name))
+		}
+		return
+	}
+
+	dirs, files := 0, 0
+	items := make([]string, 0, len(node.children))
+	for _, child := range node.children {
+		if child.isDir {
+			dirs++
+			items = append(items, child.name)
+		} else {
+			files++
+			items = append(items, t.proseName(child))
+		}
+	}
+
+	var counts string
+	dirCount := msg.Sprintf(plural(dirs, "%d subdirectory", "%d subdirectories"), dirs)
+	fileCount := msg.Sprintf(plural(files, "%d file", "%d files"), files)
+	switch {
+	case files == 0:
+		counts = dirCount
+	case dirs == 0:
+		counts = fileCount
+	default:
+		counts = msg.Sprintf("%s and %s", dirCount, fileCount)
+	}
+	fmt.Fprintln(w, indent+msg.Sprintf("Directory %s contains %s: %s.", name, counts, strings.Join(items, msg.Sprintf(", "))))
+
+	for _, child := range node.children {
+		if child.isDir {
+			t.writeProseDir(w, child, depth+1)
+		}
+	}
+}
+
+// proseName returns a node's name followed by its annotations in parentheses
+func (t *Tree) proseName(node *TreeNode) string {
+	notes := make([]string, 0, 2)
+	if node.omitted && !node.isDir {
+		notes = append(notes, t.msg.Sprintf("content omitted"))
+	}
+	if node.note != "" {
+		notes = append(notes, node.note)
+	}
+	if len(notes) == 0 {
+		return node.name
+	}
+	return node.name + " (" + strings.Join(notes, "; ") + ")"
+}
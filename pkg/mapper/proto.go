@@ -0,0 +1,140 @@
+package mapper
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ProtoSchema is the .proto file describing output written by FormatProtobuf
+//
+//go:embed snapshot.proto
+var ProtoSchema string
+
+// protoVersion is the version field of the Snapshot message written
+const protoVersion = 1
+
+// protoBuffer appends protobuf wire format fields
+type protoBuffer []byte
+
+// Wire types of the fields written
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protoBuffer) tag(field, wire int) {
+	*b = binary.AppendUvarint(*b, uint64(field<<3|wire))
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	if v != 0 {
+		b.tag(field, wireVarint)
+		*b = binary.AppendUvarint(*b, v)
+	}
+}
+
+func (b *protoBuffer) bool(field int, v bool) {
+	if v {
+		b.uint(field, 1)
+	}
+}
+
+// string appends a string field, replacing invalid UTF-8, which proto3
+// parsers reject in strings
+func (b *protoBuffer) string(field int, s string) {
+	if s != "" {
+		b.bytes(field, []byte(strings.ToValidUTF8(s, "�")))
+	}
+}
+
+// bytes appends a length-delimited field, even when empty
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.tag(field, wireBytes)
+	*b = binary.AppendUvarint(*b, uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+// renderProtobuf writes the tree as a Snapshot message of ProtoSchema. The
+// entries are written one at a time, so only one file's content is held in
+// memory.
+func (t *Tree) renderProtobuf(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	var header protoBuffer
+	header.uint(1, protoVersion)
+	header.string(2, t.root.name)
+	out.Write(header)
+
+	var entry, record protoBuffer
+	var err error
+	t.walkEntries(t.root, ".", func(node *TreeNode, name string) {
+		if err != nil {
+			return
+		}
+		entry = t.appendProtoEntry(entry[:0], node, name)
+		if len(entry) >= 1<<31 {
+			err = fmt.Errorf("cannot write %s: protobuf messages are limited to 2 GB", name)
+			return
+		}
+		record = record[:0]
+		record.bytes(3, entry)
+		_, err = out.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// walkEntries calls fn with node, found at name, and then every entry below
+// it in tree order
+func (t *Tree) walkEntries(node *TreeNode, name string, fn func(node *TreeNode, name string)) {
+	fn(node, name)
+	for _, child := range node.children {
+		t.walkEntries(child, path.Join(name, child.name), fn)
+	}
+}
+
+// appendProtoEntry appends the fields of the Entry message of node to b
+func (t *Tree) appendProtoEntry(b protoBuffer, node *TreeNode, name string) protoBuffer {
+	b.string(1, name)
+	b.string(2, node.name)
+	b.bool(3, node.isDir)
+	if !node.isDir {
+		if info, err := fs.Stat(t.fsys, name); err == nil {
+			// Present even when 0, since the field is optional
+			b.tag(4, wireVarint)
+			b = binary.AppendUvarint(b, uint64(info.Size()))
+		}
+	}
+	b.bool(5, node.omitted)
+	b.string(6, node.note)
+	b.string(7, node.link)
+	b.uint(8, uint64(node.more))
+	for _, a := range t.annotations[name] {
+		b.bytes(9, appendProtoAnnotation(nil, a))
+	}
+	for _, a := range t.findings[name] {
+		b.bytes(10, appendProtoAnnotation(nil, a))
+	}
+	if !node.isDir && !node.omitted && !t.opts.StructureOnly {
+		if content, ok := t.fileContent(node, name); ok {
+			b.bytes(11, []byte(content))
+		}
+	}
+	return b
+}
+
+// appendProtoAnnotation appends the fields of the Annotation message of a to b
+func appendProtoAnnotation(b protoBuffer, a Annotation) protoBuffer {
+	b.string(1, a.Source)
+	b.string(2, a.Severity)
+	b.uint(3, uint64(max(a.Line, 0)))
+	b.string(4, a.Message)
+	return b
+}
@@ -0,0 +1,134 @@
+package mapper
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// defaultQueryTop is how many files keep their content when ranking by a query
+const defaultQueryTop = 10
+
+// pathWeight is how much more a term counts when it appears in a file's path
+const pathWeight = 5
+
+// queryTerms splits a query into lowercase terms, the same way file words are split
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	for _, word := range splitWords(query) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// splitWords breaks text into lowercase words, splitting identifiers at
+// underscores, digits and camelCase humps so PaymentRetry, payment_retry and
+// payment-retry all yield "payment" and "retry". Single letters are dropped.
+func splitWords(text string) []string {
+	words := make([]string, 0)
+	var word []rune
+	flush := func() {
+		if len(word) > 1 {
+			words = append(words, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	var prev rune
+	for i, r := range []rune(text) {
+		switch {
+		case !unicode.IsLetter(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(prev):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+// fileWords counts the words of a file's content and path, path words
+// weighing more
+func fileWords(fsys fs.FS, name string) map[string]int {
+	counts := make(map[string]int)
+	if data, err := fs.ReadFile(fsys, name); err == nil {
+		for _, word := range splitWords(string(data)) {
+			counts[word]++
+		}
+	}
+	for _, word := range splitWords(name) {
+		counts[word] += pathWeight
+	}
+	return counts
+}
+
+// rankByQuery scores every file with content by the frequency of the query's
+// terms in its identifiers, comments and path, weighting rare terms higher.
+// Word counts come from opts.Index where it is up to date, and files with
+// more commits in churn rank higher. The best files keep their content,
+// annotated with their rank, while the rest stay in the tree with their
+// content omitted.
+func rankByQuery(tree *TreeNode, fsys fs.FS, opts *Options, churn map[string]int, msg i18n.Printer) {
+	terms := queryTerms(opts.Query)
+	if len(terms) == 0 {
+		return
+	}
+	top := opts.QueryTop
+	if top <= 0 {
+		top = defaultQueryTop
+	}
+
+	type scored struct {
+		node   *TreeNode
+		name   string
+		counts map[string]int
+		score  float64
+	}
+	files := make([]*scored, 0)
+	docFreq := make(map[string]int)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		if node.omitted || node.hoisted {
+			return
+		}
+		f := &scored{node: node, name: name, counts: opts.Index.wordsOf(fsys, name)}
+		for _, term := range terms {
+			if f.counts[term] > 0 {
+				docFreq[term]++
+			}
+		}
+		files = append(files, f)
+	})
+
+	for _, f := range files {
+		for _, term := range terms {
+			if tf := f.counts[term]; tf > 0 {
+				f.score += termScore(tf, len(files), docFreq[term])
+			}
+		}
+		f.score *= churnBoost(churn[f.name])
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].score != files[j].score {
+			return files[i].score > files[j].score
+		}
+		return files[i].name < files[j].name
+	})
+
+	for i, f := range files {
+		if i < top && f.score > 0 {
+			f.node.addNote(msg.Sprintf("query rank %d", i+1))
+			continue
+		}
+		f.node.omitted = true
+	}
+}
@@ -0,0 +1,93 @@
+package mapper
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDescription is the length in runes above which descriptions are cut
+const maxDescription = 100
+
+// isReadme reports whether name is a README, e.g. README.md or readme.rst
+func isReadme(name string) bool {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.EqualFold(stem, "readme")
+}
+
+// hoistReadmes describes every directory with a README by its first heading,
+// or its first paragraph when it has none before, and moves the README to
+// the front of the directory so its content comes first
+func hoistReadmes(node *TreeNode, fsys fs.FS, name string) {
+	if !node.isDir {
+		return
+	}
+	for i, child := range node.children {
+		if child.isDir || child.omitted || child.link != "" || !isReadme(child.name) {
+			continue
+		}
+		if description := readmeDescription(fsys, path.Join(name, child.name)); description != "" {
+			node.addNote(description)
+		}
+		copy(node.children[1:i+1], node.children[:i])
+		node.children[0] = child
+		break
+	}
+	for _, child := range node.children {
+		hoistReadmes(child, fsys, path.Join(name, child.name))
+	}
+}
+
+// readmeDescription returns the first heading or paragraph of a README on
+// one line, skipping badges, HTML and front matter
+func readmeDescription(fsys fs.FS, name string) string {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var paragraph []string
+	inFrontMatter := false
+	scanner := bufio.NewScanner(f)
+	for lines := 0; scanner.Scan() && lines < 200; lines++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case lines == 0 && line == "---":
+			inFrontMatter = true
+			continue
+		case inFrontMatter:
+			inFrontMatter = line != "---"
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "[![") || strings.HasPrefix(line, "![") {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		// Setext underlines and rST adornments make the previous line a heading
+		if strings.Trim(line, "=-~#*") == "" {
+			if len(paragraph) > 0 {
+				return shorten(strings.Join(paragraph, " "))
+			}
+			continue
+		}
+		if heading, ok := strings.CutPrefix(line, "#"); ok && len(paragraph) == 0 {
+			return shorten(strings.Trim(strings.TrimLeft(heading, "#"), " #"))
+		}
+		paragraph = append(paragraph, line)
+	}
+	return shorten(strings.Join(paragraph, " "))
+}
+
+// shorten cuts s to maxDescription runes, ending it with an ellipsis
+func shorten(s string) string {
+	if utf8.RuneCountInString(s) <= maxDescription {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:maxDescription-1])) + "…"
+}
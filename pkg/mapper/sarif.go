@@ -0,0 +1,195 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// severityOrder ranks SARIF levels from the most to the least severe
+var severityOrder = []string{"error", "warning", "note", "none"}
+
+// sarifLog is the part of a SARIF 2.1.0 log read by LoadSARIF
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name string `json:"name"`
+			} `json:"driver"`
+		} `json:"tool"`
+		BaseIDs map[string]struct {
+			URI string `json:"uri"`
+		} `json:"originalUriBaseIds"`
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI       string `json:"uri"`
+						URIBaseID string `json:"uriBaseId"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// LoadSARIF reads the results of a SARIF log, as written by linters and
+// scanners such as golangci-lint, Semgrep or CodeQL, as findings with paths
+// relative to root. Results without a location in root are left out.
+func LoadSARIF(name, root string) ([]Annotation, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SARIF log: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("error parsing SARIF log %s: %v", name, err)
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	findings := make([]Annotation, 0)
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 {
+				continue
+			}
+			loc := result.Locations[0].PhysicalLocation
+			uri := loc.ArtifactLocation.URI
+			if base, ok := run.BaseIDs[loc.ArtifactLocation.URIBaseID]; ok {
+				uri = strings.TrimSuffix(base.URI, "/") + "/" + uri
+			}
+			rel, ok := sarifPath(uri, root)
+			if !ok {
+				continue
+			}
+			level := result.Level
+			if level == "" {
+				level = "warning" // The SARIF default
+			}
+			message := result.Message.Text
+			if result.RuleID != "" {
+				message = result.RuleID + ": " + message
+			}
+			findings = append(findings, Annotation{Path: rel, Source: run.Tool.Driver.Name, Severity: level, Line: loc.Region.StartLine, Message: message})
+		}
+	}
+	return findings, nil
+}
+
+// sarifPath turns an artifact URI, relative or a file URI, into a slash path
+// below root, reporting false when it lies outside
+func sarifPath(uri, root string) (string, bool) {
+	if rest, ok := strings.CutPrefix(uri, "file://"); ok {
+		uri = rest
+		if unescaped, err := url.PathUnescape(rest); err == nil {
+			uri = unescaped
+		}
+		// file:///C:/src on Windows
+		if len(uri) > 2 && uri[0] == '/' && uri[2] == ':' {
+			uri = uri[1:]
+		}
+	}
+	name := filepath.FromSlash(uri)
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return "", false
+		}
+		name = rel
+	}
+	name = path.Clean(filepath.ToSlash(name))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// countFindings notes on every entry how many findings it has, by level,
+// and returns the findings by path
+func countFindings(tree *TreeNode, findings []Annotation, msg i18n.Printer) map[string][]Annotation {
+	nodes := nodesByPath(tree)
+	byPath := groupByPath(nodes, findings)
+	for name, list := range byPath {
+		levels := make(map[string]int)
+		for _, f := range list {
+			levels[f.Severity]++
+		}
+		parts := make([]string, 0, len(levels))
+		for _, level := range severityOrder {
+			if n := levels[level]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, level))
+				delete(levels, level)
+			}
+		}
+		for _, level := range sortedKeys(levels) {
+			parts = append(parts, fmt.Sprintf("%d %s", levels[level], level))
+		}
+		note := msg.Sprintf(plural(len(list), "%d finding", "%d findings"), len(list))
+		nodes[name].addNote(note + ": " + strings.Join(parts, ", "))
+	}
+	return byPath
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeFindingsAppendix appends the section listing every finding when the
+// appendix was requested
+func (t *Tree) writeFindingsAppendix(w io.Writer) {
+	if !t.opts.FindingsAppendix || len(t.findings) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "<Findings>")
+	writeFindings(t.findings, w)
+	fmt.Fprintln(w, "</Findings>")
+}
+
+// writeFindings lists every finding by path and line
+func writeFindings(findings map[string][]Annotation, w io.Writer) {
+	all := make([]Annotation, 0)
+	for _, list := range findings {
+		all = append(all, list...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Path != all[j].Path {
+			return all[i].Path < all[j].Path
+		}
+		return all[i].Line < all[j].Line
+	})
+	for _, f := range all {
+		location := f.Path
+		if f.Line > 0 {
+			location += fmt.Sprintf(":%d", f.Line)
+		}
+		source := ""
+		if f.Source != "" {
+			source = f.Source + ": "
+		}
+		fmt.Fprintf(w, "%s [%s] %s%s\n", location, f.Severity, source, f.Message)
+	}
+}
@@ -0,0 +1,377 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Tree is the result of scanning a directory
+type Tree struct {
+	root        *TreeNode
+	fsys        fs.FS
+	opts        Options
+	report      *ScanReport
+	msg         i18n.Printer // Translates the labels written into the output
+	infra       []InfraFile
+	migrations  []MigrationSet
+	annotations map[string][]Annotation // Annotations by path, see Options.Annotations
+	findings    map[string][]Annotation // Findings by path, see Options.Findings
+	churn       map[string]int          // Commits by path, see Options.ChurnDays
+	tokens      []FileTokens            // See TokenCounts, nil until counted
+}
+
+// Scan walks the root directory on the OS filesystem and builds its tree
+// according to opts, which may be nil
+func Scan(root string, opts *Options) (*Tree, error) {
+	return ScanContext(context.Background(), root, opts)
+}
+
+// ScanContext is like Scan but stops with ctx's error once ctx is done.
+// Concurrent scans may share Options.Cache but need their own Options.Checkpoint.
+func ScanContext(ctx context.Context, root string, opts *Options) (*Tree, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving root: %v", err)
+	}
+	return scanFS(ctx, dirFS(root), filepath.Base(root), root, root, opts)
+}
+
+// ScanFS walks fsys, such as an embed.FS or a zip.Reader, and builds its tree.
+// The root is shown as rootName and pattern paths are relative to the root of fsys.
+func ScanFS(fsys fs.FS, rootName string, opts *Options) (*Tree, error) {
+	return ScanFSContext(context.Background(), fsys, rootName, opts)
+}
+
+// ScanFSContext is like ScanFS but stops with ctx's error once ctx is done
+func ScanFSContext(ctx context.Context, fsys fs.FS, rootName string, opts *Options) (*Tree, error) {
+	return scanFS(ctx, fsys, rootName, rootName, "", opts)
+}
+
+// newScan returns the options a scan of fsys applies, with patterns folded
+// when matching ignores case, and the report it fills
+func newScan(ctx context.Context, fsys fs.FS, origin, dir string, opts *Options) (*Options, *ScanReport, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	msg, err := i18n.Lookup(opts.Language)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.IgnoreCase {
+		folded := *opts
+		folded.Patterns = opts.Patterns.foldCase()
+		folded.Include = opts.Include.foldCase()
+		opts = &folded
+	}
+	if opts.Normalize != NormNone {
+		fsys = newNormFS(fsys, opts.Normalize)
+		normalized := *opts
+		normalized.Patterns = opts.Patterns.normalize(opts.Normalize)
+		normalized.Include = opts.Include.normalize(opts.Normalize)
+		normalized.ExcludePath = opts.Normalize.Normalize(opts.ExcludePath)
+		opts = &normalized
+	}
+	report := &ScanReport{ctx: ctx, fsys: fsys, origin: origin, dir: dir, cache: opts.Cache, gitignores: newGitignoreSet(fsys, opts), nested: newNestedSet(fsys, opts), content: opts.ContentCache, msg: msg}
+	if opts.Jobs > 1 {
+		report.pool = newWalkPool(opts.Jobs)
+	}
+	return opts, report, nil
+}
+
+// scanFS builds the tree of fsys. The origin keeps cached hashes of
+// different filesystems apart, and dir is the directory of fsys on disk, ""
+// when fsys is not a directory.
+func scanFS(ctx context.Context, fsys fs.FS, rootName, origin, dir string, opts *Options) (*Tree, error) {
+	opts, report, err := newScan(ctx, fsys, origin, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	msg := report.msg
+	node, err := createTree(".", opts.Patterns, opts, report)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("error creating tree structure: %v", err)
+	}
+	node.name = rootName
+
+	t := &Tree{root: node, fsys: report.fsys, opts: *opts, report: report, msg: msg}
+
+	// These are collected during the scan since they may change how the tree is shown
+	if opts.Deployment {
+		t.infra = collectInfra(node, fsys, opts.InfraSummaryOnly)
+	}
+	if opts.ConsolidateMigrations {
+		t.migrations = consolidateMigrations(node, fsys, msg)
+	}
+	if opts.PairTests {
+		pairTestFiles(node, msg)
+	}
+	if opts.HoistReadmes {
+		hoistReadmes(node, fsys, ".")
+	}
+	if opts.ShowSymlinks {
+		dedupeSymlinks(node, fsys, opts.SymlinkContent, msg)
+	}
+	dedupeHardLinks(node, fsys, msg)
+	var churn map[string]int
+	if opts.ChurnDays > 0 && dir != "" {
+		if churn, err = collectChurn(ctx, dir, opts.ChurnDays); err != nil {
+			i18n.Warnf("Could not read the git history of %s: %v", dir, err)
+		}
+		annotateChurn(node, churn, msg)
+		t.churn = churn
+	}
+	if opts.Query != "" {
+		rankByQuery(node, fsys, opts, churn, msg)
+	}
+	if opts.SemanticQuery != "" {
+		if err := rankBySimilarity(ctx, node, fsys, opts, churn, msg); err != nil {
+			return nil, fmt.Errorf("error ranking by semantic query: %v", err)
+		}
+	}
+	if len(opts.Annotations) > 0 {
+		t.annotations = annotate(node, opts.Annotations, msg)
+	}
+	if len(opts.Findings) > 0 {
+		t.findings = countFindings(node, opts.Findings, msg)
+	}
+	if opts.MaxTokens > 0 {
+		t.fitTokenBudget()
+	}
+	return t, nil
+}
+
+// Root returns the node of the scanned directory
+func (t *Tree) Root() *TreeNode {
+	return t.root
+}
+
+// Unreadable returns how many files were skipped for lack of read permission
+func (t *Tree) Unreadable() int {
+	return t.report.unreadable
+}
+
+// WriteRuleStats reports how many entries each pattern and built-in rule matched
+func (t *Tree) WriteRuleStats(w io.Writer) {
+	printRuleStats(t.report, t.msg, w, t.opts.Patterns, t.opts.Include)
+}
+
+// RenderContext is like Render but stops with ctx's error once ctx is done
+func (t *Tree) RenderContext(ctx context.Context, w io.Writer, format Format) error {
+	if err := t.Render(&contextWriter{ctx: ctx, w: w}, format); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return ctx.Err()
+}
+
+// contextWriter fails every write once its context is done
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// Flush flushes the underlying writer when it buffers, so checkpoints can
+// still make sure their progress reached the output
+func (cw *contextWriter) Flush() error {
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Render writes the tree to w in the given format
+func (t *Tree) Render(w io.Writer, format Format) error {
+	switch format {
+	case FormatText:
+		return t.renderText(w)
+	case FormatJSON:
+		return t.renderJSON(w)
+	case FormatMarkdown:
+		return t.renderMarkdown(w)
+	case FormatHTML:
+		return t.renderHTML(w)
+	case FormatYAML:
+		return t.renderYAML(w)
+	case FormatMermaid:
+		return t.renderMermaid(w)
+	case FormatDOT:
+		return t.renderDOT(w)
+	case FormatProse:
+		return t.renderProse(w)
+	case FormatXML:
+		return t.renderXML(w)
+	case FormatProtobuf:
+		return t.renderProtobuf(w)
+	case FormatParquet:
+		return t.renderParquet(w)
+	}
+	return fmt.Errorf("unsupported format %v", format)
+}
+
+// renderText writes the tree followed by the enabled sections and file contents.
+// With a checkpoint holding render progress, w is expected to continue the
+// output where the earlier run stopped, so the parts already written are skipped.
+func (t *Tree) renderText(w io.Writer) error {
+	opts := &t.opts
+	cp := opts.Checkpoint
+	raw := w
+	flusher, _ := w.(interface{ Flush() error })
+	var body hash.Hash
+	if opts.Footer {
+		if body = newBodyHash(cp); body != nil {
+			w = io.MultiWriter(w, body)
+		} else {
+			i18n.Warnf("Leaving out the footer, since the checkpoint lacks the checksum of the output written before")
+		}
+	}
+	cw := &countingWriter{w: w}
+	out, head := io.Writer(cw), io.Writer(cw)
+	skip := 0
+	var written func()
+	if cp != nil {
+		written = func() {
+			// A saved offset must not run ahead of what reached the output
+			if flusher != nil && cp.saveDue() {
+				flusher.Flush()
+			}
+			cp.wroteSection(cw.n, hashState(body))
+		}
+		if cp.files > 0 {
+			// The sections still run to hoist the same files as before
+			cw.n, skip, head = cp.offset, cp.files, io.Discard
+		}
+	}
+	files := skip
+	if body != nil {
+		checkpointed := written
+		written = func() {
+			files++
+			if checkpointed != nil {
+				checkpointed()
+			}
+		}
+	}
+	// Lines are only known when the whole output is written in this run
+	var lines *lineCounter
+	if opts.FileIndex && skip == 0 {
+		lines = &lineCounter{w: out}
+		out, head = lines, lines
+	}
+
+	if opts.SummaryHeader {
+		t.section(head, t.writeSummarySection)
+	}
+	t.section(head, t.writeStructure)
+	if opts.StructureOnly {
+		if body != nil {
+			writeFooter(raw, 0, cw.n, body)
+		}
+		return nil
+	}
+
+	t.writeLeadSections(head)
+
+	var index *fileIndex
+	if lines != nil {
+		index = newFileIndex(t.root, lines)
+		checkpointed := written
+		written = func() {
+			if checkpointed != nil {
+				checkpointed()
+			}
+			index.wrote()
+		}
+	}
+	if err := writeFileContents(t.root, t.fsys, out, opts, t.msg, skip, written); err != nil {
+		return fmt.Errorf("error writing file contents: %v", err)
+	}
+
+	t.writeTrailSections(out)
+	if index != nil {
+		t.section(out, func(w io.Writer) { index.writeFileIndex(t.msg, w) })
+	}
+	if body != nil {
+		writeFooter(raw, files, cw.n, body)
+	}
+	return nil
+}
+
+// section frames what write writes between the delimiters of
+// Options.Delimited, if enabled
+func (t *Tree) section(w io.Writer, write func(io.Writer)) {
+	sw, done := delimitSection(w, &t.opts)
+	write(sw)
+	done()
+}
+
+// writeStructure writes the Project_Structure section
+func (t *Tree) writeStructure(w io.Writer) {
+	fmt.Fprintln(w, "<Project_Structure>")
+	printTree(t.root, "", true, w, t.msg)
+	fmt.Fprintln(w, "</Project_Structure>")
+}
+
+// writeLeadSections writes the enabled sections going between the tree and
+// the file contents, hoisting the files they hold
+func (t *Tree) writeLeadSections(w io.Writer) {
+	if t.opts.Interfaces {
+		t.section(w, func(w io.Writer) { hoistInterfaces(t.root, t.fsys, w) })
+	}
+	if t.opts.Dependencies {
+		t.section(w, func(w io.Writer) { writeDependencies(collectManifests(t.root, t.fsys), w) })
+	}
+	t.section(w, func(w io.Writer) { writeDeploymentSurface(t.infra, w) })
+	for _, set := range t.migrations {
+		t.section(w, func(w io.Writer) { writeSchemas([]MigrationSet{set}, w) })
+	}
+}
+
+// writeTrailSections writes the sections going after the file contents
+func (t *Tree) writeTrailSections(w io.Writer) {
+	t.section(w, func(w io.Writer) { writeBinaryInventory(t.report, w) })
+	t.section(w, func(w io.Writer) { writeOmissions(t.report, t.msg, w) })
+	t.section(w, t.writeFindingsAppendix)
+}
+
+// Name returns the base name of the file or directory
+func (n *TreeNode) Name() string {
+	return n.name
+}
+
+// IsDir reports whether the node is a directory
+func (n *TreeNode) IsDir() bool {
+	return n.isDir
+}
+
+// Omitted reports whether the node is listed without its content
+func (n *TreeNode) Omitted() bool {
+	return n.omitted
+}
+
+// Note returns the annotation shown next to the node, if any
+func (n *TreeNode) Note() string {
+	return n.note
+}
+
+// Children returns the entries of a directory node in tree order
+func (n *TreeNode) Children() []*TreeNode {
+	return n.children
+}
@@ -0,0 +1,122 @@
+package mapper
+
+import (
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// SkipLists are the built-in rules skipping directories, binary extensions
+// and files by name. The zero value skips nothing but the tool's own files.
+type SkipLists struct {
+	Dirs       map[string]bool // Directory names, e.g. "vendor"
+	Extensions map[string]bool // Lowercase extensions with the dot, e.g. ".log"
+	Files      map[string]bool // File names, e.g. ".DS_Store"
+}
+
+// skipProfiles hold the artifacts an operating system leaves in directories,
+// by GOOS name. The profile of the running system is part of the defaults.
+var skipProfiles = map[string]*SkipLists{
+	"darwin": {
+		Dirs:  map[string]bool{"__MACOSX": true, ".Spotlight-V100": true, ".Trashes": true, ".fseventsd": true},
+		Files: map[string]bool{".DS_Store": true, ".localized": true},
+	},
+	"windows": {
+		Dirs:  map[string]bool{"$RECYCLE.BIN": true, "System Volume Information": true},
+		Files: map[string]bool{"Thumbs.db": true, "ehthumbs.db": true, "desktop.ini": true},
+	},
+	"linux": {
+		Dirs:  map[string]bool{"lost+found": true},
+		Files: map[string]bool{".directory": true},
+	},
+}
+
+// SkipProfiles returns the names of the operating system profiles accepted
+// by AddProfile, besides "all"
+func SkipProfiles() []string {
+	return slices.Sorted(maps.Keys(skipProfiles))
+}
+
+// DefaultSkipLists returns a copy of the built-in lists with the profile of
+// the running operating system, to change before use
+func DefaultSkipLists() *SkipLists {
+	s := &SkipLists{Dirs: maps.Clone(skipDirs), Extensions: maps.Clone(skipExtensions), Files: maps.Clone(skipFiles)}
+	s.AddProfile(runtime.GOOS)
+	return s
+}
+
+// defaultSkipLists is used when Options.SkipLists is nil
+var defaultSkipLists = DefaultSkipLists()
+
+// skipLists returns the skip lists in effect
+func (o *Options) skipLists() *SkipLists {
+	if o.SkipLists != nil {
+		return o.SkipLists
+	}
+	return defaultSkipLists
+}
+
+// Add skips entry, written as "vendor/" for a directory, "*.log" for an
+// extension and a plain name for a file
+func (s *SkipLists) Add(entry string) {
+	list, key := s.listOf(entry)
+	list[key] = true
+}
+
+// AddProfile adds the entries of the named operating system profile, "macos"
+// standing for darwin and "all" adding every profile. A system without a
+// profile of its own adds nothing.
+func (s *SkipLists) AddProfile(name string) error {
+	if name == "all" {
+		for _, profile := range skipProfiles {
+			s.merge(profile)
+		}
+		return nil
+	}
+	if name == "macos" {
+		name = "darwin"
+	}
+	if profile, ok := skipProfiles[name]; ok {
+		s.merge(profile)
+		return nil
+	}
+	if name == runtime.GOOS {
+		return nil
+	}
+	return fmt.Errorf("unknown skip profile %q (known: %s, all)", name, strings.Join(SkipProfiles(), ", "))
+}
+
+// merge adds the entries of other
+func (s *SkipLists) merge(other *SkipLists) {
+	for dir := range other.Dirs {
+		s.Add(dir + "/")
+	}
+	for ext := range other.Extensions {
+		s.Add("*" + ext)
+	}
+	for file := range other.Files {
+		s.Add(file)
+	}
+}
+
+// Remove stops skipping entry, written as for Add
+func (s *SkipLists) Remove(entry string) {
+	list, key := s.listOf(entry)
+	delete(list, key)
+}
+
+// listOf returns the list entry belongs in and its key there
+func (s *SkipLists) listOf(entry string) (map[string]bool, string) {
+	if s.Dirs == nil {
+		s.Dirs, s.Extensions, s.Files = make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	}
+	if dir, ok := strings.CutSuffix(entry, "/"); ok {
+		return s.Dirs, dir
+	}
+	if ext, ok := strings.CutPrefix(entry, "*."); ok {
+		return s.Extensions, "." + strings.ToLower(ext)
+	}
+	return s.Files, entry
+}
@@ -0,0 +1,74 @@
+package mapper
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"unicode/utf8"
+)
+
+// sniffSize is how much of a file is read to tell text from binary content
+const sniffSize = 8 * 1024
+
+// sniffPool recycles the buffers files are sniffed into
+var sniffPool = sync.Pool{
+	New: func() any { return new([sniffSize]byte) },
+}
+
+// sniffFile opens the file at name, which also checks it is readable, and
+// reports whether its first bytes look binary
+func sniffFile(fsys fs.FS, name string) (bool, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := sniffPool.Get().(*[sniffSize]byte)
+	defer sniffPool.Put(buf)
+	n, err := io.ReadFull(file, buf[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return looksBinary(buf[:n], n == sniffSize), nil
+}
+
+// looksBinary reports whether data holds a NUL byte or is mostly not UTF-8,
+// which leaves text in legacy encodings with a few accented letters alone.
+// When data is a prefix of the file, a rune cut off at its end is ignored.
+func looksBinary(data []byte, prefix bool) bool {
+	invalid := 0
+	for i := 0; i < len(data); {
+		if data[i] == 0 {
+			return true
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			if prefix && !utf8.FullRune(data[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return invalid*10 > len(data)
+}
+
+// specialKind names the kind of a file that is not regular and must not be
+// read, such as a named pipe whose reader blocks until a writer appears, or
+// returns "" for regular files
+func specialKind(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	case mode&fs.ModeIrregular != 0:
+		return "irregular file"
+	}
+	return ""
+}
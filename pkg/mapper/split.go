@@ -0,0 +1,256 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// PartPath returns the path of the n-th part, counting from 1, of a snapshot
+// split from output, e.g. project_structure.part2.txt
+func PartPath(output string, n int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(output, ext), n, ext)
+}
+
+// unsplitPath returns the snapshot a part was split from, as PartPath names
+// it, or "" when name is not a part
+func unsplitPath(name string) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndex(stem, ".part")
+	if i < 0 {
+		return ""
+	}
+	n := stem[i+len(".part"):]
+	if n == "" || strings.Trim(n, "0123456789") != "" {
+		return ""
+	}
+	return stem[:i] + ext
+}
+
+// isOutputPath reports whether name is the output file or one of its parts
+func isOutputPath(name, output string) bool {
+	return output != "" && (name == output || unsplitPath(name) == output)
+}
+
+// Part is a piece of a split snapshot: the tree and the contents of
+// consecutive files, see SplitParts
+type Part struct {
+	jobs   []contentJob
+	bytes  int64
+	tokens int64
+}
+
+// Files returns how many files have their content in the part
+func (p *Part) Files() int {
+	return len(p.jobs)
+}
+
+// partLimit is the largest size of a part, in bytes and tokens, 0 for none
+type partLimit struct {
+	bytes, tokens int64
+}
+
+// fits reports whether a part of n bytes and tokens stays within the limit
+func (l partLimit) fits(n, tokens int64) bool {
+	return (l.bytes <= 0 || n <= l.bytes) && (l.tokens <= 0 || tokens <= l.tokens)
+}
+
+// SplitParts divides the text output into parts of at most maxBytes bytes
+// and maxTokens tokens each, 0 for no limit, so a large snapshot can be
+// given to a model in consecutive messages. Every part starts with the tree,
+// so it stands on its own. A file larger than a part is divided into pieces
+// between its top-level declarations, see filePieces. Checkpoint, Footer and
+// FileIndex do not apply.
+func (t *Tree) SplitParts(maxBytes, maxTokens int64) []*Part {
+	limit := partLimit{maxBytes, maxTokens}
+	var header, lead, trail bytes.Buffer
+	t.writePartLabel(&header, 10, 10) // Parts are rarely counted in hundreds
+	t.section(&header, t.writeStructure)
+	if t.opts.SummaryHeader {
+		t.section(&lead, t.writeSummarySection)
+	}
+	// The lead sections hoist their files, so they go before the contents
+	t.writeLeadSections(&lead)
+	t.writeTrailSections(&trail)
+	headerTokens := t.opts.countTokens(header.Bytes())
+
+	parts := make([]*Part, 0)
+	part := &Part{bytes: int64(header.Len() + lead.Len()), tokens: headerTokens + t.opts.countTokens(lead.Bytes())}
+	measure := func(job contentJob) (n, tokens int64, ok bool) {
+		section, err := renderFileContent(job, &t.opts)
+		if err != nil || section == nil {
+			return 0, 0, false
+		}
+		defer putBuffer(section)
+		return int64(section.Len()), t.opts.countTokens(section.Bytes()), true
+	}
+	add := func(job contentJob, n, tokens int64) {
+		if len(part.jobs) > 0 && !limit.fits(part.bytes+n, part.tokens+tokens) {
+			parts = append(parts, part)
+			part = &Part{bytes: int64(header.Len()), tokens: headerTokens}
+		}
+		if !limit.fits(part.bytes+n, part.tokens+tokens) {
+			i18n.Warnf("%s does not fit in a part, so its part is larger", job.name)
+		}
+		part.jobs = append(part.jobs, job)
+		part.bytes += n
+		part.tokens += tokens
+	}
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if node.omitted || node.hoisted {
+			return
+		}
+		job := contentJob{node: node, fsys: t.fsys, name: name, msg: t.msg}
+		n, tokens, ok := measure(job)
+		if !ok {
+			return
+		}
+		if !limit.fits(int64(header.Len())+n, headerTokens+tokens) {
+			if pieces := t.filePieces(job, limit, int64(header.Len()), headerTokens); len(pieces) > 1 {
+				for _, piece := range pieces {
+					if n, tokens, ok := measure(piece); ok {
+						add(piece, n, tokens)
+					}
+				}
+				return
+			}
+		}
+		add(job, n, tokens)
+	})
+	// The closing sections get a part of their own only when they overflow
+	// one that fits
+	trailTokens := t.opts.countTokens(trail.Bytes())
+	if len(part.jobs) > 0 && trail.Len() > 0 && limit.fits(part.bytes, part.tokens) && !limit.fits(part.bytes+int64(trail.Len()), part.tokens+trailTokens) {
+		parts = append(parts, part)
+		part = &Part{bytes: int64(header.Len()), tokens: headerTokens}
+	}
+	return append(parts, part)
+}
+
+// pieceFrame is roughly what the frame of a piece takes besides its names:
+// the tags or delimiter lines and the line range
+const pieceFrame = 64
+
+// filePieces divides a file too large for a part into pieces that each fit
+// in one after the header, cutting it only before a top-level declaration
+// and the comments above it, so functions and classes stay whole. A run of
+// lines between declarations that does not fit by itself, such as one large
+// function or a file without declarations, is cut between lines instead.
+func (t *Tree) filePieces(job contentJob, limit partLimit, headerBytes, headerTokens int64) []contentJob {
+	content, release, err := readContent(job.fsys, job.name)
+	if err != nil {
+		return nil
+	}
+	defer release()
+	if job.node.truncated() {
+		content = truncateContent(content, job.node.keepLines, job.node.keepTail)
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	frame := int64(len(job.name) + 2*len(job.node.name) + pieceFrame)
+	fits := func(n, tokens int64) bool {
+		return limit.fits(headerBytes+frame+n, headerTokens+EstimateTokens(frame)+tokens)
+	}
+
+	// A unit is a run of lines never cut: the lines from one declaration to
+	// the next, or a single line of a run too large for a piece
+	type unit struct {
+		from, to      int
+		bytes, tokens int64
+	}
+	units := make([]unit, 0)
+	starts := append([]int{1}, declarationStarts(job.name, content)...)
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1] - 1
+		}
+		if end < start {
+			continue
+		}
+		text := bytes.Join(lines[start-1:end], nil)
+		if u := (unit{start, end, int64(len(text)), t.opts.countTokens(text)}); fits(u.bytes, u.tokens) {
+			units = append(units, u)
+			continue
+		}
+		for line := start; line <= end; line++ {
+			text := lines[line-1]
+			units = append(units, unit{line, line, int64(len(text)), t.opts.countTokens(text)})
+		}
+	}
+
+	pieces := make([]contentJob, 0)
+	var current unit
+	for _, u := range units {
+		if current.from > 0 && !fits(current.bytes+u.bytes, current.tokens+u.tokens) {
+			pieces = append(pieces, contentJob{node: job.node, fsys: job.fsys, name: job.name, from: current.from, to: current.to, msg: job.msg})
+			current = unit{}
+		}
+		if current.from == 0 {
+			current.from = u.from
+		}
+		current.to = u.to
+		current.bytes += u.bytes
+		current.tokens += u.tokens
+	}
+	if current.from > 0 {
+		pieces = append(pieces, contentJob{node: job.node, fsys: job.fsys, name: job.name, from: current.from, to: current.to, msg: job.msg})
+	}
+	return pieces
+}
+
+// filePiece returns the lines from through to of content, counting from 1,
+// after a marker telling which lines of how many they are
+func filePiece(content []byte, from, to int) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	to = min(to, len(lines))
+	if from > to {
+		return nil
+	}
+	out := fmt.Appendf(nil, "[lines %d-%d of %d]\n", from, to, len(lines))
+	for _, line := range lines[from-1 : to] {
+		out = append(out, line...)
+	}
+	return out
+}
+
+// writePartLabel writes the section telling which part of how many this is
+func (t *Tree) writePartLabel(w io.Writer, i, n int) {
+	t.section(w, func(w io.Writer) {
+		fmt.Fprintf(w, "<Part>\n%s\n</Part>\n", t.msg.Sprintf("Part %d of %d", i, n))
+	})
+}
+
+// RenderPart writes the i-th of parts, counting from 0, as text: which part
+// it is, the tree and the contents of its files. The first part also holds
+// the sections going before the contents, such as Interfaces, and the last
+// those going after them, such as Omissions.
+func (t *Tree) RenderPart(w io.Writer, parts []*Part, i int) error {
+	t.writePartLabel(w, i+1, len(parts))
+	if i == 0 && t.opts.SummaryHeader {
+		t.section(w, t.writeSummarySection)
+	}
+	t.section(w, t.writeStructure)
+	if i == 0 {
+		t.writeLeadSections(w)
+	}
+	if err := runContentPipeline(parts[i].jobs, w, &t.opts, nil); err != nil {
+		return fmt.Errorf("error writing file contents: %v", err)
+	}
+	if i == len(parts)-1 {
+		t.writeTrailSections(w)
+	}
+	return nil
+}
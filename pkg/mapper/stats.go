@@ -0,0 +1,103 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// recordDecision counts the rule responsible for a skip decision
+func (r *ScanReport) recordDecision(d SkipDecision) {
+	r.countSkip(d.reason)
+	if d.pattern != nil {
+		if r.patternHits == nil {
+			r.patternHits = make(map[*Pattern]int)
+		}
+		r.patternHits[d.pattern]++
+		return
+	}
+
+	if d.reason == NotSkipped {
+		return
+	}
+	if d.reason == SkipUnreadable {
+		r.unreadable++
+	}
+	if r.ruleHits == nil {
+		r.ruleHits = make(map[string]int)
+	}
+	r.ruleHits[d.reason.String()+": "+d.rule]++
+}
+
+// countSkip counts an entry skipped for reason in the run summary
+func (r *ScanReport) countSkip(reason SkipReason) {
+	if reason == NotSkipped {
+		return
+	}
+	if r.skips == nil {
+		r.skips = make(map[SkipReason]int)
+	}
+	r.skips[reason]++
+}
+
+// printRuleStats reports how many entries each pattern and built-in rule matched
+func printRuleStats(report *ScanReport, msg i18n.Printer, output io.Writer, lists ...*PatternList) {
+	fmt.Fprintln(output, msg.Sprintf("Rule statistics:"))
+
+	for _, patterns := range lists {
+		if patterns == nil {
+			continue
+		}
+		kind := "ignore"
+		if patterns.matchType == Filter {
+			kind = "filter"
+		}
+		for i := range patterns.patterns {
+			p := &patterns.patterns[i]
+			hits := report.patternHits[p]
+			note := ""
+			if hits == 0 {
+				note = msg.Sprintf(" (unused)")
+			}
+			fmt.Fprintf(output, "  %6d  %s %s [%s]%s\n", hits, kind, p.text, p.source, note)
+		}
+	}
+
+	for _, dir := range report.nested.dirs() {
+		for i := range report.nested.lists[dir].patterns {
+			p := &report.nested.lists[dir].patterns[i]
+			hits := report.patternHits[p]
+			note := ""
+			if hits == 0 {
+				note = msg.Sprintf(" (unused)")
+			}
+			fmt.Fprintf(output, "  %6d  ignore %s [%s]%s\n", hits, p.text, p.source, note)
+		}
+	}
+	for _, dir := range report.gitignores.dirs() {
+		for i := range report.gitignores.lists[dir].patterns {
+			p := &report.gitignores.lists[dir].patterns[i]
+			if hits := report.patternHits[p]; hits > 0 {
+				fmt.Fprintf(output, "  %6d  gitignore %s [%s]\n", hits, p.text, p.source)
+			}
+		}
+	}
+
+	rules := make([]string, 0, len(report.ruleHits))
+	for rule := range report.ruleHits {
+		rules = append(rules, rule)
+	}
+	// Most frequent first, then by name for stable output
+	sort.Slice(rules, func(i, j int) bool {
+		hi, hj := report.ruleHits[rules[i]], report.ruleHits[rules[j]]
+		if hi != hj {
+			return hi > hj
+		}
+		return rules[i] < rules[j]
+	})
+	for _, rule := range rules {
+		fmt.Fprintf(output, "  %6d  %s\n", report.ruleHits[rule], rule)
+	}
+}
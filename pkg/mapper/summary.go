@@ -0,0 +1,83 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+)
+
+// summaryTopTokens is how many of the files with the most tokens the summary lists
+const summaryTopTokens = 10
+
+// summaryReasons orders the skip reasons in the run summary, with the label
+// each count is written with
+var summaryReasons = []struct {
+	reason SkipReason
+	label  string
+}{
+	{SkipPattern, "%d by ignore or filter patterns"},
+	{SkipBuiltinDir, "%d by the built-in directory list"},
+	{SkipBuiltinFile, "%d by the built-in file list"},
+	{SkipHidden, "%d hidden"},
+	{SkipBinaryExtension, "%d binary"},
+	{SkipTooLarge, "%d over the size limit"},
+	{SkipEntryLimit, "%d over the entry limits"},
+	{SkipLockfile, "%d lock files"},
+	{SkipUnreadable, "%d unreadable"},
+	{SkipSpecial, "%d special files"},
+	{SkipTokenBudget, "%d over the token budget"},
+}
+
+// WriteSummary writes how many files were included with their total size
+// and tokens, the files with the most tokens, and how many entries were
+// skipped for each reason. A skipped directory
+// counts as one entry, whatever it holds.
+func (t *Tree) WriteSummary(w io.Writer) {
+	totals := t.Totals()
+	if t.opts.Tokenizer != nil {
+		t.msg.Fprintf(w, plural(totals.Files, "Included %d file, %s, %d tokens\n", "Included %d files, %s, %d tokens\n"), totals.Files, FormatSize(totals.Bytes), totals.Tokens)
+	} else {
+		t.msg.Fprintf(w, plural(totals.Files, "Included %d file, %s, about %d tokens\n", "Included %d files, %s, about %d tokens\n"), totals.Files, FormatSize(totals.Bytes), totals.Tokens)
+	}
+	t.writeTopTokens(w)
+
+	total := 0
+	for _, n := range t.report.skips {
+		total += n
+	}
+	if total == 0 {
+		t.msg.Fprintf(w, "Skipped nothing\n")
+		return
+	}
+	t.msg.Fprintf(w, plural(total, "Skipped %d entry:\n", "Skipped %d entries:\n"), total)
+	for _, r := range summaryReasons {
+		if n := t.report.skips[r.reason]; n > 0 {
+			fmt.Fprintf(w, "  %s\n", t.msg.Sprintf(r.label, n))
+		}
+	}
+}
+
+// writeTopTokens lists the files with the most tokens, largest first
+func (t *Tree) writeTopTokens(w io.Writer) {
+	counts := t.TokenCounts()
+	if len(counts) > summaryTopTokens {
+		counts = counts[:summaryTopTokens]
+	}
+	for len(counts) > 0 && counts[len(counts)-1].Tokens == 0 {
+		counts = counts[:len(counts)-1]
+	}
+	if len(counts) < 2 {
+		return
+	}
+	t.msg.Fprintf(w, "Most tokens:\n")
+	width := len(fmt.Sprint(counts[0].Tokens))
+	for _, c := range counts {
+		fmt.Fprintf(w, "  %*d  %s\n", width, c.Tokens, c.Path)
+	}
+}
+
+// writeSummarySection writes the summary as the first section of text output
+func (t *Tree) writeSummarySection(w io.Writer) {
+	fmt.Fprintln(w, "<Summary>")
+	t.WriteSummary(w)
+	fmt.Fprintln(w, "</Summary>")
+}
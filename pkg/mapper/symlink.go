@@ -0,0 +1,129 @@
+package mapper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// readLink returns the target of the symlink at name as written in the
+// link, or "?" when fsys does not reveal it
+func (r *ScanReport) readLink(name string) string {
+	if r.dir == "" {
+		return "?"
+	}
+	target, err := os.Readlink(r.osPath(name))
+	if err != nil {
+		return "?"
+	}
+	return filepath.ToSlash(target)
+}
+
+// osPath returns the path on disk of the entry at name below the root,
+// which has the name stored on disk rather than the normalized one
+func (r *ScanReport) osPath(name string) string {
+	if n, ok := r.fsys.(*normFS); ok {
+		name = n.realName(name)
+	}
+	return filepath.Join(r.dir, filepath.FromSlash(name))
+}
+
+// linkTarget returns the path below the root a symlink at name points to,
+// or false when it points outside the root or its target is unknown
+func linkTarget(name, target string) (string, bool) {
+	if target == "?" || path.IsAbs(target) {
+		return "", false
+	}
+	resolved := path.Join(path.Dir(name), target)
+	return resolved, fs.ValidPath(resolved)
+}
+
+// dedupeSymlinks decides which shown symlinks to files carry their target's
+// content. A target mapped in the tree keeps its content there; otherwise
+// the first link to a target carries it and later links refer to that one.
+// Without content, every link is only listed.
+func dedupeSymlinks(tree *TreeNode, fsys fs.FS, withContent bool, msg i18n.Printer) {
+	mapped := make(map[string]bool)
+	links := make([]*TreeNode, 0)
+	names := make([]string, 0)
+	walkFiles(tree, func(node *TreeNode, name string) {
+		switch {
+		case node.link != "":
+			links = append(links, node)
+			names = append(names, name)
+		case !node.omitted:
+			mapped[name] = true
+		}
+	})
+
+	carriers := make(map[string]string) // Target -> link carrying its content
+	for i, node := range links {
+		node.omitted = true
+		if !withContent || node.link == "?" {
+			continue
+		}
+		key := node.link
+		if resolved, ok := linkTarget(names[i], node.link); ok {
+			key = resolved
+			if mapped[resolved] {
+				continue
+			}
+		}
+		if info, err := fs.Stat(fsys, names[i]); err != nil || info.IsDir() {
+			continue
+		}
+		if carrier, ok := carriers[key]; ok {
+			node.addNote(msg.Sprintf("content under %s", carrier))
+			continue
+		}
+		carriers[key] = names[i]
+		node.omitted = false
+	}
+}
+
+// dirKey identifies the directory at name, whose info is given, by device
+// and inode or else by its resolved path on disk. It reports false when
+// neither is available, as for most fs.FS implementations.
+func (r *ScanReport) dirKey(name string, info fs.FileInfo) (string, bool) {
+	if id, ok := inode(info); ok {
+		return fmt.Sprintf("%d:%d", id[0], id[1]), true
+	}
+	if r.dir == "" {
+		return "", false
+	}
+	full := r.osPath(name)
+	if id, ok := volumeFileID(full); ok {
+		return id, true
+	}
+	resolved, err := filepath.EvalSymlinks(full)
+	return resolved, err == nil
+}
+
+// enterDir records the directory at name as being walked, returning a
+// function undoing it, so a followed symlink back into it is seen as a cycle
+func (r *ScanReport) enterDir(name string) func() {
+	info, err := fs.Stat(r.fsys, name)
+	if err != nil {
+		return func() {}
+	}
+	key, ok := r.dirKey(name, info)
+	if !ok {
+		return func() {}
+	}
+	if r.walking == nil {
+		r.walking = make(map[string]bool)
+	}
+	r.walking[key] = true
+	return func() { delete(r.walking, key) }
+}
+
+// isCycle reports whether following the symlinked directory at name, whose
+// target's info is given, would walk a directory already being walked
+func (r *ScanReport) isCycle(name string, info fs.FileInfo) bool {
+	key, ok := r.dirKey(name, info)
+	return ok && r.walking[key]
+}
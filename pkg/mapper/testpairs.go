@@ -0,0 +1,137 @@
+package mapper
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// testFileInfo describes how a file name relates to the test conventions of its language
+type testFileInfo struct {
+	lang   string // Language family used to pair files
+	stem   string // Name with test markers and extension removed
+	isTest bool
+}
+
+// langFamilies groups extensions whose tests may use a sibling extension
+var langFamilies = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "js",
+	".jsx":  "js",
+	".mjs":  "js",
+	".cjs":  "js",
+	".ts":   "js",
+	".tsx":  "js",
+	".java": "java",
+	".kt":   "kotlin",
+	".rb":   "ruby",
+	".cs":   "csharp",
+	".php":  "php",
+	".c":    "c",
+	".cc":   "cpp",
+	".cpp":  "cpp",
+	".rs":   "rust",
+	".ex":   "elixir",
+	".exs":  "elixir",
+}
+
+// classifyTestFile returns the pairing key of a file, or false for non-source files
+func classifyTestFile(name string) (testFileInfo, bool) {
+	ext := path.Ext(name)
+	lang, ok := langFamilies[strings.ToLower(ext)]
+	if !ok {
+		return testFileInfo{}, false
+	}
+	base := strings.TrimSuffix(name, ext)
+	info := testFileInfo{lang: lang, stem: base}
+
+	// Suffix and prefix conventions, most specific first
+	for _, suffix := range []string{"_test", ".test", ".spec", "_spec", "Test", "Tests", "Spec", "_tests"} {
+		if stem, ok := strings.CutSuffix(base, suffix); ok && stem != "" {
+			info.stem, info.isTest = stem, true
+			return info, true
+		}
+	}
+	if stem, ok := strings.CutPrefix(base, "test_"); ok && stem != "" {
+		info.stem, info.isTest = stem, true
+	}
+	return info, true
+}
+
+// pairTestFiles annotates source files with their tests and tests with their
+// sources. Sources without a test are marked untested when their language has
+// any tests at all, making coverage gaps visible.
+func pairTestFiles(tree *TreeNode, msg i18n.Printer) {
+	type entry struct {
+		node    *TreeNode
+		relPath string
+		info    testFileInfo
+	}
+
+	sources := make(map[string][]*entry) // lang:stem -> files
+	tests := make([]*entry, 0)
+	testedLangs := make(map[string]bool)
+
+	walkFiles(tree, func(node *TreeNode, relPath string) {
+		info, ok := classifyTestFile(node.name)
+		if !ok {
+			return
+		}
+		e := &entry{node: node, relPath: relPath, info: info}
+		if info.isTest {
+			tests = append(tests, e)
+			testedLangs[info.lang] = true
+			return
+		}
+		key := info.lang + ":" + info.stem
+		sources[key] = append(sources[key], e)
+	})
+
+	// partnerName shows siblings by name and anything else by its full path
+	partnerName := func(from, to *entry) string {
+		if path.Dir(from.relPath) == path.Dir(to.relPath) {
+			return to.node.name
+		}
+		return to.relPath
+	}
+
+	paired := make(map[*entry][]string)
+	for _, test := range tests {
+		candidates := sources[test.info.lang+":"+test.info.stem]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		// Prefer a source in the same directory, then the closest path
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return sharedPrefixLen(candidates[i].relPath, test.relPath) > sharedPrefixLen(candidates[j].relPath, test.relPath)
+		})
+		source := candidates[0]
+
+		test.node.addNote("⇄ " + partnerName(test, source))
+		paired[source] = append(paired[source], partnerName(source, test))
+	}
+
+	for _, list := range sources {
+		for _, source := range list {
+			if partners, ok := paired[source]; ok {
+				source.node.addNote("⇄ " + strings.Join(partners, ", "))
+			} else if testedLangs[source.info.lang] {
+				source.node.addNote(msg.Sprintf("untested"))
+			}
+		}
+	}
+}
+
+// sharedPrefixLen returns how many leading path segments two paths share
+func sharedPrefixLen(a, b string) int {
+	as, bs := strings.Split(path.Dir(a), "/"), strings.Split(path.Dir(b), "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
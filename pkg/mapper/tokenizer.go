@@ -0,0 +1,129 @@
+package mapper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// Tokenizer counts the tokens a model sees in a text. BPE counts them as
+// OpenAI's models do, and ExecTokenizer asks a command, for other models.
+type Tokenizer interface {
+	Count(text []byte) int
+}
+
+// ExecTokenizer counts tokens with a command started once and kept running,
+// for models whose tokenizer is not built in. For every text, a JSON line
+// {"text": "..."} is written to its stdin, and it answers with a line
+// {"tokens": 123}, or {"error": "..."}. The command exits when its stdin is
+// closed. Should it fail, a warning is given once and tokens are estimated
+// from sizes from then on.
+type ExecTokenizer struct {
+	Command string
+	Args    []string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	failed bool
+}
+
+// Count sends text to the command and returns its answer
+func (e *ExecTokenizer) Count(text []byte) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.failed {
+		n, err := e.count(text)
+		if err == nil {
+			return n
+		}
+		i18n.Warnf("Tokenizer %s failed: %v; estimating tokens from sizes", e.Command, err)
+		e.failed = true
+		if e.cmd != nil {
+			e.cmd.Process.Kill()
+			e.close()
+		}
+	}
+	return int(EstimateTokens(int64(len(text))))
+}
+
+// count runs one request, starting the command on the first
+func (e *ExecTokenizer) count(text []byte) (int, error) {
+	if e.cmd == nil {
+		if err := e.start(); err != nil {
+			return 0, err
+		}
+	}
+	request, err := json.Marshal(map[string]string{"text": string(text)})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := e.stdin.Write(append(request, '\n')); err != nil {
+		return 0, err
+	}
+	line, err := e.stdout.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, errors.New("command exited")
+		}
+		return 0, err
+	}
+	var response struct {
+		Tokens *int   `json:"tokens"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(line, &response); err != nil {
+		return 0, fmt.Errorf("invalid answer %q: %v", line, err)
+	}
+	if response.Error != "" {
+		return 0, errors.New(response.Error)
+	}
+	if response.Tokens == nil {
+		return 0, fmt.Errorf("answer %q has no tokens", line)
+	}
+	return *response.Tokens, nil
+}
+
+// start runs the command with pipes to its stdin and stdout
+func (e *ExecTokenizer) start() error {
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	e.cmd, e.stdin, e.stdout = cmd, stdin, bufio.NewReader(stdout)
+	return nil
+}
+
+// Close closes the stdin of the command and waits for it to exit
+func (e *ExecTokenizer) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.close()
+}
+
+func (e *ExecTokenizer) close() error {
+	if e.cmd == nil {
+		return nil
+	}
+	e.stdin.Close()
+	err := e.cmd.Wait()
+	e.cmd = nil
+	return err
+}
@@ -0,0 +1,197 @@
+package mapper
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// bpeSpace lists the characters Unicode counts as white space, which the
+// \s of RE2 does not all match
+const bpeSpace = `\t\n\v\f\r \x{85}\p{Z}`
+
+// bpePatterns split text into the pieces encoded separately, as each
+// encoding of tiktoken does, by the name of its ranks file. Their \s+(?!\S)
+// alternative needs a lookahead RE2 lacks, so whitespace runs are matched by
+// the last group and shortened by Count.
+var bpePatterns = map[string]*regexp.Regexp{
+	"cl100k_base": bpePattern(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|[\s]*[\r\n]+|([\s]+)`),
+	"o200k_base": bpePattern(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|[\s]*[\r\n]+|([\s]+)`),
+	"p50k_base": bpePattern(`'(?:[sdmt]|ll|ve|re)| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|([\s]+)`),
+}
+
+// defaultEncoding splits the text of ranks files named after no known encoding
+const defaultEncoding = "cl100k_base"
+
+func init() {
+	// These share the pattern of p50k_base
+	bpePatterns["r50k_base"] = bpePatterns["p50k_base"]
+	bpePatterns["p50k_edit"] = bpePatterns["p50k_base"]
+}
+
+// bpePattern compiles a split pattern, matching Unicode white space where it
+// says [\s]
+func bpePattern(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(strings.ReplaceAll(pattern, `\s`, bpeSpace))
+}
+
+// BPE counts tokens exactly as a byte-pair encoding of OpenAI's tiktoken
+// does, from the merge ranks of a .tiktoken file such as cl100k_base.tiktoken
+type BPE struct {
+	ranks map[string]int
+	split *regexp.Regexp
+}
+
+// LoadBPE reads the ranks of a .tiktoken file, which has one base64-encoded
+// token and its rank per line. Text is split as the encoding the file is
+// named after does, e.g. o200k_base.tiktoken, and as cl100k_base when the
+// name is not one of cl100k_base, o200k_base, p50k_base, p50k_edit or
+// r50k_base.
+func LoadBPE(path string) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening tokenizer: %v", err)
+	}
+	defer file.Close()
+
+	encoding := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	split, ok := bpePatterns[encoding]
+	if !ok {
+		split = bpePatterns[defaultEncoding]
+	}
+	b := &BPE{ranks: make(map[string]int), split: split}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a token and its rank", path, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid token: %v", path, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %v", path, line, err)
+		}
+		b.ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading tokenizer: %v", err)
+	}
+	if len(b.ranks) == 0 {
+		return nil, fmt.Errorf("%s has no tokens", path)
+	}
+	return b, nil
+}
+
+// Count returns the number of tokens text encodes to. Special tokens such
+// as <|endoftext|> are counted as plain text.
+func (b *BPE) Count(text []byte) int {
+	s := string(text)
+	count := 0
+	for len(s) > 0 {
+		loc := b.split.FindStringSubmatchIndex(s)
+		end := loc[1]
+		// A whitespace run followed by more text leaves its last character
+		// to the piece after it
+		if loc[2] >= 0 && end < len(s) {
+			if _, size := utf8.DecodeLastRuneInString(s[:end]); size < end {
+				end -= size
+			}
+		}
+		count += b.countPiece(s[:end])
+		s = s[end:]
+	}
+	return count
+}
+
+// countPiece merges the bytes of a piece pairwise, lowest rank first, until
+// no adjacent pair is a token, returning how many tokens are left
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	// bounds[i] is where the i-th part starts
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// EstimateTokens roughly counts the tokens that size bytes of text make for
+// a language model when no tokenizer is given, taking four bytes per token
+func EstimateTokens(size int64) int64 {
+	return (size + 3) / 4
+}
+
+// countTokens returns the tokens of data with the tokenizer of the options,
+// or estimated from its size without one
+func (o *Options) countTokens(data []byte) int64 {
+	if o.Tokenizer != nil {
+		return int64(o.Tokenizer.Count(data))
+	}
+	return EstimateTokens(int64(len(data)))
+}
+
+// FileTokens is the token count of a file whose content is included
+type FileTokens struct {
+	Path   string
+	Tokens int64
+}
+
+// TokenCounts returns the tokens of every file whose content is included,
+// most first, counted with Options.Tokenizer or estimated from their size.
+// The counts are computed once and kept.
+func (t *Tree) TokenCounts() []FileTokens {
+	if t.tokens != nil {
+		return t.tokens
+	}
+	t.tokens = make([]FileTokens, 0)
+	walkFiles(t.root, func(node *TreeNode, name string) {
+		if node.omitted {
+			return
+		}
+		count := FileTokens{Path: name}
+		if t.opts.Tokenizer == nil && !node.truncated() {
+			if info, err := fs.Stat(t.fsys, name); err == nil {
+				count.Tokens = EstimateTokens(info.Size())
+			}
+		} else if data, release, err := readContent(t.fsys, name); err == nil {
+			if node.truncated() {
+				data = truncateContent(data, node.keepLines, node.keepTail)
+			}
+			count.Tokens = t.opts.countTokens(data)
+			release()
+		}
+		t.tokens = append(t.tokens, count)
+	})
+	sort.SliceStable(t.tokens, func(i, j int) bool { return t.tokens[i].Tokens > t.tokens[j].Tokens })
+	return t.tokens
+}
@@ -0,0 +1,310 @@
+package mapper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// contentJob is a file whose content is written to the output
+type contentJob struct {
+	node *TreeNode
+	fsys fs.FS
+	name string // Path of the file within fsys
+	// Lines written, counting from 1, when the file is split into pieces;
+	// from is 0 for the whole file
+	from, to int
+	msg      i18n.Printer // Language of the labels written instead of content
+}
+
+// hasTransforms reports whether file contents may be rewritten or framed with
+// their length before being written, which requires reading each file into
+// memory first
+func (o *Options) hasTransforms() bool {
+	return o.OutlineOver > 0 || o.Delimited || o.MaxTokens > 0
+}
+
+// streamFileContent copies a file to the output without holding it in memory.
+// It is used when no transform is enabled, so the content is passed through as is.
+func streamFileContent(job contentJob, output io.Writer) error {
+	file, err := job.fsys.Open(job.name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			i18n.Warnf("Could not read file %s: %v", job.name, err)
+		}
+		return nil
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(output, "<%s>\n", job.node.name); err != nil {
+		return err
+	}
+	readErr, err := copyContent(output, file)
+	if err != nil {
+		// A failed write or a cancelled run is reported by the caller
+		return err
+	}
+	if readErr != nil {
+		i18n.Warnf("Could not copy file %s: %v", job.name, readErr)
+	}
+	_, err = fmt.Fprintf(output, "\n\n</%s>\n", job.node.name)
+	return err
+}
+
+// copyContent copies file to output, telling a failed read of the file apart
+// from a failed write, which includes a write after the run was cancelled
+func copyContent(output io.Writer, file io.Reader) (readErr, writeErr error) {
+	source := &sourceReader{r: file}
+	if _, err := io.Copy(output, source); err != nil && source.err == nil {
+		return nil, err
+	}
+	return source.err, nil
+}
+
+// sourceReader remembers the error of reading r other than its end
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
+
+// renderFileContent reads a file and applies the enabled transforms, returning
+// the framed section in a pooled buffer. A nil result means the file is left out.
+func renderFileContent(job contentJob, opts *Options) (*bytes.Buffer, error) {
+	node, name := job.node, job.name
+	whole := !node.truncated() && job.from == 0
+	if outline, ok := opts.ContentCache.outline(job.fsys, name, opts, job.msg); ok && whole {
+		buf := getBuffer()
+		if opts.Delimited {
+			writeDelimitedFile(buf, name, []byte(outline))
+		} else {
+			fmt.Fprintf(buf, "<%s>\n%s\n</%s>\n", node.name, outline, node.name)
+		}
+		return buf, nil
+	}
+
+	content, release, err := readContent(job.fsys, name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			i18n.Warnf("Could not read file %s: %v", name, err)
+		}
+		return nil, nil
+	}
+	defer release()
+	if node.truncated() {
+		content = truncateContent(content, node.keepLines, node.keepTail)
+	}
+	if job.from > 0 {
+		content = filePiece(content, job.from, job.to)
+	}
+
+	buf := getBuffer()
+	if opts.Delimited {
+		body := getBuffer()
+		if !whole || !writeOutlineCached(body, job.fsys, name, content, opts, job.msg) {
+			body.Write(content)
+		}
+		writeDelimitedFile(buf, name, body.Bytes())
+		putBuffer(body)
+		return buf, nil
+	}
+	fmt.Fprintf(buf, "<%s>\n", node.name)
+	if !whole || !writeOutlineCached(buf, job.fsys, name, content, opts, job.msg) {
+		buf.Grow(len(content) + len(node.name) + 8)
+		buf.Write(content)
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(buf, "\n</%s>\n", node.name)
+	return buf, nil
+}
+
+// fileContent reads the file of node and applies the enabled transforms
+func (t *Tree) fileContent(node *TreeNode, name string) (string, bool) {
+	if outline, ok := t.opts.ContentCache.outline(t.fsys, name, &t.opts, t.msg); ok && !node.truncated() {
+		return outline, true
+	}
+	data, release, err := readContent(t.fsys, name)
+	if err != nil {
+		i18n.Warnf("Could not read file %s: %v", name, err)
+		return "", false
+	}
+	defer release()
+	if node.truncated() {
+		return string(truncateContent(data, node.keepLines, node.keepTail)), true
+	}
+
+	var buf bytes.Buffer
+	if writeOutlineCached(&buf, t.fsys, name, data, &t.opts, t.msg) {
+		return buf.String(), true
+	}
+	return string(data), true
+}
+
+// writeOutlineCached is writeOutlineIfLong recording the outline, or that
+// there is none, in opts.ContentCache
+func writeOutlineCached(output io.Writer, fsys fs.FS, name string, content []byte, opts *Options, msg i18n.Printer) bool {
+	if opts.ContentCache == nil || opts.OutlineOver <= 0 {
+		return writeOutlineIfLong(output, name, content, opts, msg)
+	}
+	var outline bytes.Buffer
+	outlined := writeOutlineIfLong(&outline, name, content, opts, msg)
+	opts.ContentCache.storeOutline(fsys, name, opts, msg, outline.String())
+	output.Write(outline.Bytes())
+	return outlined
+}
+
+// mmapThreshold is the size from which files on the OS filesystem are
+// memory-mapped instead of copied into a buffer
+const mmapThreshold = 8 * 1024 * 1024
+
+// readContent returns the whole content of a file. The data is only valid
+// until release is called. Large OS files are memory-mapped when supported,
+// falling back to a pooled buffer otherwise.
+func readContent(fsys fs.FS, name string) (data []byte, release func(), err error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if osFile, ok := file.(*os.File); ok && info.Size() >= mmapThreshold {
+		if data, unmap, err := mmapFile(osFile, info.Size()); err == nil {
+			return data, unmap, nil
+		}
+	}
+
+	buf := getBuffer()
+	buf.Grow(int(info.Size()) + bytes.MinRead)
+	if _, err := buf.ReadFrom(file); err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+	return buf.Bytes(), func() { putBuffer(buf) }, nil
+}
+
+// writeSection writes a rendered section and recycles its buffer
+func writeSection(output io.Writer, section *bytes.Buffer) error {
+	if section == nil {
+		return nil
+	}
+	_, err := output.Write(section.Bytes())
+	putBuffer(section)
+	return err
+}
+
+// runContentPipeline renders files on a pool of workers and writes the results
+// in their original order. At most twice the worker count of rendered files is
+// held in memory while waiting for earlier ones to finish. When not nil,
+// written is called after each file has been written.
+func runContentPipeline(jobs []contentJob, output io.Writer, opts *Options, written func()) error {
+	if written == nil {
+		written = func() {}
+	}
+
+	if !opts.hasTransforms() {
+		for _, job := range jobs {
+			var err error
+			if job.from > 0 || job.node.truncated() {
+				// Content that is cut cannot be streamed
+				var section *bytes.Buffer
+				if section, err = renderFileContent(job, opts); err == nil {
+					err = writeSection(output, section)
+				}
+			} else {
+				err = streamFileContent(job, output)
+			}
+			if err != nil {
+				return err
+			}
+			written()
+		}
+		return nil
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers == 1 {
+		for _, job := range jobs {
+			section, err := renderFileContent(job, opts)
+			if err != nil {
+				return err
+			}
+			if err := writeSection(output, section); err != nil {
+				return err
+			}
+			written()
+		}
+		return nil
+	}
+
+	type result struct {
+		section *bytes.Buffer
+		err     error
+	}
+
+	pending := make(chan chan result, 2*workers)
+	sem := make(chan struct{}, workers)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(pending)
+		for _, job := range jobs {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+			ch := make(chan result, 1)
+			select {
+			case pending <- ch:
+			case <-done:
+				<-sem
+				return
+			}
+			go func(job contentJob) {
+				section, err := renderFileContent(job, opts)
+				ch <- result{section, err}
+				<-sem
+			}(job)
+		}
+	}()
+
+	var firstErr error
+	for ch := range pending {
+		r := <-ch
+		if firstErr != nil {
+			putBuffer(r.section)
+			continue
+		}
+		if r.err == nil {
+			r.err = writeSection(output, r.section)
+		}
+		if r.err != nil {
+			firstErr = r.err
+			close(done)
+			continue
+		}
+		written()
+	}
+	return firstErr
+}
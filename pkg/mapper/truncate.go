@@ -0,0 +1,67 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// truncateContent keeps the first head and the last tail lines of content,
+// replacing the lines between them with a marker telling how many were left out
+func truncateContent(content []byte, head, tail int) []byte {
+	body := bytes.TrimSuffix(content, []byte("\n"))
+	if len(body) == 0 {
+		return content
+	}
+	total := bytes.Count(body, []byte("\n")) + 1
+	if total <= head+tail {
+		return content
+	}
+	start := 0
+	for i := 0; i < head; i++ {
+		start += bytes.IndexByte(content[start:], '\n') + 1
+	}
+	end := len(body)
+	for i := 0; i < tail; i++ {
+		end = bytes.LastIndexByte(body[:end], '\n')
+	}
+	if tail > 0 {
+		end++
+	}
+	out := make([]byte, 0, start+len(content)-end+48)
+	out = append(out, content[:start]...)
+	out = fmt.Appendf(out, "... [%s lines omitted] ...\n", groupDigits(total-head-tail))
+	if tail > 0 {
+		out = append(out, content[end:]...)
+	}
+	return out
+}
+
+// groupDigits writes n with commas between groups of three digits, e.g. 12,345
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// truncated reports whether only the first and last lines of the node's
+// content are written
+func (n *TreeNode) truncated() bool {
+	return n.keepLines > 0 || n.keepTail > 0
+}
+
+// truncatedNote returns the note of a file cut to its first head and last
+// tail lines
+func truncatedNote(head, tail int, msg i18n.Printer) string {
+	switch {
+	case tail <= 0:
+		return msg.Sprintf("first %d lines, over the size limit", head)
+	case head <= 0:
+		return msg.Sprintf("last %d lines, over the size limit", tail)
+	}
+	return msg.Sprintf("first %d and last %d lines, over the size limit", head, tail)
+}
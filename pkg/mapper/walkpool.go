@@ -0,0 +1,89 @@
+package mapper
+
+import (
+	"io/fs"
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+// walkPool reads directories and checks entries of a scan on up to
+// Options.Jobs goroutines. The walk itself stays on one goroutine and takes
+// the results in directory order, so the tree does not depend on the jobs.
+type walkPool struct {
+	sem     chan struct{}
+	pending map[string]chan dirListing // Directories read ahead, taken by readDir
+}
+
+// dirListing is the result of reading a directory ahead of the walk
+type dirListing struct {
+	entries []fs.DirEntry
+	err     error
+}
+
+func newWalkPool(jobs int) *walkPool {
+	return &walkPool{sem: make(chan struct{}, jobs), pending: make(map[string]chan dirListing)}
+}
+
+// readDir lists the directory at name, waiting for it if it is read ahead
+func (r *ScanReport) readDir(name string) ([]fs.DirEntry, error) {
+	if r.pool != nil {
+		if ch, ok := r.pool.pending[name]; ok {
+			delete(r.pool.pending, name)
+			listing := <-ch
+			return listing.entries, listing.err
+		}
+	}
+	return fs.ReadDir(r.fsys, name)
+}
+
+// readAhead starts reading the directory at name for a later readDir
+func (r *ScanReport) readAhead(name string) {
+	ch := make(chan dirListing, 1)
+	r.pool.pending[name] = ch
+	go func() {
+		r.pool.sem <- struct{}{}
+		entries, err := fs.ReadDir(r.fsys, name)
+		<-r.pool.sem
+		ch <- dirListing{entries, err}
+	}()
+}
+
+// decider returns the function deciding the entries of the directory at
+// name by index. With a pool, every entry is decided at once in parallel,
+// and the directories to walk are read ahead.
+func (r *ScanReport) decider(entries []fs.DirEntry, name string, ignoreMatcher *PatternList, opts *Options) func(i int) (SkipDecision, error) {
+	check := func(i int) (SkipDecision, error) {
+		return shouldSkipFile(r.fsys, entries[i], path.Join(name, entries[i].Name()), ignoreMatcher, r.nested, r.gitignores, opts)
+	}
+	if r.pool == nil || len(entries) < 2 {
+		return check
+	}
+
+	// The checks only read pattern files once they are loaded
+	r.nested.preload(name)
+	r.gitignores.preload(name)
+	decisions := make([]SkipDecision, len(entries))
+	errs := make([]error, len(entries))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(cap(r.pool.sem), len(entries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(entries); i = int(next.Add(1) - 1) {
+				r.pool.sem <- struct{}{}
+				decisions[i], errs[i] = check(i)
+				<-r.pool.sem
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, entry := range entries {
+		if errs[i] == nil && entry.IsDir() && (decisions[i].reason == NotSkipped || decisions[i].partial) {
+			r.readAhead(path.Join(name, entry.Name()))
+		}
+	}
+	return func(i int) (SkipDecision, error) { return decisions[i], errs[i] }
+}
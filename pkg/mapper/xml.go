@@ -0,0 +1,153 @@
+package mapper
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"unicode/utf8"
+)
+
+// XMLNamespace is the namespace of the elements written by FormatXML
+const XMLNamespace = "https://github.com/ananth-ar/dirMapper/schema/snapshot/1"
+
+// XMLSchema is the XSD that output written by FormatXML validates against
+//
+//go:embed snapshot.xsd
+var XMLSchema string
+
+// xmlNode is the XML form of a tree node, a directory or file element
+type xmlNode struct {
+	XMLName     xml.Name
+	Name        string          `xml:"name,attr"`
+	Path        string          `xml:"path,attr"`
+	Size        *int64          `xml:"size,attr,omitempty"` // Files only
+	Omitted     bool            `xml:"omitted,attr,omitempty"`
+	Note        string          `xml:"note,attr,omitempty"`
+	Link        string          `xml:"link,attr,omitempty"`
+	More        int             `xml:"more,attr,omitempty"`
+	Annotations []xmlAnnotation `xml:"annotation"`
+	Findings    []xmlAnnotation `xml:"finding"`
+	Content     *xmlContent     `xml:"content"`
+	Children    []*xmlNode
+}
+
+// xmlAnnotation is the XML form of an annotation or finding
+type xmlAnnotation struct {
+	Source   string `xml:"source,attr,omitempty"`
+	Severity string `xml:"severity,attr,omitempty"`
+	Line     int    `xml:"line,attr,omitempty"`
+	Message  string `xml:",chardata"`
+}
+
+// xmlContent holds a file's content, base64-encoded when it has characters
+// XML 1.0 cannot carry even escaped
+type xmlContent struct {
+	Encoding string // "base64" or empty
+	Text     string
+}
+
+// MarshalXML writes the content as character data. Unlike struct fields
+// marked chardata, line feeds are kept as they are instead of being escaped.
+func (c *xmlContent) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	if c.Encoding != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "encoding"}, Value: c.Encoding})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(xml.CharData(c.Text)); err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlSnapshot is the document element
+type xmlSnapshot struct {
+	XMLName xml.Name `xml:"snapshot"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Root    *xmlNode
+}
+
+// renderXML writes the tree as a well-formed XML document valid against
+// XMLSchema. File contents are included unless only the structure was requested.
+func (t *Tree) renderXML(w io.Writer) error {
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	doc := xmlSnapshot{Xmlns: XMLNamespace, Root: t.toXML(t.root, ".")}
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding XML: %v", err)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// toXML converts node, found at name within the scanned filesystem
+func (t *Tree) toXML(node *TreeNode, name string) *xmlNode {
+	out := &xmlNode{
+		XMLName:     xml.Name{Local: "file"},
+		Name:        node.name,
+		Path:        name,
+		Omitted:     node.omitted,
+		Note:        node.note,
+		Link:        node.link,
+		More:        node.more,
+		Annotations: toXMLAnnotations(t.annotations[name]),
+		Findings:    toXMLAnnotations(t.findings[name]),
+	}
+
+	if node.isDir {
+		out.XMLName.Local = "directory"
+		for _, child := range node.children {
+			out.Children = append(out.Children, t.toXML(child, path.Join(name, child.name)))
+		}
+		return out
+	}
+
+	if info, err := fs.Stat(t.fsys, name); err == nil {
+		size := info.Size()
+		out.Size = &size
+	}
+	if !node.omitted && !t.opts.StructureOnly {
+		if content, ok := t.fileContent(node, name); ok {
+			out.Content = newXMLContent(content)
+		}
+	}
+	return out
+}
+
+// toXMLAnnotations converts the annotations or findings of a node
+func toXMLAnnotations(annotations []Annotation) []xmlAnnotation {
+	out := make([]xmlAnnotation, 0, len(annotations))
+	for _, a := range annotations {
+		out = append(out, xmlAnnotation{Source: a.Source, Severity: a.Severity, Line: a.Line, Message: a.Message})
+	}
+	return out
+}
+
+// newXMLContent wraps content, which encoding/xml would otherwise silently
+// alter when it is not valid UTF-8 or holds control characters
+func newXMLContent(content string) *xmlContent {
+	if isXMLText(content) {
+		return &xmlContent{Text: content}
+	}
+	return &xmlContent{Encoding: "base64", Text: base64.StdEncoding.EncodeToString([]byte(content))}
+}
+
+// isXMLText reports whether s is valid UTF-8 made of characters allowed in
+// XML 1.0. Carriage returns are escaped by encoding/xml, so they survive.
+func isXMLText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0xFFFE || r == 0xFFFF {
+			return false
+		}
+	}
+	return true
+}
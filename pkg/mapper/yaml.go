@@ -0,0 +1,77 @@
+package mapper
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/yaml"
+)
+
+// renderYAML writes the structure as nested mappings. Directory keys end in
+// a slash and map to their entries, files map to their metadata, and a
+// directory's own metadata is kept under the "." key. Files with content map
+// to a block mapping whose content is a literal block scalar.
+func (t *Tree) renderYAML(w io.Writer) error {
+	t.writeYAMLEntry(w, t.root, ".", 0)
+	return nil
+}
+
+// writeYAMLEntry writes the key of node, found at name, and its value
+func (t *Tree) writeYAMLEntry(w io.Writer, node *TreeNode, name string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	meta := yamlNodeMeta(node)
+
+	if !node.isDir {
+		if info, err := fs.Stat(t.fsys, name); err == nil {
+			meta = append([]string{fmt.Sprintf("size: %d", info.Size())}, meta...)
+		}
+		content, ok := "", false
+		if !node.omitted && !t.opts.StructureOnly {
+			content, ok = t.fileContent(node, name)
+		}
+		if !ok {
+			fmt.Fprintf(w, "%s%s: {%s}\n", indent, yaml.Quote(node.name), strings.Join(meta, ", "))
+			return
+		}
+		fmt.Fprintf(w, "%s%s:\n", indent, yaml.Quote(node.name))
+		for _, m := range meta {
+			fmt.Fprintf(w, "%s  %s\n", indent, m)
+		}
+		fmt.Fprintf(w, "%s  content: %s\n", indent, yaml.Block(content, indent+"  "))
+		return
+	}
+
+	key := yaml.Quote(node.name + "/")
+	if len(node.children) == 0 && len(meta) == 0 {
+		fmt.Fprintf(w, "%s%s: {}\n", indent, key)
+		return
+	}
+	fmt.Fprintf(w, "%s%s:\n", indent, key)
+	if len(meta) > 0 {
+		fmt.Fprintf(w, "%s  .: {%s}\n", indent, strings.Join(meta, ", "))
+	}
+	for _, child := range node.children {
+		t.writeYAMLEntry(w, child, path.Join(name, child.name), depth+1)
+	}
+}
+
+// yamlNodeMeta returns the flow mapping entries describing how a node is shown
+func yamlNodeMeta(node *TreeNode) []string {
+	meta := make([]string, 0, 2)
+	if node.omitted {
+		meta = append(meta, "omitted: true")
+	}
+	if node.excluded {
+		meta = append(meta, "excluded: true")
+	}
+	if node.note != "" {
+		meta = append(meta, "note: "+yaml.Quote(node.note))
+	}
+	if node.more > 0 {
+		meta = append(meta, fmt.Sprintf("more: %d", node.more))
+	}
+	return meta
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+)
+
+// progressInterval is the minimum time between two updates of the progress line
+const progressInterval = 100 * time.Millisecond
+
+// maxProgressDir bounds the directory shown, keeping the line on one row
+const maxProgressDir = 50
+
+// progress shows on stderr, rewritten in place, how far a run got: files
+// scanned and the current directory while walking, then bytes written
+type progress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	last    time.Time
+	width   int   // Length of the line shown, to blank it out
+	written int64 // Bytes of output written
+}
+
+// newProgress returns the progress line of a run writing to outputPath, or
+// nil when it is not shown: with --quiet or -v, when the snapshot goes to
+// stdout, or when stderr is not a terminal the line could be rewritten on
+func newProgress(opts *cliOptions, outputPath string) *progress {
+	if opts.quiet || opts.verbosity > 0 || outputPath == "" {
+		return nil
+	}
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progress{out: os.Stderr}
+}
+
+// scanning is the OnProgress handler of the walk
+func (p *progress) scanning(dir string, files int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.due() {
+		p.show(i18n.Default.Sprintf("Scanning: %d files, %s", files, shortenDir(dir)))
+	}
+}
+
+// writer returns w counting the bytes written to it on the progress line
+func (p *progress) writer(w io.Writer) io.Writer {
+	return &progressWriter{p: p, w: w}
+}
+
+// progressWriter counts the output written for progress
+type progressWriter struct {
+	p *progress
+	w io.Writer
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	p := pw.p
+	p.mu.Lock()
+	p.written += int64(n)
+	if p.due() {
+		p.show(i18n.Default.Sprintf("Writing: %.1f MB", float64(p.written)/(1<<20)))
+	}
+	p.mu.Unlock()
+	return n, err
+}
+
+// due reports whether the line is to be updated now
+func (p *progress) due() bool {
+	now := time.Now()
+	if now.Sub(p.last) < progressInterval {
+		return false
+	}
+	p.last = now
+	return true
+}
+
+// show replaces the line shown by line. Spaces rather than terminal escape
+// sequences blank out the rest of a longer earlier line, which works in any
+// console.
+func (p *progress) show(line string) {
+	width := utf8.RuneCountInString(line)
+	fmt.Fprintf(p.out, "\r%s%s", line, strings.Repeat(" ", max(0, p.width-width)))
+	p.width = width
+}
+
+// clear removes the line before other messages are written. It does nothing
+// on a nil progress, so callers need not check whether it is shown.
+func (p *progress) clear() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.width > 0 {
+		fmt.Fprintf(p.out, "\r%s\r", strings.Repeat(" ", p.width))
+		p.width = 0
+	}
+}
+
+// shortenDir keeps the end of a long directory path, which names the
+// directory being walked
+func shortenDir(dir string) string {
+	runes := []rune(dir)
+	if len(runes) <= maxProgressDir {
+		return dir
+	}
+	return "…" + string(runes[len(runes)-maxProgressDir+1:])
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ananth-ar/dirMapper/internal/i18n"
+	"github.com/ananth-ar/dirMapper/pkg/mapper"
+)
+
+// sizeFlag is a byte count given with an optional unit, e.g. 200KB or 1.5MB
+type sizeFlag int64
+
+// sizeUnits are the units a sizeFlag accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+func (s *sizeFlag) String() string {
+	if s == nil || *s == 0 {
+		return ""
+	}
+	return mapper.FormatSize(int64(*s))
+}
+
+func (s *sizeFlag) Set(value string) error {
+	number, unit := strings.TrimSpace(value), 1.0
+	for _, u := range sizeUnits {
+		if rest, ok := strings.CutSuffix(strings.ToUpper(number), u.suffix); ok {
+			number, unit = strings.TrimSpace(rest), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q, expected e.g. 200KB or 1.5MB", value)
+	}
+	*s = sizeFlag(n * unit)
+	return nil
+}
+
+// splitting reports whether the snapshot is split into parts
+func splitting(opts *cliOptions) bool {
+	return opts.splitSize > 0 || opts.splitTokens > 0
+}
+
+// writeSplitSnapshot maps root and writes it as parts named after
+// outputPath, removing parts left over by an earlier run with more of them
+func writeSplitSnapshot(root, outputPath string, opts *cliOptions) (*mapper.Tree, error) {
+	switch {
+	case outputPath == "":
+		return nil, errors.New("--split-size and --split-tokens need an output file")
+	case opts.format != mapper.FormatText:
+		return nil, errors.New("--split-size and --split-tokens only apply to --format text")
+	case opts.checkpoint != "" || opts.Footer || opts.FileIndex:
+		return nil, errors.New("--split-size and --split-tokens cannot be combined with --checkpoint, --footer or --file-index")
+	}
+	if abs, err := filepath.Abs(outputPath); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil {
+			opts.ExcludePath = filepath.ToSlash(rel)
+		}
+	}
+
+	progress := newProgress(opts, outputPath)
+	defer progress.clear()
+	if progress != nil {
+		opts.OnProgress = progress.scanning
+	}
+	tree, err := mapper.ScanContext(opts.ctx, root, &opts.Options)
+	if err != nil {
+		if opts.ctx.Err() != nil {
+			return nil, errInterrupted
+		}
+		return nil, err
+	}
+	parts := tree.SplitParts(int64(opts.splitSize), opts.splitTokens)
+	progress.clear()
+
+	for i := range parts {
+		if opts.ctx.Err() != nil {
+			return nil, errInterrupted
+		}
+		path := mapper.PartPath(outputPath, i+1)
+		if err := writePart(tree, parts, i, path); err != nil {
+			return nil, err
+		}
+		if !opts.quiet {
+			i18n.Default.Fprintf(os.Stderr, "Part %d of %d has been written to %s\n", i+1, len(parts), path)
+		}
+	}
+	for n := len(parts) + 1; ; n++ {
+		if err := os.Remove(mapper.PartPath(outputPath, n)); err != nil {
+			break
+		}
+	}
+	return tree, nil
+}
+
+// writePart writes the i-th of parts to path
+func writePart(tree *mapper.Tree, parts []*mapper.Part, i int, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer file.Close()
+	buffered := bufio.NewWriterSize(file, 256<<10)
+	if err := tree.RenderPart(buffered, parts, i); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing output: %v", err)
+	}
+	return file.Close()
+}